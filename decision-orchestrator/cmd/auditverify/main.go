@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/config"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/log"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+// auditverify walks the per-payment hash chains of the audit trail and
+// recomputes the sealed daily roots, exiting non-zero if anything is broken.
+func main() {
+	payment := flag.String("payment", "", "only verify the chain of this payment")
+	from := flag.String("from", "", "first sealed day to recheck (YYYY-MM-DD)")
+	to := flag.String("to", "", "last sealed day to recheck (YYYY-MM-DD)")
+	pubKeyFile := flag.String("public-key", "", "PEM Ed25519 public key used to check root signatures")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	logger, err := log.New(cfg.LogLevel)
+	if err != nil {
+		panic(err)
+	}
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		logger.Fatal("mongo connect failed", zap.Error(err))
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(cfg.MongoDB)
+	records := audit.NewAuditRepo(db)
+
+	var verifier audit.ChainVerifier
+	if err := records.Scan(ctx, *payment, func(rec audit.Record) error {
+		verifier.Add(rec)
+		return nil
+	}); err != nil {
+		logger.Fatal("audit scan failed", zap.Error(err))
+	}
+	for _, b := range verifier.Breaks {
+		logger.Error("chain break", zap.String("payment_id", b.PaymentID), zap.Int64("seq", b.Seq), zap.String("record_id", b.RecordID), zap.String("problem", b.Problem))
+	}
+	logger.Info("chains verified", zap.Int("records", verifier.Checked), zap.Int("breaks", len(verifier.Breaks)))
+	failed := len(verifier.Breaks) > 0
+
+	if *payment == "" {
+		pub, err := publicKey(*pubKeyFile, cfg.AuditSigningKey)
+		if err != nil {
+			logger.Fatal("public key load failed", zap.Error(err))
+		}
+		if pub == nil {
+			logger.Warn("no public key given, root signatures are not checked")
+		}
+		bad, err := verifyRoots(ctx, logger, records, audit.NewRootRepo(db), pub, *from, *to)
+		if err != nil {
+			logger.Fatal("root verification failed", zap.Error(err))
+		}
+		failed = failed || bad > 0
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func verifyRoots(ctx context.Context, logger *zap.Logger, records *audit.AuditRepo, roots *audit.RootRepo, pub ed25519.PublicKey, from, to string) (int, error) {
+	stored, err := roots.List(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	var bad int
+	for _, root := range stored {
+		day, err := time.Parse("2006-01-02", root.Day)
+		if err != nil {
+			return bad, err
+		}
+		fresh, err := audit.ComputeRoot(ctx, records, day)
+		if err != nil {
+			return bad, err
+		}
+		if fresh.Root != root.Root || fresh.LeafCount != root.LeafCount {
+			logger.Error("daily root mismatch", zap.String("day", root.Day), zap.String("sealed", root.Root), zap.String("computed", fresh.Root), zap.Int("sealed_records", root.LeafCount), zap.Int("records", fresh.LeafCount))
+			bad++
+			continue
+		}
+		if pub != nil && !audit.VerifyRoot(pub, root) {
+			logger.Error("daily root signature invalid", zap.String("day", root.Day), zap.String("key_id", root.KeyID))
+			bad++
+		}
+	}
+	logger.Info("daily roots verified", zap.Int("roots", len(stored)), zap.Int("bad", bad))
+	return bad, nil
+}
+
+// publicKey prefers an explicit public key file and falls back to deriving it
+// from the configured signing key.
+func publicKey(pubKeyFile, signingKeyFile string) (ed25519.PublicKey, error) {
+	if pubKeyFile == "" {
+		if signingKeyFile == "" {
+			return nil, nil
+		}
+		signer, err := audit.LoadSigner(signingKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return signer.PublicKey(), nil
+	}
+
+	raw, err := os.ReadFile(pubKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("public key must be ed25519")
+	}
+	return pub, nil
+}
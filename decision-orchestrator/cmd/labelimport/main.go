@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/config"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/feedback"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/log"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+// labelimport attaches fraud labels from a CSV file to payments, the same
+// way the chargebacks consumer does. Rows already imported are skipped, so
+// a failed import can simply be run again.
+func main() {
+	file := flag.String("file", "", "CSV file with id,payment_id,fraud,occurred_at[,reason_code,amount,source]")
+	source := flag.String("source", "import", "label source for rows without a source column")
+	dryRun := flag.Bool("dry-run", false, "parse and validate the file without writing labels")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	logger, err := log.New(cfg.LogLevel)
+	if err != nil {
+		panic(err)
+	}
+	if *file == "" {
+		logger.Fatal("-file is required")
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		logger.Fatal("open import file failed", zap.Error(err))
+	}
+	defer f.Close()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		logger.Fatal("mongo connect failed", zap.Error(err))
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(cfg.MongoDB)
+	ingester := feedback.NewIngester(logger, repo.NewPaymentRepo(db), audit.NewAuditRepo(db))
+
+	var added, skipped, rejected int
+	err = feedback.ReadCSV(f, *source, func(line int, m feedback.LabelMessage) error {
+		var ok bool
+		var err error
+		if *dryRun {
+			ok, err = true, m.Validate()
+		} else {
+			ok, err = ingester.Attach(ctx, m)
+		}
+		switch {
+		case errors.Is(err, feedback.ErrInvalidLabel), errors.Is(err, repo.ErrPaymentNotFound):
+			logger.Warn("row rejected", zap.Int("line", line), zap.Error(err))
+			rejected++
+			return nil
+		case err != nil:
+			return err
+		case ok:
+			added++
+		default:
+			skipped++
+		}
+		return nil
+	})
+	logger.Info("label import finished", zap.Int("added", added), zap.Int("already_present", skipped), zap.Int("rejected", rejected), zap.Bool("dry_run", *dryRun))
+	if err != nil {
+		logger.Fatal("label import failed", zap.Error(err))
+	}
+	if rejected > 0 {
+		os.Exit(1)
+	}
+}
@@ -9,11 +9,26 @@ import (
 	"time"
 
 	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/app"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/auth"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/codec"
 	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/config"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/experiment"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/feedback"
 	httpHandler "github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/http"
 	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/kafka"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/lease"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/lists"
 	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/log"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/model"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/money"
 	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/observability"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/outbox"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/reasons"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/rules"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/velocity"
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"go.uber.org/zap"
@@ -39,6 +54,12 @@ func main() {
 	}
 	defer shutdown(ctx)
 
+	shutdownMeter, err := observability.InitMeter(ctx, cfg.AppName, cfg.OTELEndpoint)
+	if err != nil {
+		logger.Fatal("otel meter init failed", zap.Error(err))
+	}
+	defer shutdownMeter(ctx)
+
 	mongoClient, err := mongo.Connect(options.Client().ApplyURI(cfg.MongoURI))
 	if err != nil {
 		logger.Fatal("mongo connect failed", zap.Error(err))
@@ -46,16 +67,202 @@ func main() {
 
 	db := mongoClient.Database(cfg.MongoDB)
 
+	payments := repo.NewPaymentRepo(db)
+	if err := payments.EnsureIndexes(ctx); err != nil {
+		logger.Fatal("payment index creation failed", zap.Error(err))
+	}
+
+	auditTrail := audit.NewAuditRepo(db)
+	if err := auditTrail.EnsureIndexes(ctx); err != nil {
+		logger.Fatal("audit index creation failed", zap.Error(err))
+	}
+
+	if err := outbox.NewOutboxRepo(db).EnsureIndexes(ctx); err != nil {
+		logger.Fatal("outbox index creation failed", zap.Error(err))
+	}
+
+	if err := repo.NewOverrideRepo(db).EnsureIndexes(ctx); err != nil {
+		logger.Fatal("override index creation failed", zap.Error(err))
+	}
+
+	riskLists := lists.NewListRepo(db)
+	if err := riskLists.EnsureIndexes(ctx); err != nil {
+		logger.Fatal("list index creation failed", zap.Error(err))
+	}
+	listCache := lists.NewCache(logger, riskLists, cfg.ListsReload)
+	if err := listCache.Load(ctx); err != nil {
+		logger.Fatal("list load failed", zap.Error(err))
+	}
+	go listCache.Run(ctx)
+
+	policies := repo.NewMerchantPolicyRepo(db)
+	if err := policies.EnsureIndexes(ctx); err != nil {
+		logger.Fatal("merchant policy index creation failed", zap.Error(err))
+	}
+
+	experiments := experiment.NewExperimentRepo(db)
+	if err := experiments.EnsureIndexes(ctx); err != nil {
+		logger.Fatal("experiment index creation failed", zap.Error(err))
+	}
+	assigner := experiment.NewAssigner(logger, experiments, cfg.ExperimentsReload)
+	if err := assigner.Load(ctx); err != nil {
+		logger.Fatal("experiment load failed", zap.Error(err))
+	}
+	go assigner.Run(ctx)
+
+	var counters velocity.Store
+	switch cfg.VelocityStore {
+	case "memory":
+		logger.Warn("velocity counters kept in memory, they are per replica and lost on restart")
+		counters = velocity.NewMemoryStore()
+	default:
+		store := velocity.NewMongoStore(db)
+		if err := store.EnsureIndexes(ctx); err != nil {
+			logger.Fatal("velocity index creation failed", zap.Error(err))
+		}
+		counters = store
+	}
+
+	reasonCodes, err := reasons.Load(cfg.ReasonCodesFile)
+	if err != nil {
+		logger.Fatal("reason code registry load failed", zap.Error(err))
+	}
+
 	producer := kafka.New(cfg.KafkaBrokers, cfg.ProducerRetries, cfg.ProducerTimeout)
 	defer producer.Close()
 
-	orch := app.NewOrchestrator(logger, db, producer, cfg.OutboxTopic)
+	codecs, err := codec.Load(cfg.SchemaRegistry, cfg.TopicCodecs)
+	if err != nil {
+		logger.Fatal("codec init failed", zap.Error(err))
+	}
+
+	orchOpts := []app.Option{app.WithCodecs(codecs), app.WithRiskRequestTopic(cfg.RiskRequestTopic), app.WithDualControl(cfg.DualControlAbove, cfg.ReportingCurrency), app.WithLists(listCache), app.WithVelocity(counters), app.WithExperiments(assigner), app.WithReasonCodes(reasonCodes), app.WithChallengeWindow(cfg.ChallengeWindow)}
+	if cfg.EventFormat == "cloudevents" {
+		if cfg.CloudEventsMode == "structured" && codecs.ContentType(cfg.OutboxTopic) != "application/json" {
+			logger.Fatal("structured cloudevents require the json codec on the outbox topic")
+		}
+		orchOpts = append(orchOpts, app.WithCloudEvents(cfg.AppName, cfg.CloudEventsMode))
+	}
+	var ruleSource rules.Source
+	switch {
+	case cfg.RulesSource == "mongo":
+		ruleSource = rules.NewMongoSource(db)
+	case cfg.RulesFile != "":
+		ruleSource = rules.NewFileSource(cfg.RulesFile)
+	}
+	var ruleSet *rules.Reloader
+	if ruleSource != nil {
+		ruleSet, err = rules.NewReloader(ctx, logger, ruleSource, rules.Inputs{Payments: payments, Velocity: counters}, cfg.RulesReload)
+		if err != nil {
+			logger.Fatal("rules load failed", zap.Error(err))
+		}
+		if _, version := ruleSet.Current(); version == "" {
+			logger.Warn("no rule set stored yet, local rule evaluations fail until one is saved with PUT /rules")
+		}
+		go ruleSet.Run(ctx)
+		orchOpts = append(orchOpts, app.WithRules(ruleSet))
+	}
+
+	var scorer *model.Scorer
+	if cfg.ModelFile != "" {
+		scorer, err = model.NewScorer(ctx, logger, cfg.ModelFile, counters, cfg.ModelReload)
+		if err != nil {
+			logger.Fatal("model load failed", zap.Error(err))
+		}
+		go scorer.Run(ctx)
+		logger.Info("local model loaded", zap.String("version", scorer.Version()))
+		orchOpts = append(orchOpts, app.WithModel(scorer))
+	}
+
+	if cfg.FXRatesFile != "" {
+		fx, err := money.NewConverter(ctx, logger, cfg.FXRatesFile, cfg.ReportingCurrency, cfg.FXReload)
+		if err != nil {
+			logger.Fatal("fx rates load failed", zap.Error(err))
+		}
+		go fx.Run(ctx)
+		logger.Info("fx rates loaded", zap.String("reporting_currency", cfg.ReportingCurrency), zap.Strings("currencies", fx.Currencies()))
+		orchOpts = append(orchOpts, app.WithFX(fx))
+	}
+
+	var shadowDecisions *repo.ShadowRepo
+	if cfg.ShadowMode != "" {
+		if cfg.ShadowMode == app.ShadowRules && ruleSet == nil {
+			logger.Fatal("shadow mode rules needs a rule set, set RULES_FILE or RULES_SOURCE=mongo")
+		}
+		shadowDecisions = repo.NewShadowRepo(db)
+		if err := shadowDecisions.EnsureIndexes(ctx); err != nil {
+			logger.Fatal("shadow index creation failed", zap.Error(err))
+		}
+		orchOpts = append(orchOpts, app.WithShadow(cfg.ShadowMode))
+	}
+
+	orch := app.NewOrchestrator(logger, db, producer, cfg.OutboxTopic, orchOpts...)
 
-	consumer := kafka.NewConsumer(cfg.KafkaBrokers, cfg.KafkaGroupID, cfg.RiskDecisionTopic, orch.HandleRiskDecision)
+	consumer := kafka.NewConsumer(cfg.KafkaBrokers, cfg.KafkaGroupID, cfg.RiskDecisionTopic, orch.HandleRiskDecision).WithCodecs(codecs).WithLogger(logger)
+	labels := feedback.NewIngester(logger, payments, auditTrail)
+	chargebackConsumer := kafka.NewConsumer(cfg.KafkaBrokers, cfg.KafkaGroupID+"-chargebacks", cfg.ChargebackTopic, labels.Handle).WithCodecs(codecs).WithLogger(logger)
+	refundConsumer := kafka.NewConsumer(cfg.KafkaBrokers, cfg.KafkaGroupID+"-refunds", cfg.RefundTopic, orch.HandleRefund).WithCodecs(codecs).WithLogger(logger)
+	challengeConsumer := kafka.NewConsumer(cfg.KafkaBrokers, cfg.KafkaGroupID+"-challenges", cfg.ChallengeTopic, orch.HandleChallengeResult).WithCodecs(codecs).WithLogger(logger)
+	var shadowConsumer *kafka.Consumer
+	if cfg.ShadowMode == app.ShadowTopic {
+		shadowConsumer = kafka.NewConsumer(cfg.KafkaBrokers, cfg.KafkaGroupID+"-shadow", cfg.ShadowTopic, orch.HandleShadowDecision).WithCodecs(codecs).WithLogger(logger)
+	}
+
+	health := httpHandler.HealthHandler()
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", health)
+	mux.Handle("/readyz", health)
+	httpHandler.NewPaymentHandler(logger, payments).Register(mux)
+	httpHandler.NewIntakeHandler(logger, orch).Register(mux)
+	httpHandler.NewOverrideHandler(logger, orch).Register(mux)
+	httpHandler.NewRefundHandler(logger, orch).Register(mux)
+	httpHandler.NewMerchantPolicyHandler(logger, policies).Register(mux)
+	httpHandler.NewListHandler(logger, riskLists).Register(mux)
+	httpHandler.NewVelocityHandler(logger, counters).Register(mux)
+	httpHandler.NewExperimentHandler(logger, experiments).Register(mux)
+	httpHandler.NewReasonCodeHandler(reasonCodes).Register(mux)
+	httpHandler.NewReportHandler(logger, feedback.NewReporter(db, cfg.ReportingCurrency)).Register(mux)
+	if ruleSet != nil {
+		httpHandler.NewRulesHandler(logger, ruleSet).Register(mux)
+	}
+	if scorer != nil {
+		httpHandler.NewModelHandler(logger, scorer).Register(mux)
+	}
+	if shadowDecisions != nil {
+		httpHandler.NewShadowHandler(logger, shadowDecisions).Register(mux)
+	}
+	auditHandler := httpHandler.NewAuditHandler(logger, auditTrail)
+	var signer *audit.Signer
+	if cfg.AuditSigningKey != "" {
+		if signer, err = audit.LoadSigner(cfg.AuditSigningKey); err != nil {
+			logger.Fatal("audit signing key load failed", zap.Error(err))
+		}
+		auditHandler.WithRoots(audit.NewRootRepo(db), signer.PublicKey())
+	}
+	auditHandler.Register(mux)
+
+	authn, err := auth.New(auth.Settings{
+		APIKeysFile: cfg.AuthAPIKeysFile,
+		HMACSecret:  cfg.AuthHMACSecret,
+		JWKSFile:    cfg.AuthJWKSFile,
+		JWTIssuer:   cfg.AuthJWTIssuer,
+		JWTAudience: cfg.AuthJWTAudience,
+		RoleClaim:   cfg.AuthRoleClaim,
+	})
+	if err != nil {
+		logger.Fatal("auth init failed", zap.Error(err))
+	}
+	authz := auth.NewMiddleware(logger, authn, httpHandler.RoutePolicy)
+	if cfg.AuthDisabled {
+		logger.Warn("authentication disabled, every caller is treated as admin")
+		authz.Disable()
+	} else if len(authn) == 0 {
+		logger.Warn("no authenticators configured, protected routes will reject every request")
+	}
 
 	srv := &http.Server{
 		Addr:    cfg.HTTPAddr,
-		Handler: httpHandler.HealthHandler(),
+		Handler: authz.Wrap(mux),
 	}
 
 	go func() {
@@ -72,6 +279,77 @@ func main() {
 		}
 	}()
 
+	go func() {
+		logger.Info("chargeback consumer running", zap.String("topic", cfg.ChargebackTopic))
+		if err := chargebackConsumer.Run(ctx); err != nil {
+			logger.Fatal("chargeback consumer failed", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		logger.Info("refund consumer running", zap.String("topic", cfg.RefundTopic))
+		if err := refundConsumer.Run(ctx); err != nil {
+			logger.Fatal("refund consumer failed", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		logger.Info("challenge result consumer running", zap.String("topic", cfg.ChallengeTopic))
+		if err := challengeConsumer.Run(ctx); err != nil {
+			logger.Fatal("challenge result consumer failed", zap.Error(err))
+		}
+	}()
+
+	if shadowConsumer != nil {
+		go func() {
+			logger.Info("shadow consumer running", zap.String("topic", cfg.ShadowTopic))
+			if err := shadowConsumer.Run(ctx); err != nil {
+				logger.Fatal("shadow consumer failed", zap.Error(err))
+			}
+		}()
+	}
+
+	leases := lease.NewLeaseRepo(db, cfg.InstanceID+":"+uuid.NewString(), cfg.LeaseTTL)
+
+	if cfg.SweeperEnabled {
+		policy := app.TimeoutPolicy{SLA: cfg.DecisionSLA, Action: cfg.TimeoutPolicy, ApproveUnder: cfg.TimeoutApproveMax, Currency: cfg.ReportingCurrency}
+		sweeper, err := app.NewSweeper(logger, orch, policy, cfg.SweeperInterval, cfg.SweeperBatch)
+		if err != nil {
+			logger.Fatal("sweeper init failed", zap.Error(err))
+		}
+		elector := lease.NewElector(logger, leases, "timeout-sweeper")
+		go func() {
+			logger.Info("timeout sweeper waiting for lease", zap.Duration("sla", cfg.DecisionSLA), zap.String("policy", cfg.TimeoutPolicy))
+			_ = elector.Run(ctx, sweeper.Run)
+		}()
+	}
+
+	// challenges expire even with the sweeper disabled, nothing else ends them
+	expirer, err := app.NewChallengeExpirer(logger, orch, cfg.SweeperInterval, cfg.SweeperBatch)
+	if err != nil {
+		logger.Fatal("challenge expiry init failed", zap.Error(err))
+	}
+	expiryElector := lease.NewElector(logger, leases, "challenge-expiry")
+	go func() {
+		_ = expiryElector.Run(ctx, expirer.Run)
+	}()
+
+	if cfg.RelayEnabled {
+		relay := app.NewRelay(logger, orch, cfg.RelayInterval, cfg.RelayGrace, cfg.SweeperBatch)
+		elector := lease.NewElector(logger, leases, "outbox-relay")
+		go func() {
+			_ = elector.Run(ctx, relay.Run)
+		}()
+	}
+
+	if signer != nil {
+		rootJob := audit.NewRootJob(logger, auditTrail, audit.NewRootRepo(db), signer, cfg.AuditRootInterval)
+		elector := lease.NewElector(logger, leases, "audit-roots")
+		go func() {
+			_ = elector.Run(ctx, rootJob.Run)
+		}()
+	}
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 	<-sig
@@ -79,6 +357,12 @@ func main() {
 	logger.Info("shutting down")
 	_ = srv.Shutdown(context.Background())
 	_ = consumer.Close()
+	_ = chargebackConsumer.Close()
+	_ = refundConsumer.Close()
+	_ = challengeConsumer.Close()
+	if shadowConsumer != nil {
+		_ = shadowConsumer.Close()
+	}
 	_ = mongoClient.Disconnect(context.Background())
 	time.Sleep(300 * time.Millisecond)
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/app"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/codec"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/config"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/kafka"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/log"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/outbox"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+// replay re-publishes stored outbox events, upcasting old payloads to the
+// current schema version of their type before they hit the topic.
+func main() {
+	from := flag.String("from", "", "RFC3339 start of the created_at range (inclusive)")
+	to := flag.String("to", "", "RFC3339 end of the created_at range (exclusive), defaults to now")
+	unpublished := flag.Bool("unpublished", false, "only replay events never marked published")
+	dryRun := flag.Bool("dry-run", false, "upcast and log events without publishing")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	logger, err := log.New(cfg.LogLevel)
+	if err != nil {
+		panic(err)
+	}
+
+	start, err := time.Parse(time.RFC3339, *from)
+	if err != nil {
+		logger.Fatal("invalid -from", zap.Error(err))
+	}
+	end := time.Now()
+	if *to != "" {
+		if end, err = time.Parse(time.RFC3339, *to); err != nil {
+			logger.Fatal("invalid -to", zap.Error(err))
+		}
+	}
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		logger.Fatal("mongo connect failed", zap.Error(err))
+	}
+	defer mongoClient.Disconnect(ctx)
+
+	db := mongoClient.Database(cfg.MongoDB)
+
+	codecs, err := codec.Load(cfg.SchemaRegistry, cfg.TopicCodecs)
+	if err != nil {
+		logger.Fatal("codec init failed", zap.Error(err))
+	}
+
+	producer := kafka.New(cfg.KafkaBrokers, cfg.ProducerRetries, cfg.ProducerTimeout)
+	defer producer.Close()
+
+	orchOpts := []app.Option{app.WithCodecs(codecs), app.WithRiskRequestTopic(cfg.RiskRequestTopic)}
+	if cfg.EventFormat == "cloudevents" {
+		orchOpts = append(orchOpts, app.WithCloudEvents(cfg.AppName, cfg.CloudEventsMode))
+	}
+	orch := app.NewOrchestrator(logger, db, producer, cfg.OutboxTopic, orchOpts...)
+
+	var replayed, failed int
+	err = outbox.NewOutboxRepo(db).Scan(ctx, start, end, *unpublished, func(event outbox.OutboxEvent) error {
+		if *dryRun {
+			up, err := app.UpcastEvent(event)
+			if err != nil {
+				logger.Error("would fail to upcast", zap.String("id", event.ID), zap.String("type", event.Type), zap.Error(err))
+				failed++
+				return nil
+			}
+			logger.Info("would replay", zap.String("id", event.ID), zap.String("type", event.Type),
+				zap.Int("stored_version", event.SchemaVersion), zap.Int("schema_version", up.SchemaVersion))
+			replayed++
+			return nil
+		}
+		if err := orch.Republish(ctx, event); err != nil {
+			return err
+		}
+		replayed++
+		return nil
+	})
+	if err != nil {
+		logger.Fatal("replay failed", zap.Error(err), zap.Int("replayed", replayed))
+	}
+	logger.Info("replay finished", zap.Int("replayed", replayed), zap.Int("failed", failed))
+}
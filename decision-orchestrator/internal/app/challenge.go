@@ -0,0 +1,239 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/events"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/outbox"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/reasons"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	segmentioKafka "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// DecisionChallenge is the risk engine's answer asking for step-up
+// authentication instead of approving or declining.
+const DecisionChallenge = "CHALLENGE"
+
+const DefaultChallengeWindow = 15 * time.Minute
+
+// ChallengeResultMessage is one entry on the challenge results topic.
+type ChallengeResultMessage struct {
+	PaymentID  string               `json:"payment_id"`
+	Result     repo.ChallengeResult `json:"result"`
+	OccurredAt time.Time            `json:"occurred_at"`
+}
+
+var challengeReasonCodes = map[repo.ChallengeResult]string{
+	repo.ChallengePassed:    reasons.ChallengePassed,
+	repo.ChallengeFailed:    reasons.ChallengeFailed,
+	repo.ChallengeAbandoned: reasons.ChallengeAbandoned,
+	repo.ChallengeExpired:   reasons.ChallengeExpired,
+}
+
+// requireChallenge stores a CHALLENGE decision and tells the merchant to
+// challenge the customer. The payment stays open until the result arrives
+// or the sweeper expires the challenge.
+func (o *Orchestrator) requireChallenge(ctx context.Context, p repo.Payment, rd RiskDecision, v verdict, exp repo.Explanation, coords *audit.KafkaCoordinates) error {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	ch := repo.Challenge{RequestedAt: now, ExpiresAt: now.Add(o.challengeTTL)}
+	err := o.payments.RequireChallenge(ctx, p.ID, rd.Score, v.reason, exp, ch)
+	if errors.Is(err, repo.ErrStaleStatus) {
+		// either decided otherwise or a redelivery of this decision; the
+		// latter writes its event again with the challenge it stored
+		cur, err := o.payments.Get(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		if !challengeApplied(*cur, rd.Score, v.reason) {
+			o.log.Warn("late risk decision ignored", zap.String("payment_id", p.ID))
+			return nil
+		}
+		ch = *cur.Challenge
+	} else if err != nil {
+		o.log.Error("failed to store challenge", zap.Error(err), zap.String("payment_id", p.ID))
+		return err
+	} else {
+		modelVersion, modelScore := modelFields(exp.Model)
+		o.record(ctx, audit.Record{
+			PaymentID:      p.ID,
+			Kind:           audit.KindDecision,
+			Actor:          audit.ActorRiskEngine,
+			PreviousStatus: string(repo.StatusPending),
+			NewStatus:      string(repo.StatusChallengeRequired),
+			Score:          rd.Score,
+			Reason:         v.reason,
+			CorrelationID:  rd.CorrelationID,
+			Kafka:          coords,
+			ReasonCodes:    v.reasonCodes,
+			ModelVersion:   modelVersion,
+			ModelScore:     modelScore,
+		})
+		o.compareShadow(ctx, p, rd, v)
+	}
+
+	eventPayload, _ := json.Marshal(events.PaymentChallengeRequired{
+		SchemaVersion: events.Default().Current(events.TypePaymentChallengeRequired),
+		Type:          events.TypePaymentChallengeRequired,
+		PaymentID:     p.ID,
+		MerchantID:    p.MerchantID,
+		Amount:        p.Amount,
+		Currency:      p.Currency,
+		Score:         rd.Score,
+		Reason:        v.reason,
+		ReasonCodes:   v.reasonCodes,
+		ExpiresAt:     ch.ExpiresAt,
+		CorrelationID: p.CorrelationID,
+		OccurredAt:    ch.RequestedAt,
+	})
+	event := outbox.OutboxEvent{
+		ID:            p.ID + ":challenge:" + p.CorrelationID,
+		AggregateID:   p.ID,
+		Type:          events.TypePaymentChallengeRequired,
+		SchemaVersion: events.Default().Current(events.TypePaymentChallengeRequired),
+		Payload:       eventPayload,
+		Headers:       map[string]any{"content-type": "application/json"},
+		CreatedAt:     time.Now(),
+		Published:     false,
+		CorrelationID: p.CorrelationID,
+	}
+	if err := o.outbox.Insert(ctx, event); err != nil {
+		if errors.Is(err, outbox.ErrDuplicateEvent) {
+			return nil
+		}
+		o.log.Error("failed to insert outbox event", zap.Error(err))
+		return err
+	}
+	return o.publish(ctx, event)
+}
+
+// challengeApplied reports whether p, whose transition to a challenge went
+// stale, holds the challenge this decision asked for.
+func challengeApplied(p repo.Payment, score float64, reason string) bool {
+	return p.Status == repo.StatusChallengeRequired && p.Challenge != nil && p.RiskScore == score && p.RiskReason == reason
+}
+
+// HandleChallengeResult consumes the challenge results topic. Results that
+// occurred after the challenge expired count as expired; results without
+// occurred_at count as occurring when they arrive. Malformed messages
+// and results for payments no longer awaiting one are logged and skipped.
+func (o *Orchestrator) HandleChallengeResult(ctx context.Context, msg segmentioKafka.Message) error {
+	var m ChallengeResultMessage
+	if err := json.Unmarshal(msg.Value, &m); err != nil {
+		o.log.Error("invalid challenge result message", zap.Error(err))
+		return nil
+	}
+	if !reportable(m.Result) {
+		o.log.Warn("challenge result skipped", zap.String("payment_id", m.PaymentID), zap.String("result", string(m.Result)))
+		return nil
+	}
+
+	p, err := o.payments.Get(ctx, m.PaymentID)
+	if errors.Is(err, repo.ErrPaymentNotFound) {
+		o.log.Warn("challenge result for unknown payment ignored", zap.String("payment_id", m.PaymentID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	result, ok := challengeOutcome(*p, m, time.Now())
+	if !ok {
+		o.log.Warn("challenge result for payment not awaiting one ignored", zap.String("payment_id", p.ID), zap.String("status", string(p.Status)))
+		return nil
+	}
+	_, err = o.resolveChallenge(ctx, *p, result, audit.ActorSystem, nil)
+	return err
+}
+
+// reportable reports whether r is a result the challenge provider may send.
+// Expiry is only ever decided here.
+func reportable(r repo.ChallengeResult) bool {
+	return r == repo.ChallengePassed || r == repo.ChallengeFailed || r == repo.ChallengeAbandoned
+}
+
+// challengeOutcome returns the result m resolves p's challenge with, or
+// false when p is not awaiting a result.
+func challengeOutcome(p repo.Payment, m ChallengeResultMessage, now time.Time) (repo.ChallengeResult, bool) {
+	if p.Status != repo.StatusChallengeRequired || p.Challenge == nil {
+		return "", false
+	}
+	if challengeExpired(*p.Challenge, m.OccurredAt, now) {
+		return repo.ChallengeExpired, true
+	}
+	return m.Result, true
+}
+
+// challengeExpired reports whether a result that occurred at occurredAt
+// came too late for ch. Results without a time are judged by when they
+// arrive.
+func challengeExpired(ch repo.Challenge, occurredAt, now time.Time) bool {
+	if occurredAt.IsZero() {
+		occurredAt = now
+	}
+	return occurredAt.After(ch.ExpiresAt)
+}
+
+// challengeStatus is the final status for a challenge result: only a passed
+// challenge approves.
+func challengeStatus(result repo.ChallengeResult) repo.PaymentStatus {
+	if result == repo.ChallengePassed {
+		return repo.StatusApproved
+	}
+	return repo.StatusDeclined
+}
+
+// resolveChallenge finalizes p with the status for result. The boolean is
+// false when the challenge had already been resolved.
+func (o *Orchestrator) resolveChallenge(ctx context.Context, p repo.Payment, result repo.ChallengeResult, actor audit.Actor, headers map[string]any) (bool, error) {
+	status := challengeStatus(result)
+	code := challengeReasonCodes[result]
+	err := o.payments.ResolveChallenge(ctx, p.ID, status, result, code, time.Now().UTC().Truncate(time.Millisecond))
+	if errors.Is(err, repo.ErrStaleStatus) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	codes := append(slices.Clip(p.ReasonCodes), code)
+	reason := fmt.Sprintf("challenge %s", result)
+	o.record(ctx, audit.Record{
+		PaymentID:      p.ID,
+		Kind:           audit.KindDecision,
+		Actor:          actor,
+		PreviousStatus: string(repo.StatusChallengeRequired),
+		NewStatus:      string(status),
+		Score:          p.RiskScore,
+		Reason:         reason,
+		CorrelationID:  p.CorrelationID,
+		ReasonCodes:    codes,
+	})
+
+	experimentID, arm := experimentOf(p)
+	event := finalizedEvent(p.ID+":challenge-result:"+p.CorrelationID, events.PaymentDecisionFinalized{
+		PaymentID:     p.ID,
+		Status:        string(status),
+		Score:         p.RiskScore,
+		Reason:        reason,
+		CorrelationID: p.CorrelationID,
+		TimedOut:      result == repo.ChallengeExpired,
+		ExperimentID:  experimentID,
+		ExperimentArm: arm,
+		MerchantID:    p.MerchantID,
+		ReasonCodes:   codes,
+		Contributions: eventContributions(p.Contributions),
+	})
+	for k, v := range headers {
+		event.Headers[k] = v
+	}
+	if err := o.outbox.Insert(ctx, event); err != nil && !errors.Is(err, outbox.ErrDuplicateEvent) {
+		return true, err
+	}
+	return true, o.publish(ctx, event)
+}
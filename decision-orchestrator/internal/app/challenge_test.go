@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	segmentioKafka "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+func TestChallengeExpired(t *testing.T) {
+	expires := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	ch := repo.Challenge{RequestedAt: expires.Add(-10 * time.Minute), ExpiresAt: expires}
+	tests := []struct {
+		name       string
+		occurredAt time.Time
+		now        time.Time
+		want       bool
+	}{
+		{"occurred in time, arrived late", expires.Add(-time.Second), expires.Add(time.Hour), false},
+		{"occurred at expiry", expires, expires.Add(time.Hour), false},
+		{"occurred late", expires.Add(time.Second), expires.Add(time.Second), true},
+		{"no time, arrived in time", time.Time{}, expires.Add(-time.Minute), false},
+		{"no time, arrived late", time.Time{}, expires.Add(time.Minute), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := challengeExpired(ch, tt.occurredAt, tt.now); got != tt.want {
+				t.Errorf("challengeExpired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChallengeOutcome(t *testing.T) {
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	open := repo.Payment{Status: repo.StatusChallengeRequired, Challenge: &repo.Challenge{RequestedAt: now.Add(-10 * time.Minute), ExpiresAt: now.Add(5 * time.Minute)}}
+	lapsed := repo.Payment{Status: repo.StatusChallengeRequired, Challenge: &repo.Challenge{RequestedAt: now.Add(-20 * time.Minute), ExpiresAt: now.Add(-5 * time.Minute)}}
+	resolved := repo.Payment{Status: repo.StatusApproved, Challenge: &repo.Challenge{ExpiresAt: now.Add(5 * time.Minute), Result: repo.ChallengePassed}}
+	tests := []struct {
+		name    string
+		payment repo.Payment
+		msg     ChallengeResultMessage
+		want    repo.ChallengeResult
+		ok      bool
+	}{
+		{"passed in time", open, ChallengeResultMessage{Result: repo.ChallengePassed, OccurredAt: now}, repo.ChallengePassed, true},
+		{"failed in time", open, ChallengeResultMessage{Result: repo.ChallengeFailed, OccurredAt: now}, repo.ChallengeFailed, true},
+		{"abandoned without a time", open, ChallengeResultMessage{Result: repo.ChallengeAbandoned}, repo.ChallengeAbandoned, true},
+		{"passed after expiry", lapsed, ChallengeResultMessage{Result: repo.ChallengePassed, OccurredAt: now}, repo.ChallengeExpired, true},
+		{"passed before expiry, arrived after", lapsed, ChallengeResultMessage{Result: repo.ChallengePassed, OccurredAt: now.Add(-6 * time.Minute)}, repo.ChallengePassed, true},
+		{"no time, arrived after expiry", lapsed, ChallengeResultMessage{Result: repo.ChallengePassed}, repo.ChallengeExpired, true},
+		{"already resolved", resolved, ChallengeResultMessage{Result: repo.ChallengeFailed, OccurredAt: now}, "", false},
+		{"never challenged", repo.Payment{Status: repo.StatusPending}, ChallengeResultMessage{Result: repo.ChallengePassed, OccurredAt: now}, "", false},
+		{"challenge status without a challenge", repo.Payment{Status: repo.StatusChallengeRequired}, ChallengeResultMessage{Result: repo.ChallengePassed}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := challengeOutcome(tt.payment, tt.msg, now)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("challengeOutcome = %q, %v; want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestChallengeStatus(t *testing.T) {
+	tests := []struct {
+		result repo.ChallengeResult
+		want   repo.PaymentStatus
+	}{
+		{repo.ChallengePassed, repo.StatusApproved},
+		{repo.ChallengeFailed, repo.StatusDeclined},
+		{repo.ChallengeAbandoned, repo.StatusDeclined},
+		{repo.ChallengeExpired, repo.StatusDeclined},
+	}
+	for _, tt := range tests {
+		if got := challengeStatus(tt.result); got != tt.want {
+			t.Errorf("challengeStatus(%s) = %s, want %s", tt.result, got, tt.want)
+		}
+		if challengeReasonCodes[tt.result] == "" {
+			t.Errorf("no reason code for %s", tt.result)
+		}
+	}
+}
+
+func TestChallengeApplied(t *testing.T) {
+	stored := repo.Payment{Status: repo.StatusChallengeRequired, RiskScore: 0.7, RiskReason: "new device", Challenge: &repo.Challenge{}}
+	tests := []struct {
+		name   string
+		modify func(*repo.Payment)
+		want   bool
+	}{
+		{"same decision redelivered", func(*repo.Payment) {}, true},
+		{"other score", func(p *repo.Payment) { p.RiskScore = 0.8 }, false},
+		{"other reason", func(p *repo.Payment) { p.RiskReason = "velocity" }, false},
+		{"since resolved", func(p *repo.Payment) { p.Status = repo.StatusApproved }, false},
+		{"decided otherwise", func(p *repo.Payment) { p.Status, p.Challenge = repo.StatusDeclined, nil }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := stored
+			tt.modify(&p)
+			if got := challengeApplied(p, 0.7, "new device"); got != tt.want {
+				t.Errorf("challengeApplied = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Messages that cannot resolve a challenge are dropped before the payment
+// is looked up; the orchestrator here has no payment store to look in.
+func TestHandleChallengeResultSkips(t *testing.T) {
+	o := &Orchestrator{log: zap.NewNop()}
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not json", `passed`},
+		{"no result", `{"payment_id": "p-1"}`},
+		{"unknown result", `{"payment_id": "p-1", "result": "maybe"}`},
+		{"expiry is not reported", `{"payment_id": "p-1", "result": "expired"}`},
+		{"result in other case", `{"payment_id": "p-1", "result": "PASSED"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := o.HandleChallengeResult(context.Background(), segmentioKafka.Message{Value: []byte(tt.value)}); err != nil {
+				t.Errorf("HandleChallengeResult = %v, want the message skipped", err)
+			}
+		})
+	}
+}
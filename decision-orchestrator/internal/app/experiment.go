@@ -0,0 +1,18 @@
+package app
+
+import "github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+
+// ExperimentAssigner buckets new payments into experiment arms. It returns
+// nil for payments no experiment covers.
+type ExperimentAssigner interface {
+	Assign(p repo.Payment) *repo.ExperimentAssignment
+}
+
+// experimentOf returns the experiment and arm p was assigned to, if any, in
+// the form events carry them.
+func experimentOf(p repo.Payment) (string, string) {
+	if p.Experiment == nil {
+		return "", ""
+	}
+	return p.Experiment.ExperimentID, p.Experiment.Arm
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/experiment"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+func TestExperimentOf(t *testing.T) {
+	if id, arm := experimentOf(repo.Payment{ID: "pay-1"}); id != "" || arm != "" {
+		t.Errorf("experimentOf without an assignment = %q, %q; want empty", id, arm)
+	}
+
+	e := experiment.Experiment{
+		ID:       "exp-1",
+		BucketBy: experiment.BucketByUser,
+		Arms: []experiment.Arm{
+			{Name: "control", Weight: 50},
+			{Name: "strict", Weight: 50, Policy: &repo.ArmPolicy{ApproveBelow: 0.2, DeclineFrom: 0.6}},
+		},
+	}
+	// events for one user's payments, and for retries of one payment, all
+	// carry the arm the user was bucketed into
+	arms := map[string]string{}
+	for i := range 50 {
+		user := fmt.Sprintf("u-%d", i%5)
+		p := repo.Payment{ID: fmt.Sprintf("pay-%d", i), UserID: user}
+		p.Experiment = e.Assign(p)
+
+		id, arm := experimentOf(p)
+		if id != "exp-1" || arm != p.Experiment.Arm {
+			t.Fatalf("experimentOf = %q, %q; want exp-1, %q", id, arm, p.Experiment.Arm)
+		}
+		if _, again := experimentOf(p); again != arm {
+			t.Fatalf("experimentOf changed arm from %q to %q", arm, again)
+		}
+		if prev, ok := arms[user]; ok && prev != arm {
+			t.Errorf("user %s moved from arm %q to %q", user, prev, arm)
+		}
+		arms[user] = arm
+	}
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/lease"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+// ChallengeExpirer declines payments whose challenge expired without a
+// result. It runs whether or not the timeout sweeper is enabled, since a
+// challenge has no other way to end. Like the sweeper it is meant to run
+// under a lease and its writes are fenced on the lease token.
+type ChallengeExpirer struct {
+	log      *zap.Logger
+	interval time.Duration
+	batch    int
+	expired  metric.Int64Counter
+
+	find    func(ctx context.Context, before time.Time, limit int) ([]repo.Payment, error)
+	resolve func(ctx context.Context, p repo.Payment, result repo.ChallengeResult, actor audit.Actor, headers map[string]any) (bool, error)
+}
+
+func NewChallengeExpirer(l *zap.Logger, orch *Orchestrator, interval time.Duration, batch int) (*ChallengeExpirer, error) {
+	counter, err := otel.Meter("decision-orchestrator").Int64Counter(
+		"payments.challenge_expiries",
+		metric.WithDescription("Payments declined because their challenge expired without a result"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ChallengeExpirer{
+		log:      l,
+		interval: interval,
+		batch:    batch,
+		expired:  counter,
+		find:     orch.payments.FindChallengesExpiredBefore,
+		resolve:  orch.resolveChallenge,
+	}, nil
+}
+
+func (e *ChallengeExpirer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			n, err := e.Expire(ctx)
+			if err != nil {
+				e.log.Error("challenge expiry failed", zap.Error(err))
+				continue
+			}
+			if n > 0 {
+				e.log.Info("expired challenges", zap.Int("count", n))
+			}
+		}
+	}
+}
+
+// Expire declines one batch of payments whose challenge expired and returns
+// how many this replica finalized.
+func (e *ChallengeExpirer) Expire(ctx context.Context) (int, error) {
+	expired, err := e.find(ctx, time.Now(), e.batch)
+	if err != nil {
+		return 0, err
+	}
+
+	var finalized int
+	for _, p := range expired {
+		if err := lease.Check(ctx); err != nil {
+			return finalized, err
+		}
+		var headers map[string]any
+		if token, ok := lease.Token(ctx); ok {
+			headers = map[string]any{"fencing_token": token}
+		}
+		ok, err := e.resolve(ctx, p, repo.ChallengeExpired, audit.ActorSweeper, headers)
+		if err != nil {
+			e.log.Error("failed to expire challenge", zap.Error(err), zap.String("payment_id", p.ID))
+			continue
+		}
+		if ok {
+			finalized++
+			e.expired.Add(ctx, 1)
+		}
+	}
+	return finalized, nil
+}
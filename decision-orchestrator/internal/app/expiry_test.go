@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+)
+
+func TestChallengeExpirerExpire(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+
+	e, err := NewChallengeExpirer(zap.NewNop(), &Orchestrator{}, time.Minute, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var limit int
+	e.find = func(_ context.Context, _ time.Time, n int) ([]repo.Payment, error) {
+		limit = n
+		return []repo.Payment{{ID: "p-expired"}, {ID: "p-resolved"}, {ID: "p-broken"}, {ID: "p-expired-2"}}, nil
+	}
+	var resolved []string
+	e.resolve = func(_ context.Context, p repo.Payment, result repo.ChallengeResult, actor audit.Actor, headers map[string]any) (bool, error) {
+		if result != repo.ChallengeExpired || actor != audit.ActorSweeper || headers != nil {
+			t.Errorf("resolve(%s) with %s by %s, headers %v; want expired by the sweeper without headers", p.ID, result, actor, headers)
+		}
+		resolved = append(resolved, p.ID)
+		switch p.ID {
+		case "p-resolved":
+			// a result arrived between the query and the write
+			return false, nil
+		case "p-broken":
+			return false, errors.New("connection reset")
+		}
+		return true, nil
+	}
+
+	n, err := e.Expire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || limit != 10 {
+		t.Errorf("Expire = %d with limit %d, want 2 with 10", n, limit)
+	}
+	// a failing payment does not hold up the rest of the batch
+	if len(resolved) != 4 {
+		t.Errorf("resolved %v, want all four payments tried", resolved)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, dp := range sum.DataPoints {
+					counts[m.Name] += dp.Value
+				}
+			}
+		}
+	}
+	if counts["payments.challenge_expiries"] != 2 {
+		t.Errorf("payments.challenge_expiries = %d, want 2", counts["payments.challenge_expiries"])
+	}
+	if _, ok := counts["payments.decision_timeouts"]; ok {
+		t.Error("challenge expiries counted as decision timeouts")
+	}
+}
+
+func TestChallengeExpirerFindFails(t *testing.T) {
+	e, err := NewChallengeExpirer(zap.NewNop(), &Orchestrator{}, time.Minute, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boom := errors.New("connection reset")
+	e.find = func(context.Context, time.Time, int) ([]repo.Payment, error) { return nil, boom }
+	e.resolve = func(context.Context, repo.Payment, repo.ChallengeResult, audit.Actor, map[string]any) (bool, error) {
+		t.Error("resolve called although nothing was found")
+		return false, nil
+	}
+	if n, err := e.Expire(context.Background()); n != 0 || !errors.Is(err, boom) {
+		t.Errorf("Expire = %d, %v; want 0 and the query error", n, err)
+	}
+}
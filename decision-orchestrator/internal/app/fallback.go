@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/rules"
+)
+
+var ErrNoRules = errors.New("local rule engine is not configured")
+
+// RuleEvaluator is satisfied by a fixed rules.Engine and by rules.Reloader.
+type RuleEvaluator interface {
+	Evaluate(ctx context.Context, p repo.Payment) (rules.Decision, error)
+}
+
+// EvaluateLocally scores a payment with the in-process rule engine and
+// returns the result in the same shape the external risk engine answers in.
+func (o *Orchestrator) EvaluateLocally(ctx context.Context, p repo.Payment) (RiskDecision, error) {
+	if o.rules == nil {
+		return RiskDecision{}, ErrNoRules
+	}
+	d, err := o.rules.Evaluate(ctx, p)
+	if err != nil {
+		return RiskDecision{}, err
+	}
+	contributions := make([]repo.Contribution, 0, len(d.Hits))
+	for _, h := range d.Hits {
+		contributions = append(contributions, repo.Contribution{Feature: "rule:" + h.Rule, Value: h.Score})
+	}
+	return RiskDecision{
+		PaymentID:     p.ID,
+		Decision:      string(d.Decision),
+		Score:         d.Score,
+		Reason:        d.Reason,
+		CorrelationID: p.CorrelationID,
+		ReasonCodes:   d.ReasonCodes,
+		Contributions: contributions,
+	}, nil
+}
@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/events"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/money"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/outbox"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidPayment      = errors.New("invalid payment")
+	ErrIdempotencyConflict = errors.New("idempotency key reused with a different request")
+)
+
+type CreatePaymentRequest struct {
+	UserID        string `json:"user_id"`
+	MerchantID    string `json:"merchant_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// InstrumentFingerprint identifies the card or account used without
+	// carrying the instrument itself.
+	InstrumentFingerprint string `json:"instrument_fingerprint,omitempty"`
+}
+
+func (r CreatePaymentRequest) validate() error {
+	switch {
+	case r.UserID == "":
+		return fmt.Errorf("%w: user_id is required", ErrInvalidPayment)
+	case r.MerchantID == "":
+		return fmt.Errorf("%w: merchant_id is required", ErrInvalidPayment)
+	case r.Amount <= 0:
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidPayment)
+	}
+	if _, err := money.Lookup(r.Currency); err != nil {
+		return fmt.Errorf("%w: currency must be an ISO 4217 code", ErrInvalidPayment)
+	}
+	return nil
+}
+
+// hash fingerprints the fields that define the payment so a reused
+// idempotency key with a different body can be rejected.
+func (r CreatePaymentRequest) hash() string {
+	fields := []any{r.UserID, r.MerchantID, r.Amount, strings.ToUpper(r.Currency)}
+	if r.InstrumentFingerprint != "" {
+		// only appended when set so hashes of older requests stay valid
+		fields = append(fields, r.InstrumentFingerprint)
+	}
+	b, _ := json.Marshal(fields)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// CreatePayment stores a new PENDING payment and asks the risk engine to
+// evaluate it. Requests repeated with the same idempotency key return the
+// original payment; the boolean reports whether a payment was created.
+func (o *Orchestrator) CreatePayment(ctx context.Context, req CreatePaymentRequest, idempotencyKey string) (*repo.Payment, bool, error) {
+	if err := req.validate(); err != nil {
+		return nil, false, err
+	}
+
+	if idempotencyKey != "" {
+		existing, err := o.payments.GetByIdempotencyKey(ctx, req.MerchantID, idempotencyKey)
+		if err == nil {
+			return o.replayCreate(ctx, existing, req)
+		}
+		if !errors.Is(err, repo.ErrPaymentNotFound) {
+			return nil, false, err
+		}
+	}
+
+	correlationID := req.CorrelationID
+	if correlationID == "" {
+		correlationID = uuid.NewString()
+	}
+
+	p := repo.Payment{
+		ID:             uuid.NewString(),
+		UserID:         req.UserID,
+		Amount:         req.Amount,
+		Currency:       strings.ToUpper(req.Currency),
+		MerchantID:     req.MerchantID,
+		CreatedAt:      time.Now().UTC().Truncate(time.Millisecond),
+		Status:         repo.StatusPending,
+		CorrelationID:  correlationID,
+		IdempotencyKey: idempotencyKey,
+		RequestHash:    req.hash(),
+
+		InstrumentFingerprint: req.InstrumentFingerprint,
+	}
+	p.Reporting = o.reportingAmount(p)
+	if o.experiments != nil {
+		p.Experiment = o.experiments.Assign(p)
+	}
+	if err := o.payments.Insert(ctx, p); err != nil {
+		if errors.Is(err, repo.ErrDuplicatePayment) && idempotencyKey != "" {
+			// lost a race with a concurrent request using the same key
+			existing, getErr := o.payments.GetByIdempotencyKey(ctx, req.MerchantID, idempotencyKey)
+			if getErr != nil {
+				return nil, false, getErr
+			}
+			return o.replayCreate(ctx, existing, req)
+		}
+		o.log.Error("failed to insert payment", zap.Error(err))
+		return nil, false, err
+	}
+	o.record(ctx, audit.Record{
+		PaymentID:     p.ID,
+		Kind:          audit.KindTransition,
+		Actor:         audit.ActorIntake,
+		NewStatus:     string(repo.StatusPending),
+		Reason:        "payment created",
+		CorrelationID: p.CorrelationID,
+	})
+	o.trackVelocity(ctx, p)
+
+	if err := o.requestEvaluation(ctx, p); err != nil {
+		return nil, false, err
+	}
+	return &p, true, nil
+}
+
+// trackVelocity counts p once, when it is created, so replays do not inflate
+// the counters. Failures are logged: counters are advisory and must not
+// block intake.
+func (o *Orchestrator) trackVelocity(ctx context.Context, p repo.Payment) {
+	if o.velocity == nil {
+		return
+	}
+	if err := o.velocity.Record(ctx, p); err != nil {
+		o.log.Error("velocity update failed", zap.Error(err), zap.String("payment_id", p.ID))
+	}
+}
+
+func (o *Orchestrator) replayCreate(ctx context.Context, existing *repo.Payment, req CreatePaymentRequest) (*repo.Payment, bool, error) {
+	if existing.RequestHash != req.hash() {
+		return nil, false, ErrIdempotencyConflict
+	}
+	if existing.Status == repo.StatusPending {
+		// the first attempt may have died between the payment and outbox writes
+		if err := o.requestEvaluation(ctx, *existing); err != nil {
+			return nil, false, err
+		}
+	}
+	return existing, false, nil
+}
+
+// requestEvaluation records the RiskEvaluationRequested event and publishes
+// it. A publish failure is not returned: the event is durable in the outbox,
+// the outbox relay publishes it later and the payment stays PENDING until
+// then.
+func (o *Orchestrator) requestEvaluation(ctx context.Context, p repo.Payment) error {
+	experimentID, arm := experimentOf(p)
+	eventPayload, _ := json.Marshal(events.RiskEvaluationRequested{
+		SchemaVersion: events.Default().Current(events.TypeRiskEvaluationRequested),
+		Type:          events.TypeRiskEvaluationRequested,
+		PaymentID:     p.ID,
+		UserID:        p.UserID,
+		MerchantID:    p.MerchantID,
+		Amount:        p.Amount,
+		Currency:      p.Currency,
+		CorrelationID: p.CorrelationID,
+		OccurredAt:    p.CreatedAt,
+
+		InstrumentFingerprint: p.InstrumentFingerprint,
+		ExperimentID:          experimentID,
+		ExperimentArm:         arm,
+	})
+
+	event := outbox.OutboxEvent{
+		ID:            p.ID + ":evaluate:" + p.CorrelationID,
+		AggregateID:   p.ID,
+		Topic:         o.riskTopic,
+		Type:          events.TypeRiskEvaluationRequested,
+		SchemaVersion: events.Default().Current(events.TypeRiskEvaluationRequested),
+		Payload:       eventPayload,
+		Headers:       map[string]any{"content-type": "application/json"},
+		CreatedAt:     time.Now(),
+		Published:     false,
+		CorrelationID: p.CorrelationID,
+	}
+	if err := o.outbox.Insert(ctx, event); err != nil {
+		if !errors.Is(err, outbox.ErrDuplicateEvent) {
+			o.log.Error("failed to insert outbox event", zap.Error(err))
+			return err
+		}
+		stored, err := o.outbox.Get(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		if stored.Published {
+			return nil
+		}
+		event = *stored
+	}
+
+	_ = o.publish(ctx, event)
+	return nil
+}
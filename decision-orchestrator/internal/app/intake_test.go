@@ -0,0 +1,49 @@
+package app
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreatePaymentRequestHashIgnoresCurrencyCase(t *testing.T) {
+	upper := CreatePaymentRequest{UserID: "u-1", MerchantID: "m-1", Amount: 1000, Currency: "USD"}
+	lower := upper
+	lower.Currency = "usd"
+	if upper.hash() != lower.hash() {
+		t.Error("a retry with a lower case currency hashes differently")
+	}
+	other := upper
+	other.Amount = 1001
+	if upper.hash() == other.hash() {
+		t.Error("requests with different amounts hash the same")
+	}
+}
+
+func TestCreatePaymentRequestValidate(t *testing.T) {
+	valid := CreatePaymentRequest{UserID: "u-1", MerchantID: "m-1", Amount: 1000, Currency: "eur"}
+	tests := []struct {
+		name   string
+		modify func(*CreatePaymentRequest)
+		ok     bool
+	}{
+		{"valid", func(*CreatePaymentRequest) {}, true},
+		{"missing user", func(r *CreatePaymentRequest) { r.UserID = "" }, false},
+		{"missing merchant", func(r *CreatePaymentRequest) { r.MerchantID = "" }, false},
+		{"zero amount", func(r *CreatePaymentRequest) { r.Amount = 0 }, false},
+		{"unknown currency", func(r *CreatePaymentRequest) { r.Currency = "ABC" }, false},
+		{"metal", func(r *CreatePaymentRequest) { r.Currency = "XAU" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid
+			tt.modify(&r)
+			err := r.validate()
+			if tt.ok && err != nil {
+				t.Errorf("validate: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidPayment) {
+				t.Errorf("validate = %v, want ErrInvalidPayment", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+// ModelScorer is satisfied by model.Scorer.
+type ModelScorer interface {
+	Score(ctx context.Context, p repo.Payment) (repo.ModelScore, error)
+}
+
+// scoreLocally runs the in-process model so its score can be stored next to
+// the risk engine's. The model does not decide yet, so a failure is logged
+// and the decision goes ahead without a local score.
+func (o *Orchestrator) scoreLocally(ctx context.Context, p repo.Payment) *repo.ModelScore {
+	if o.model == nil {
+		return nil
+	}
+	ms, err := o.model.Score(ctx, p)
+	if err != nil {
+		o.log.Error("local model scoring failed", zap.Error(err), zap.String("payment_id", p.ID))
+		return nil
+	}
+	return &ms
+}
+
+func modelFields(ms *repo.ModelScore) (string, float64) {
+	if ms == nil {
+		return "", 0
+	}
+	return ms.Version, ms.Score
+}
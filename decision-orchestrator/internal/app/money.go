@@ -0,0 +1,28 @@
+package app
+
+import (
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+// FXConverter is satisfied by money.Converter.
+type FXConverter interface {
+	Convert(amount int64, currency string, at time.Time) (repo.ReportingAmount, error)
+}
+
+// reportingAmount converts p into the reporting currency. A payment in a
+// currency without a rate is still accepted; it is left out of reporting
+// sums and rules reading its reporting amount fail for it.
+func (o *Orchestrator) reportingAmount(p repo.Payment) *repo.ReportingAmount {
+	if o.fx == nil {
+		return nil
+	}
+	ra, err := o.fx.Convert(p.Amount, p.Currency, p.CreatedAt)
+	if err != nil {
+		o.log.Warn("payment not converted to reporting currency", zap.Error(err), zap.String("payment_id", p.ID))
+		return nil
+	}
+	return &ra
+}
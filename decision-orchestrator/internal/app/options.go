@@ -0,0 +1,114 @@
+package app
+
+import (
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/codec"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/reasons"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/velocity"
+)
+
+type Option func(*Orchestrator)
+
+// WithCloudEvents wraps every published event in a CloudEvents 1.0 envelope
+// using the given source and content mode (structured or binary).
+func WithCloudEvents(source, mode string) Option {
+	return func(o *Orchestrator) {
+		o.eventSource = source
+		o.ceMode = mode
+	}
+}
+
+// WithCodecs encodes published event data with the codec configured for the
+// topic it is published to.
+func WithCodecs(codecs *codec.Topics) Option {
+	return func(o *Orchestrator) {
+		o.codecs = codecs
+	}
+}
+
+// WithRiskRequestTopic sets where RiskEvaluationRequested events are sent.
+func WithRiskRequestTopic(topic string) Option {
+	return func(o *Orchestrator) {
+		o.riskTopic = topic
+	}
+}
+
+// WithDualControl requires a second analyst to approve overrides of payments
+// whose amount is above the threshold, in minor units of the reporting
+// currency. Zero disables dual control.
+func WithDualControl(above int64, currency string) Option {
+	return func(o *Orchestrator) {
+		o.dualControl = above
+		o.dualCurrency = currency
+	}
+}
+
+// WithRules enables the in-process rule engine used when the external risk
+// engine cannot be relied on.
+func WithRules(engine RuleEvaluator) Option {
+	return func(o *Orchestrator) {
+		o.rules = engine
+	}
+}
+
+// WithLists consults allow/deny lists before any risk decision is applied.
+func WithLists(lists ListMatcher) Option {
+	return func(o *Orchestrator) {
+		o.lists = lists
+	}
+}
+
+// WithVelocity records every new payment in the per user and merchant
+// sliding window counters.
+func WithVelocity(store velocity.Store) Option {
+	return func(o *Orchestrator) {
+		o.velocity = store
+	}
+}
+
+// WithShadow compares every risk engine decision with a candidate source,
+// ShadowTopic or ShadowRules, without letting the candidate affect payments.
+func WithShadow(source string) Option {
+	return func(o *Orchestrator) {
+		o.shadowSource = source
+	}
+}
+
+// WithExperiments assigns new payments to experiment arms at intake.
+func WithExperiments(assigner ExperimentAssigner) Option {
+	return func(o *Orchestrator) {
+		o.experiments = assigner
+	}
+}
+
+// WithReasonCodes checks decision reason codes against the registry.
+func WithReasonCodes(registry *reasons.Registry) Option {
+	return func(o *Orchestrator) {
+		o.reasonCodes = registry
+	}
+}
+
+// WithModel scores payments with the in-process model when the risk engine
+// decides them, recording both scores.
+func WithModel(scorer ModelScorer) Option {
+	return func(o *Orchestrator) {
+		o.model = scorer
+	}
+}
+
+// WithFX converts new payments into the reporting currency so rules,
+// velocity counters and reports can compare amounts across currencies.
+func WithFX(fx FXConverter) Option {
+	return func(o *Orchestrator) {
+		o.fx = fx
+	}
+}
+
+// WithChallengeWindow sets how long a customer has to complete step-up
+// authentication before the sweeper declines the payment.
+func WithChallengeWindow(window time.Duration) Option {
+	return func(o *Orchestrator) {
+		o.challengeTTL = window
+	}
+}
@@ -3,11 +3,17 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/codec"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/events"
 	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/kafka"
 	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/outbox"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/reasons"
 	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/velocity"
 	segmentioKafka "github.com/segmentio/kafka-go"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.uber.org/zap"
@@ -17,26 +23,57 @@ type Orchestrator struct {
 	log           *zap.Logger
 	payments      *repo.PaymentRepo
 	outbox        *outbox.OutboxRepo
+	audit         *audit.AuditRepo
+	overrides     *repo.OverrideRepo
+	policies      PolicySource
+	shadow        ShadowRecorder
 	kafkaProducer *kafka.Producer
 	outboxTopic   string
+	eventSource   string
+	ceMode        string
+	codecs        *codec.Topics
+	riskTopic     string
+	dualControl   int64
+	dualCurrency  string
+	rules         RuleEvaluator
+	lists         ListMatcher
+	velocity      velocity.Store
+	shadowSource  string
+	experiments   ExperimentAssigner
+	reasonCodes   *reasons.Registry
+	model         ModelScorer
+	challengeTTL  time.Duration
+	fx            FXConverter
 }
 
 type RiskDecision struct {
-	PaymentID     string  `json:"payment_id"`
-	Decision      string  `json:"decision"`
-	Score         float64 `json:"score"`
-	Reason        string  `json:"reason"`
-	CorrelationID string  `json:"correlation_id"`
+	PaymentID     string   `json:"payment_id"`
+	Decision      string   `json:"decision"`
+	Score         float64  `json:"score"`
+	Reason        string   `json:"reason"`
+	CorrelationID string   `json:"correlation_id"`
+	ReasonCodes   []string `json:"reason_codes,omitempty"`
+
+	Contributions []repo.Contribution `json:"contributions,omitempty"`
 }
 
-func NewOrchestrator(l *zap.Logger, db *mongo.Database, prod *kafka.Producer, outboxTopic string) *Orchestrator {
-	return &Orchestrator{
+func NewOrchestrator(l *zap.Logger, db *mongo.Database, prod *kafka.Producer, outboxTopic string, opts ...Option) *Orchestrator {
+	o := &Orchestrator{
 		log:           l,
 		payments:      repo.NewPaymentRepo(db),
 		outbox:        outbox.NewOutboxRepo(db),
+		audit:         audit.NewAuditRepo(db),
+		overrides:     repo.NewOverrideRepo(db),
+		policies:      repo.NewMerchantPolicyRepo(db),
+		shadow:        repo.NewShadowRepo(db),
 		kafkaProducer: prod,
 		outboxTopic:   outboxTopic,
+		challengeTTL:  DefaultChallengeWindow,
+	}
+	for _, opt := range opts {
+		opt(o)
 	}
+	return o
 }
 
 func (o *Orchestrator) HandleRiskDecision(ctx context.Context, msg segmentioKafka.Message) error {
@@ -46,55 +83,135 @@ func (o *Orchestrator) HandleRiskDecision(ctx context.Context, msg segmentioKafk
 		return err
 	}
 
-	status := repo.StatusDeclined
-	if rd.Decision == string(repo.StatusApproved) {
-		status = repo.StatusApproved
+	p, err := o.payments.Get(ctx, rd.PaymentID)
+	if errors.Is(err, repo.ErrPaymentNotFound) {
+		o.log.Warn("risk decision for unknown payment ignored", zap.String("payment_id", rd.PaymentID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	v, err := o.decide(ctx, *p, rd)
+	if err != nil {
+		o.log.Error("failed to load merchant policy", zap.Error(err), zap.String("merchant_id", p.MerchantID))
+		return err
 	}
 
-	if err := o.payments.UpdateDecision(ctx, rd.PaymentID, status, rd.Score, rd.Reason); err != nil {
+	coords := &audit.KafkaCoordinates{Topic: msg.Topic, Partition: msg.Partition, Offset: msg.Offset}
+	o.checkReasonCodes(rd.PaymentID, v.reasonCodes)
+	exp := repo.Explanation{ReasonCodes: v.reasonCodes, Contributions: rd.Contributions, Model: o.scoreLocally(ctx, *p)}
+	if v.status == repo.StatusChallengeRequired {
+		return o.requireChallenge(ctx, *p, rd, v, exp, coords)
+	}
+	err = o.payments.TransitionWithPolicy(ctx, rd.PaymentID, repo.StatusPending, v.status, rd.Score, v.reason, v.policyVersion, exp)
+	if errors.Is(err, repo.ErrStaleStatus) {
+		// either finalized otherwise, typically by the timeout sweeper, or a
+		// redelivery of this decision; the latter writes its event again
+		applied, err := o.alreadyApplied(ctx, rd.PaymentID, v.status, rd.Score, v.reason)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			o.log.Warn("late risk decision ignored", zap.String("payment_id", rd.PaymentID))
+			return nil
+		}
+	} else if err != nil {
 		o.log.Error("failed to update payment decision", zap.Error(err), zap.String("payment_id", rd.PaymentID))
 		// compensation: mark failed and emit event
-		_ = o.payments.UpdateDecision(ctx, rd.PaymentID, repo.StatusFailed, rd.Score, "compensation: update failed")
+		reason := "compensation: update failed"
+		if prev, err := o.payments.UpdateDecision(ctx, rd.PaymentID, repo.StatusFailed, rd.Score, reason); err == nil {
+			o.record(ctx, audit.Record{
+				PaymentID:      rd.PaymentID,
+				Kind:           audit.KindCompensation,
+				Actor:          audit.ActorSystem,
+				PreviousStatus: string(prev),
+				NewStatus:      string(repo.StatusFailed),
+				Score:          rd.Score,
+				Reason:         reason,
+				CorrelationID:  rd.CorrelationID,
+				Kafka:          coords,
+			})
+		}
+	} else {
+		modelVersion, modelScore := modelFields(exp.Model)
+		o.record(ctx, audit.Record{
+			PaymentID:      rd.PaymentID,
+			Kind:           audit.KindDecision,
+			Actor:          audit.ActorRiskEngine,
+			PreviousStatus: string(repo.StatusPending),
+			NewStatus:      string(v.status),
+			Score:          rd.Score,
+			Reason:         v.reason,
+			CorrelationID:  rd.CorrelationID,
+			Kafka:          coords,
+			PolicyVersion:  v.policyVersion,
+			ReasonCodes:    v.reasonCodes,
+			ModelVersion:   modelVersion,
+			ModelScore:     modelScore,
+		})
+		o.compareShadow(ctx, *p, rd, v)
 	}
 
-	eventPayload, _ := json.Marshal(map[string]any{
-		"type":        "PaymentDecisionFinalized",
-		"payment_id":  rd.PaymentID,
-		"status":      status,
-		"score":       rd.Score,
-		"reason":      rd.Reason,
-		"correlation": rd.CorrelationID,
-		"ts":          time.Now().UTC(),
-	})
-
-	outboxID := rd.PaymentID + ":final:" + rd.CorrelationID
-	if err := o.outbox.Insert(ctx, outbox.OutboxEvent{
-		ID:            outboxID,
-		AggregateID:   rd.PaymentID,
-		Type:          "PaymentDecisionFinalized",
-		Payload:       eventPayload,
-		Headers:       map[string]any{"content-type": "application/json"},
-		CreatedAt:     time.Now(),
-		Published:     false,
+	experimentID, arm := experimentOf(*p)
+	event := finalizedEvent(rd.PaymentID+":final:"+rd.CorrelationID, events.PaymentDecisionFinalized{
+		PaymentID:     rd.PaymentID,
+		Status:        string(v.status),
+		Score:         rd.Score,
+		Reason:        v.reason,
 		CorrelationID: rd.CorrelationID,
-	}); err != nil {
+		PolicyVersion: v.policyVersion,
+		ExperimentID:  experimentID,
+		ExperimentArm: arm,
+		MerchantID:    p.MerchantID,
+		ReasonCodes:   v.reasonCodes,
+		Contributions: eventContributions(rd.Contributions),
+	})
+	if err := o.outbox.Insert(ctx, event); err != nil {
+		if errors.Is(err, outbox.ErrDuplicateEvent) {
+			return nil
+		}
 		o.log.Error("failed to insert outbox event", zap.Error(err))
 		return err
 	}
 
-	kafkaHeaders := []segmentioKafka.Header{
-		{Key: "correlation_id", Value: []byte(rd.CorrelationID)},
-		{Key: "event_type", Value: []byte("PaymentDecisionFinalized")},
-	}
+	return o.publish(ctx, event)
+}
 
-	if err := o.kafkaProducer.Publish(ctx, o.outboxTopic, []byte(rd.PaymentID), eventPayload, kafkaHeaders); err != nil {
-		o.log.Error("failed to publish outbox event", zap.Error(err))
-		return err
+// alreadyApplied reports whether a payment whose transition went stale
+// holds exactly the given decision, i.e. this decision was applied by an
+// earlier delivery of the same message. The decision's event is written
+// after the transition, so that delivery may have stopped before writing it.
+func (o *Orchestrator) alreadyApplied(ctx context.Context, paymentID string, status repo.PaymentStatus, score float64, reason string) (bool, error) {
+	cur, err := o.payments.Get(ctx, paymentID)
+	if err != nil {
+		return false, err
 	}
+	return cur.Status == status && cur.RiskScore == score && cur.RiskReason == reason, nil
+}
+
+func finalizedEvent(id string, decision events.PaymentDecisionFinalized) outbox.OutboxEvent {
+	decision.SchemaVersion = events.Default().Current(events.TypePaymentDecisionFinalized)
+	decision.Type = events.TypePaymentDecisionFinalized
+	decision.OccurredAt = time.Now().UTC()
+	eventPayload, _ := json.Marshal(decision)
 
-	if err := o.outbox.MarkPublished(ctx, outboxID); err != nil {
-		o.log.Warn("failed to mark outbox published", zap.Error(err))
+	return outbox.OutboxEvent{
+		ID:            id,
+		AggregateID:   decision.PaymentID,
+		Type:          decision.Type,
+		SchemaVersion: decision.SchemaVersion,
+		Payload:       eventPayload,
+		Headers:       map[string]any{"content-type": "application/json"},
+		CreatedAt:     time.Now(),
+		Published:     false,
+		CorrelationID: decision.CorrelationID,
 	}
+}
 
-	return nil
+// record appends to the audit trail. The decision it describes is already
+// persisted, so a failure here is logged rather than failing the caller.
+func (o *Orchestrator) record(ctx context.Context, rec audit.Record) {
+	if _, err := o.audit.Append(ctx, rec); err != nil {
+		o.log.Error("failed to append audit record", zap.Error(err), zap.String("payment_id", rec.PaymentID), zap.String("kind", string(rec.Kind)))
+	}
 }
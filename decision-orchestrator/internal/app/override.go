@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/events"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/outbox"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidOverride    = errors.New("invalid override")
+	ErrTransitionRejected = errors.New("transition not allowed")
+	ErrSelfApproval       = errors.New("override must be approved by a different analyst")
+)
+
+// OverrideReasonCodes are the reasons an analyst may give for an override.
+var OverrideReasonCodes = []string{
+	"customer_verified",
+	"false_positive",
+	"merchant_request",
+	"confirmed_fraud",
+	"policy_exception",
+}
+
+type OverrideRequest struct {
+	Status     repo.PaymentStatus `json:"status"`
+	ReasonCode string             `json:"reason_code"`
+	Note       string             `json:"note,omitempty"`
+}
+
+func (r OverrideRequest) validate() error {
+	switch {
+	case r.Status == "":
+		return fmt.Errorf("%w: status is required", ErrInvalidOverride)
+	case !slices.Contains(OverrideReasonCodes, r.ReasonCode):
+		return fmt.Errorf("%w: unknown reason_code %q", ErrInvalidOverride, r.ReasonCode)
+	case len(r.Note) > 1000:
+		return fmt.Errorf("%w: note is too long", ErrInvalidOverride)
+	}
+	return nil
+}
+
+// RequestOverride changes a decided payment's status on behalf of an
+// analyst. Payments above the dual control threshold are not changed yet:
+// the override is stored awaiting approval by a second analyst.
+func (o *Orchestrator) RequestOverride(ctx context.Context, paymentID, analyst string, req OverrideRequest) (*repo.Override, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	p, err := o.payments.Get(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == repo.StatusPending {
+		return nil, fmt.Errorf("%w: payment has no decision yet", ErrTransitionRejected)
+	}
+	if !repo.CanTransition(p.Status, req.Status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrTransitionRejected, p.Status, req.Status)
+	}
+
+	ov := repo.Override{
+		ID:          uuid.NewString(),
+		PaymentID:   p.ID,
+		From:        p.Status,
+		To:          req.Status,
+		ReasonCode:  req.ReasonCode,
+		Note:        req.Note,
+		Amount:      p.Amount,
+		RequestedBy: analyst,
+		RequestedAt: time.Now().UTC(),
+		State:       repo.OverridePendingApproval,
+	}
+	if o.needsApproval(*p) {
+		if err := o.overrides.Insert(ctx, ov); err != nil {
+			return nil, err
+		}
+		o.log.Info("override awaiting approval", zap.String("payment_id", p.ID), zap.String("override_id", ov.ID))
+		return &ov, nil
+	}
+
+	if _, err := o.applyOverride(ctx, p, ov); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	ov.State = repo.OverrideApplied
+	ov.ResolvedBy = analyst
+	ov.ResolvedAt = &now
+	if err := o.overrides.Insert(ctx, ov); err != nil {
+		o.log.Error("failed to store applied override", zap.Error(err), zap.String("override_id", ov.ID))
+	}
+	return &ov, nil
+}
+
+// ApproveOverride applies an override awaiting dual control. The override is
+// claimed first so two approvers cannot both apply it. If the payment left
+// the status the override was requested from, the override is marked stale
+// and repo.ErrStaleStatus is returned; if the payment could not be changed
+// for any other reason, the override goes back to awaiting approval.
+func (o *Orchestrator) ApproveOverride(ctx context.Context, overrideID, approver string) (*repo.Override, error) {
+	pending, err := o.overrides.Get(ctx, overrideID)
+	if err != nil {
+		return nil, err
+	}
+	if pending.RequestedBy == approver {
+		return nil, ErrSelfApproval
+	}
+	p, err := o.payments.Get(ctx, pending.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ov, err := o.overrides.Resolve(ctx, overrideID, repo.OverrideApplied, approver)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := o.applyOverride(ctx, p, *ov)
+	if err == nil {
+		return ov, nil
+	}
+	switch approvalFailed(err, applied) {
+	case repo.OverrideStale:
+		if markErr := o.overrides.MarkStale(ctx, ov.ID); markErr != nil {
+			o.log.Error("failed to mark override stale", zap.Error(markErr), zap.String("override_id", ov.ID))
+		}
+	case repo.OverridePendingApproval:
+		if reopenErr := o.overrides.Reopen(ctx, ov.ID); reopenErr != nil {
+			o.log.Error("failed to reopen override", zap.Error(reopenErr), zap.String("override_id", ov.ID))
+		}
+	}
+	return nil, err
+}
+
+// needsApproval reports whether overriding p needs a second analyst. The
+// threshold is compared with the payment's reporting amount; a payment that
+// cannot be valued in the reporting currency always needs approval.
+func (o *Orchestrator) needsApproval(p repo.Payment) bool {
+	if o.dualControl <= 0 {
+		return false
+	}
+	amount, ok := p.ReportingValue(o.dualCurrency)
+	return !ok || amount > o.dualControl
+}
+
+// approvalFailed returns the state an override claimed for approval moves
+// to when applying it failed: STALE if the payment left the status the
+// override was requested from, back to PENDING_APPROVAL if the payment did
+// not change so the approval can be retried, and APPLIED if the payment
+// changed and only recording the event failed.
+func approvalFailed(err error, applied bool) repo.OverrideState {
+	switch {
+	case errors.Is(err, repo.ErrStaleStatus):
+		return repo.OverrideStale
+	case !applied:
+		return repo.OverridePendingApproval
+	default:
+		return repo.OverrideApplied
+	}
+}
+
+func (o *Orchestrator) RejectOverride(ctx context.Context, overrideID, analyst string) (*repo.Override, error) {
+	return o.overrides.Resolve(ctx, overrideID, repo.OverrideRejected, analyst)
+}
+
+// applyOverride changes the payment and records the override. The boolean
+// reports whether the payment changed, which it has even when recording the
+// event afterwards fails.
+func (o *Orchestrator) applyOverride(ctx context.Context, p *repo.Payment, ov repo.Override) (bool, error) {
+	reason := "override: " + ov.ReasonCode
+	if err := o.payments.TransitionDecision(ctx, p.ID, ov.From, ov.To, p.RiskScore, reason); err != nil {
+		return false, err
+	}
+
+	var approvedBy string
+	if ov.ResolvedBy != ov.RequestedBy {
+		approvedBy = ov.ResolvedBy
+	}
+	o.record(ctx, audit.Record{
+		PaymentID:      p.ID,
+		Kind:           audit.KindOverride,
+		Actor:          audit.ActorAnalyst,
+		ActorID:        ov.RequestedBy,
+		ApprovedBy:     approvedBy,
+		PreviousStatus: string(ov.From),
+		NewStatus:      string(ov.To),
+		Score:          p.RiskScore,
+		Reason:         reason,
+		CorrelationID:  p.CorrelationID,
+	})
+
+	eventPayload, _ := json.Marshal(events.PaymentDecisionOverridden{
+		SchemaVersion:  events.Default().Current(events.TypePaymentDecisionOverridden),
+		Type:           events.TypePaymentDecisionOverridden,
+		PaymentID:      p.ID,
+		OverrideID:     ov.ID,
+		PreviousStatus: string(ov.From),
+		Status:         string(ov.To),
+		ReasonCode:     ov.ReasonCode,
+		Note:           ov.Note,
+		RequestedBy:    ov.RequestedBy,
+		ApprovedBy:     approvedBy,
+		CorrelationID:  p.CorrelationID,
+		OccurredAt:     time.Now().UTC(),
+	})
+	event := outbox.OutboxEvent{
+		ID:            p.ID + ":override:" + ov.ID,
+		AggregateID:   p.ID,
+		Type:          events.TypePaymentDecisionOverridden,
+		SchemaVersion: events.Default().Current(events.TypePaymentDecisionOverridden),
+		Payload:       eventPayload,
+		Headers:       map[string]any{"content-type": "application/json"},
+		CreatedAt:     time.Now(),
+		Published:     false,
+		CorrelationID: p.CorrelationID,
+	}
+	if err := o.outbox.Insert(ctx, event); err != nil && !errors.Is(err, outbox.ErrDuplicateEvent) {
+		o.log.Error("failed to insert outbox event", zap.Error(err))
+		return true, err
+	}
+
+	// the override is applied; a failed publish stays in the outbox for the relay
+	_ = o.publish(ctx, event)
+	return true, nil
+}
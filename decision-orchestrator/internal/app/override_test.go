@@ -0,0 +1,100 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+func TestOverrideRequestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  OverrideRequest
+		ok   bool
+	}{
+		{"valid", OverrideRequest{Status: repo.StatusApproved, ReasonCode: "false_positive"}, true},
+		{"with a note", OverrideRequest{Status: repo.StatusDeclined, ReasonCode: "confirmed_fraud", Note: "issuer confirmed"}, true},
+		{"no status", OverrideRequest{ReasonCode: "false_positive"}, false},
+		{"unknown reason", OverrideRequest{Status: repo.StatusApproved, ReasonCode: "because"}, false},
+		{"note too long", OverrideRequest{Status: repo.StatusApproved, ReasonCode: "false_positive", Note: strings.Repeat("x", 1001)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validate()
+			if tt.ok && err != nil {
+				t.Errorf("validate: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidOverride) {
+				t.Errorf("validate = %v, want ErrInvalidOverride", err)
+			}
+		})
+	}
+}
+
+func TestNeedsApproval(t *testing.T) {
+	o := &Orchestrator{dualControl: 100000, dualCurrency: "USD"}
+	tests := []struct {
+		name    string
+		payment repo.Payment
+		want    bool
+	}{
+		{"under in the reporting currency", repo.Payment{Amount: 100000, Currency: "USD"}, false},
+		{"over in the reporting currency", repo.Payment{Amount: 100001, Currency: "usd"}, true},
+		{
+			name:    "converted amount over",
+			payment: repo.Payment{Amount: 95000, Currency: "EUR", Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 104500}},
+			want:    true,
+		},
+		{
+			// 500000 yen is about 3350 USD, well over the threshold
+			name:    "few minor units, large converted amount",
+			payment: repo.Payment{Amount: 500000, Currency: "JPY", Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 335000}},
+			want:    true,
+		},
+		{
+			// 90 KWD is under 100000 minor units but about 292 USD
+			name:    "more minor units, small converted amount",
+			payment: repo.Payment{Amount: 90000, Currency: "KWD", Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 29250}},
+			want:    false,
+		},
+		{"not converted", repo.Payment{Amount: 1, Currency: "SEK"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := o.needsApproval(tt.payment); got != tt.want {
+				t.Errorf("needsApproval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	disabled := &Orchestrator{dualCurrency: "USD"}
+	if disabled.needsApproval(repo.Payment{Amount: 1 << 40, Currency: "SEK"}) {
+		t.Error("needsApproval with dual control disabled = true")
+	}
+}
+
+func TestApprovalFailed(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		applied bool
+		want    repo.OverrideState
+	}{
+		// the payment moved on between request and approval
+		{"payment changed status", fmt.Errorf("transition: %w", repo.ErrStaleStatus), false, repo.OverrideStale},
+		// nothing changed, so the approval can be retried
+		{"payment write failed", errors.New("connection reset"), false, repo.OverridePendingApproval},
+		// the payment is overridden, only its event is missing
+		{"event write failed", errors.New("connection reset"), true, repo.OverrideApplied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := approvalFailed(tt.err, tt.applied); got != tt.want {
+				t.Errorf("approvalFailed = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/lists"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/reasons"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+type ListMatcher interface {
+	Match(p repo.Payment) (*lists.Entry, bool)
+}
+
+// PolicySource returns a merchant's current policy, or ErrPolicyNotFound.
+type PolicySource interface {
+	Latest(ctx context.Context, merchantID string) (*repo.MerchantPolicy, error)
+}
+
+// verdict is the status applied to a payment and why.
+type verdict struct {
+	status        repo.PaymentStatus
+	reason        string
+	policyVersion int64
+	reasonCodes   []string
+}
+
+// decide turns the risk engine's answer into the payment's status. A list
+// entry forces the outcome and a challenge request is passed through;
+// otherwise an experiment arm's thresholds or the merchant policy map the
+// score, and merchants without a policy get the engine's own verdict.
+func (o *Orchestrator) decide(ctx context.Context, p repo.Payment, rd RiskDecision) (verdict, error) {
+	if o.lists != nil {
+		if e, ok := o.lists.Match(p); ok {
+			status, code := repo.StatusApproved, reasons.AllowList
+			if e.Action == lists.ActionDeny {
+				status, code = repo.StatusDeclined, reasons.DenyList
+			}
+			return verdict{
+				status:      status,
+				reason:      fmt.Sprintf("%s list %s %s (entry %s): %s", e.Action, e.Kind, e.Value, e.ID, e.Reason),
+				reasonCodes: []string{code},
+			}, nil
+		}
+	}
+
+	// a challenge is not mapped through thresholds: its result decides
+	if rd.Decision == DecisionChallenge {
+		return verdict{status: repo.StatusChallengeRequired, reason: rd.Reason, reasonCodes: rd.ReasonCodes}, nil
+	}
+
+	if p.Experiment != nil && p.Experiment.Policy != nil {
+		return verdict{status: p.Experiment.Policy.Decide(rd.Score), reason: rd.Reason, reasonCodes: rd.ReasonCodes}, nil
+	}
+
+	status, policyVersion, err := o.finalStatus(ctx, p.MerchantID, rd)
+	if err != nil {
+		return verdict{}, err
+	}
+	return verdict{status: status, reason: rd.Reason, policyVersion: policyVersion, reasonCodes: rd.ReasonCodes}, nil
+}
+
+// finalStatus derives the status from the risk score when the merchant has
+// a policy, returning the policy version applied. Merchants without one get
+// the risk engine's own verdict and version 0.
+func (o *Orchestrator) finalStatus(ctx context.Context, merchantID string, rd RiskDecision) (repo.PaymentStatus, int64, error) {
+	policy, err := o.policies.Latest(ctx, merchantID)
+	if errors.Is(err, repo.ErrPolicyNotFound) {
+		return engineStatus(rd), 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return policy.Decide(rd.Score), policy.Version, nil
+}
+
+// engineStatus maps the risk engine's verdict onto a payment status. Anything
+// it does not recognize declines.
+func engineStatus(rd RiskDecision) repo.PaymentStatus {
+	switch repo.PaymentStatus(rd.Decision) {
+	case repo.StatusApproved:
+		return repo.StatusApproved
+	case repo.StatusReview:
+		return repo.StatusReview
+	default:
+		return repo.StatusDeclined
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/lists"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/reasons"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+// merchantLists matches list entries by merchant ID only.
+type merchantLists map[string]lists.Entry
+
+func (m merchantLists) Match(p repo.Payment) (*lists.Entry, bool) {
+	e, ok := m[p.MerchantID]
+	return &e, ok
+}
+
+// merchantPolicies serves one policy per merchant.
+type merchantPolicies map[string]repo.MerchantPolicy
+
+func (m merchantPolicies) Latest(_ context.Context, merchantID string) (*repo.MerchantPolicy, error) {
+	if merchantID == "m-broken" {
+		return nil, errors.New("connection reset")
+	}
+	p, ok := m[merchantID]
+	if !ok {
+		return nil, repo.ErrPolicyNotFound
+	}
+	return &p, nil
+}
+
+func TestDecide(t *testing.T) {
+	o := &Orchestrator{
+		lists: merchantLists{
+			"m-deny":  {ID: "e1", Kind: lists.KindMerchant, Value: "m-deny", Action: lists.ActionDeny, Reason: "chargebacks"},
+			"m-allow": {ID: "e2", Kind: lists.KindMerchant, Value: "m-allow", Action: lists.ActionAllow, Reason: "partner"},
+		},
+		policies: merchantPolicies{
+			"m-policy": {MerchantID: "m-policy", Version: 3, ApproveBelow: 0.3, DeclineFrom: 0.9},
+			"m-deny":   {MerchantID: "m-deny", Version: 1, ApproveBelow: 1, DeclineFrom: 1},
+			"m-allow":  {MerchantID: "m-allow", Version: 1, ApproveBelow: 0, DeclineFrom: 0},
+		},
+	}
+	arm := &repo.ExperimentAssignment{ExperimentID: "x1", Arm: "strict", Policy: &repo.ArmPolicy{ApproveBelow: 0.1, DeclineFrom: 0.4}}
+	approve := RiskDecision{Decision: string(repo.StatusApproved), Score: 0.5, Reason: "engine", ReasonCodes: []string{"velocity_high"}}
+	challenge := RiskDecision{Decision: DecisionChallenge, Score: 0.95, Reason: "step up", ReasonCodes: []string{"new_device"}}
+
+	tests := []struct {
+		name    string
+		payment repo.Payment
+		rd      RiskDecision
+		want    repo.PaymentStatus
+		version int64
+		codes   []string
+	}{
+		{"deny list beats the engine", repo.Payment{MerchantID: "m-deny"}, approve, repo.StatusDeclined, 0, []string{reasons.DenyList}},
+		{"deny list beats a challenge", repo.Payment{MerchantID: "m-deny"}, challenge, repo.StatusDeclined, 0, []string{reasons.DenyList}},
+		{"allow list beats an experiment", repo.Payment{MerchantID: "m-allow", Experiment: arm}, RiskDecision{Decision: "DECLINED", Score: 1}, repo.StatusApproved, 0, []string{reasons.AllowList}},
+		{"challenge beats an experiment", repo.Payment{MerchantID: "m-policy", Experiment: arm}, challenge, repo.StatusChallengeRequired, 0, []string{"new_device"}},
+		{"challenge beats the merchant policy", repo.Payment{MerchantID: "m-policy"}, challenge, repo.StatusChallengeRequired, 0, []string{"new_device"}},
+		{"experiment beats the merchant policy", repo.Payment{MerchantID: "m-policy", Experiment: arm}, approve, repo.StatusDeclined, 0, []string{"velocity_high"}},
+		{"experiment without thresholds", repo.Payment{MerchantID: "m-policy", Experiment: &repo.ExperimentAssignment{ExperimentID: "x1", Arm: "control"}}, approve, repo.StatusReview, 3, []string{"velocity_high"}},
+		{"merchant policy maps the score", repo.Payment{MerchantID: "m-policy"}, approve, repo.StatusReview, 3, []string{"velocity_high"}},
+		{"merchant policy overrules the engine", repo.Payment{MerchantID: "m-policy"}, RiskDecision{Decision: "DECLINED", Score: 0.1}, repo.StatusApproved, 3, nil},
+		{"no policy, engine approves", repo.Payment{MerchantID: "m-none"}, approve, repo.StatusApproved, 0, []string{"velocity_high"}},
+		{"no policy, engine reviews", repo.Payment{MerchantID: "m-none"}, RiskDecision{Decision: "REVIEW", Score: 0.6}, repo.StatusReview, 0, nil},
+		{"no policy, engine declines", repo.Payment{MerchantID: "m-none"}, RiskDecision{Decision: "DECLINED", Score: 0.9}, repo.StatusDeclined, 0, nil},
+		{"no policy, unknown verdict", repo.Payment{MerchantID: "m-none"}, RiskDecision{Decision: "MAYBE", Score: 0.1}, repo.StatusDeclined, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := o.decide(context.Background(), tt.payment, tt.rd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.status != tt.want || v.policyVersion != tt.version {
+				t.Errorf("decide = %s at policy version %d, want %s at %d", v.status, v.policyVersion, tt.want, tt.version)
+			}
+			if len(v.reasonCodes) != len(tt.codes) || (len(tt.codes) > 0 && v.reasonCodes[0] != tt.codes[0]) {
+				t.Errorf("reason codes = %v, want %v", v.reasonCodes, tt.codes)
+			}
+		})
+	}
+
+	if _, err := o.decide(context.Background(), repo.Payment{MerchantID: "m-broken"}, approve); err == nil {
+		t.Error("decide succeeded although the merchant policy could not be read")
+	}
+
+	// without lists configured the engine's answer is used as is
+	bare := &Orchestrator{policies: merchantPolicies{}}
+	if v, err := bare.decide(context.Background(), repo.Payment{MerchantID: "m-deny"}, approve); err != nil || v.status != repo.StatusApproved {
+		t.Errorf("decide without lists = %s, %v; want APPROVED", v.status, err)
+	}
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/cloudevents"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/events"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/outbox"
+)
+
+// v1Finalized is an outbox record written before correlation and ts were
+// renamed in v2 of PaymentDecisionFinalized.
+func v1Finalized(version int) outbox.OutboxEvent {
+	return outbox.OutboxEvent{
+		ID:            "evt-1",
+		AggregateID:   "p-1",
+		Type:          events.TypePaymentDecisionFinalized,
+		SchemaVersion: version,
+		Payload:       []byte(`{"type":"PaymentDecisionFinalized","payment_id":"p-1","status":"APPROVED","correlation":"c-1","ts":"2026-01-02T03:04:05Z"}`),
+		CreatedAt:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		CorrelationID: "c-1",
+	}
+}
+
+func checkV2(t *testing.T, payload []byte) {
+	t.Helper()
+	var e events.PaymentDecisionFinalized
+	if err := json.Unmarshal(payload, &e); err != nil {
+		t.Fatalf("payload %s: %v", payload, err)
+	}
+	if e.SchemaVersion != 2 || e.CorrelationID != "c-1" || !e.OccurredAt.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("payload = %+v, want v2 with correlation_id c-1 and occurred_at set", e)
+	}
+}
+
+func TestRepublishV1Record(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		ceMode  string
+	}{
+		{"stored v1, plain json", 1, ""},
+		{"stored without version, plain json", 0, ""},
+		{"stored v1, structured cloudevents", 1, cloudevents.ModeStructured},
+		{"stored v1, binary cloudevents", 1, cloudevents.ModeBinary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := UpcastEvent(v1Finalized(tt.version))
+			if err != nil {
+				t.Fatalf("UpcastEvent: %v", err)
+			}
+			if event.SchemaVersion != 2 {
+				t.Errorf("SchemaVersion = %d, want 2", event.SchemaVersion)
+			}
+
+			o := &Orchestrator{outboxTopic: "payments.outbox", eventSource: "decision-orchestrator", ceMode: tt.ceMode}
+			value, headers, err := o.encodeEvent(o.topicFor(event), event)
+			if err != nil {
+				t.Fatalf("encodeEvent: %v", err)
+			}
+			h := map[string]string{}
+			for _, kv := range headers {
+				h[kv.Key] = string(kv.Value)
+			}
+
+			switch tt.ceMode {
+			case "":
+				if h["schema_version"] != "2" || h["event_id"] != "evt-1" {
+					t.Errorf("headers = %v, want schema_version 2 and event_id evt-1", h)
+				}
+				checkV2(t, value)
+			case cloudevents.ModeStructured:
+				var envelope struct {
+					ID            string          `json:"id"`
+					SchemaVersion string          `json:"schemaversion"`
+					Data          json.RawMessage `json:"data"`
+				}
+				if err := json.Unmarshal(value, &envelope); err != nil {
+					t.Fatal(err)
+				}
+				if envelope.ID != "evt-1" || envelope.SchemaVersion != "2" {
+					t.Errorf("envelope id %q schemaversion %q, want evt-1 and 2", envelope.ID, envelope.SchemaVersion)
+				}
+				checkV2(t, envelope.Data)
+			case cloudevents.ModeBinary:
+				if h["ce_id"] != "evt-1" || h["ce_schemaversion"] != "2" {
+					t.Errorf("headers = %v, want ce_id evt-1 and ce_schemaversion 2", h)
+				}
+				checkV2(t, value)
+			}
+		})
+	}
+}
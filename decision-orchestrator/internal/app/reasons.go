@@ -0,0 +1,29 @@
+package app
+
+import (
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/events"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+// checkReasonCodes warns about codes missing from the registry. They are
+// still stored and published; notification describes them generically.
+func (o *Orchestrator) checkReasonCodes(paymentID string, codes []string) {
+	if o.reasonCodes == nil {
+		return
+	}
+	if unknown := o.reasonCodes.Unknown(codes); len(unknown) > 0 {
+		o.log.Warn("unregistered reason codes in decision", zap.String("payment_id", paymentID), zap.Strings("codes", unknown))
+	}
+}
+
+func eventContributions(cs []repo.Contribution) []events.Contribution {
+	if len(cs) == 0 {
+		return nil
+	}
+	out := make([]events.Contribution, len(cs))
+	for i, c := range cs {
+		out[i] = events.Contribution{Feature: c.Feature, Value: c.Value}
+	}
+	return out
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/events"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/outbox"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	segmentioKafka "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// RefundRequest records a refund or reversal. ID is assigned by the caller,
+// usually the processor's refund reference, so retries are safe. A reversal
+// without an amount covers the whole payment.
+type RefundRequest struct {
+	ID     string          `json:"id"`
+	Kind   repo.RefundKind `json:"kind"`
+	Amount int64           `json:"amount"`
+	Reason string          `json:"reason,omitempty"`
+}
+
+// RefundMessage is one entry on the refunds topic.
+type RefundMessage struct {
+	PaymentID string `json:"payment_id"`
+	RefundRequest
+}
+
+func (r RefundRequest) validate() error {
+	switch {
+	case r.ID == "":
+		return fmt.Errorf("%w: id is required", repo.ErrInvalidRefund)
+	case len(r.Reason) > 1000:
+		return fmt.Errorf("%w: reason is too long", repo.ErrInvalidRefund)
+	}
+	return nil
+}
+
+// RecordRefund applies a refund to an approved payment and emits
+// PaymentRefunded or PaymentReversed. Repeating a refund ID returns the
+// payment unchanged, writing the event if an earlier attempt stored the
+// refund without it; the boolean reports whether the refund was new.
+func (o *Orchestrator) RecordRefund(ctx context.Context, paymentID string, actor audit.Actor, actorID string, req RefundRequest) (*repo.Payment, bool, error) {
+	if req.Kind == "" {
+		req.Kind = repo.RefundKindRefund
+	}
+	if err := req.validate(); err != nil {
+		return nil, false, err
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		p, err := o.payments.Get(ctx, paymentID)
+		if err != nil {
+			return nil, false, err
+		}
+		if prev, ok := p.FindRefund(req.ID); ok {
+			if prev.Kind != req.Kind || (req.Amount != 0 && prev.Amount != req.Amount) {
+				return nil, false, repo.ErrRefundConflict
+			}
+			// an earlier attempt may have stored the refund but not its event
+			after, previous, err := p.AfterRefund(prev.ID)
+			if err != nil {
+				return nil, false, err
+			}
+			if err := o.emitRefund(ctx, after, previous, prev); err != nil {
+				return nil, false, err
+			}
+			return p, false, nil
+		}
+
+		ref := repo.Refund{
+			ID:          req.ID,
+			Kind:        req.Kind,
+			Amount:      req.Amount,
+			Reason:      req.Reason,
+			RequestedBy: actorID,
+			CreatedAt:   time.Now().UTC().Truncate(time.Millisecond),
+		}
+		if ref.Kind == repo.RefundKindReversal && ref.Amount == 0 {
+			ref.Amount = p.Amount
+		}
+		status, err := p.ApplyRefund(ref)
+		if err != nil {
+			return nil, false, err
+		}
+		err = o.payments.AddRefund(ctx, p.ID, p.Version, ref, status)
+		if errors.Is(err, repo.ErrStaleStatus) {
+			// another refund or an override got there first, check again
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		previous := p.Status
+		p.Refunds = append(p.Refunds, ref)
+		p.RefundedAmount += ref.Amount
+		p.Status = status
+		p.Version++
+		if err := o.refunded(ctx, *p, previous, ref, actor); err != nil {
+			return nil, false, err
+		}
+		return p, true, nil
+	}
+	return nil, false, errors.New("refund contended, giving up")
+}
+
+// refunded records the audit entry and outbox event of a stored refund. A
+// failed audit append is only logged; a failed outbox insert is returned so
+// the caller retries, and the retry finds the refund and writes the event.
+func (o *Orchestrator) refunded(ctx context.Context, p repo.Payment, previous repo.PaymentStatus, ref repo.Refund, actor audit.Actor) error {
+	reason := fmt.Sprintf("%s %s of %d %s", ref.Kind, ref.ID, ref.Amount, p.Currency)
+	if ref.Reason != "" {
+		reason += ": " + ref.Reason
+	}
+	o.record(ctx, audit.Record{
+		PaymentID:      p.ID,
+		Kind:           audit.KindRefund,
+		Actor:          actor,
+		ActorID:        ref.RequestedBy,
+		PreviousStatus: string(previous),
+		NewStatus:      string(p.Status),
+		Score:          p.RiskScore,
+		Reason:         reason,
+		CorrelationID:  p.CorrelationID,
+		PolicyVersion:  p.PolicyVersion,
+	})
+	return o.emitRefund(ctx, p, previous, ref)
+}
+
+// emitRefund writes the PaymentRefunded or PaymentReversed event of ref to
+// the outbox, given the payment right after the refund. The event ID is
+// derived from the refund, so writing it again is a no-op.
+func (o *Orchestrator) emitRefund(ctx context.Context, p repo.Payment, previous repo.PaymentStatus, ref repo.Refund) error {
+	eventType := events.TypePaymentRefunded
+	if ref.Kind == repo.RefundKindReversal {
+		eventType = events.TypePaymentReversed
+	}
+	eventPayload, _ := json.Marshal(events.PaymentRefunded{
+		SchemaVersion:  events.Default().Current(eventType),
+		Type:           eventType,
+		PaymentID:      p.ID,
+		RefundID:       ref.ID,
+		Kind:           string(ref.Kind),
+		Amount:         ref.Amount,
+		Currency:       p.Currency,
+		RefundedAmount: p.RefundedAmount,
+		PreviousStatus: string(previous),
+		Status:         string(p.Status),
+		Reason:         ref.Reason,
+		MerchantID:     p.MerchantID,
+		CorrelationID:  p.CorrelationID,
+		OccurredAt:     ref.CreatedAt,
+	})
+	event := outbox.OutboxEvent{
+		ID:            p.ID + ":refund:" + ref.ID,
+		AggregateID:   p.ID,
+		Type:          eventType,
+		SchemaVersion: events.Default().Current(eventType),
+		Payload:       eventPayload,
+		Headers:       map[string]any{"content-type": "application/json"},
+		CreatedAt:     time.Now(),
+		Published:     false,
+		CorrelationID: p.CorrelationID,
+	}
+	err := o.outbox.Insert(ctx, event)
+	if errors.Is(err, outbox.ErrDuplicateEvent) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("outbox event for refund %s: %w", ref.ID, err)
+	}
+	_ = o.publish(ctx, event)
+	return nil
+}
+
+// HandleRefund consumes the refunds topic. Malformed messages, refunds for
+// unknown payments and refunds that do not fit the payment are logged and
+// skipped; storage errors are returned so the message is retried.
+func (o *Orchestrator) HandleRefund(ctx context.Context, msg segmentioKafka.Message) error {
+	var m RefundMessage
+	if err := json.Unmarshal(msg.Value, &m); err != nil {
+		o.log.Error("invalid refund message", zap.Error(err))
+		return nil
+	}
+	_, added, err := o.RecordRefund(ctx, m.PaymentID, audit.ActorSystem, "", m.RefundRequest)
+	switch {
+	case errors.Is(err, repo.ErrInvalidRefund), errors.Is(err, repo.ErrRefundNotAllowed),
+		errors.Is(err, repo.ErrRefundConflict), errors.Is(err, repo.ErrPaymentNotFound):
+		o.log.Warn("refund skipped", zap.Error(err), zap.String("refund_id", m.ID), zap.String("payment_id", m.PaymentID))
+		return nil
+	case err != nil:
+		o.log.Error("failed to record refund", zap.Error(err), zap.String("payment_id", m.PaymentID))
+		return err
+	}
+	if added {
+		o.log.Info("refund recorded", zap.String("payment_id", m.PaymentID), zap.String("refund_id", m.ID), zap.Int64("amount", m.Amount))
+	}
+	return nil
+}
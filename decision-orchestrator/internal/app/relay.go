@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Relay publishes outbox events that were stored but never delivered, for
+// example because Kafka was unavailable when the request that wrote them
+// tried. Events younger than grace are left to that request. It is meant to
+// run under a lease; consumers dedupe on event ID, so an event published
+// twice around a lease handover is harmless.
+type Relay struct {
+	log      *zap.Logger
+	orch     *Orchestrator
+	interval time.Duration
+	grace    time.Duration
+	batch    int
+}
+
+func NewRelay(l *zap.Logger, orch *Orchestrator, interval, grace time.Duration, batch int) *Relay {
+	return &Relay{log: l, orch: orch, interval: interval, grace: grace, batch: batch}
+}
+
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			n, err := r.Relay(ctx)
+			if err != nil {
+				r.log.Error("outbox relay failed", zap.Error(err), zap.Int("published", n))
+				continue
+			}
+			if n > 0 {
+				r.log.Info("relayed unpublished outbox events", zap.Int("count", n))
+			}
+		}
+	}
+}
+
+// Relay publishes one batch of overdue events in creation order. It stops at
+// the first failure so later events of the same payment do not overtake it.
+func (r *Relay) Relay(ctx context.Context) (int, error) {
+	pending, err := r.orch.outbox.FindUnpublishedBefore(ctx, time.Now().Add(-r.grace), r.batch)
+	if err != nil {
+		return 0, err
+	}
+	for i, event := range pending {
+		if err := r.orch.Republish(ctx, event); err != nil {
+			return i, err
+		}
+	}
+	return len(pending), nil
+}
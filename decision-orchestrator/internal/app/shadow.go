@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	segmentioKafka "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Shadow sources. A shadow source decides every payment alongside the risk
+// engine; its answers are stored for comparison and never change a payment.
+const (
+	ShadowTopic = "topic"
+	ShadowRules = "rules"
+)
+
+// ShadowRecorder stores each side of a shadow comparison.
+type ShadowRecorder interface {
+	RecordPrimary(ctx context.Context, p repo.Payment, side repo.ShadowSide) error
+	RecordShadow(ctx context.Context, p repo.Payment, side repo.ShadowSide) error
+}
+
+// HandleShadowDecision consumes decisions from the candidate stream. They go
+// through the same lists and merchant policies as real decisions so the
+// comparison is between the statuses each source would have produced.
+func (o *Orchestrator) HandleShadowDecision(ctx context.Context, msg segmentioKafka.Message) error {
+	var rd RiskDecision
+	if err := json.Unmarshal(msg.Value, &rd); err != nil {
+		o.log.Error("failed to unmarshal shadow decision", zap.Error(err))
+		return err
+	}
+
+	p, err := o.payments.Get(ctx, rd.PaymentID)
+	if errors.Is(err, repo.ErrPaymentNotFound) {
+		o.log.Warn("shadow decision for unknown payment ignored", zap.String("payment_id", rd.PaymentID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	v, err := o.decide(ctx, *p, rd)
+	if err != nil {
+		return err
+	}
+	return o.shadow.RecordShadow(ctx, *p, shadowSide(msg.Topic, rd, v))
+}
+
+// compareShadow stores the decision just applied and, for the local rules
+// source, evaluates the candidate. Failures only cost a comparison, so they
+// are logged and never fail the real decision.
+func (o *Orchestrator) compareShadow(ctx context.Context, p repo.Payment, rd RiskDecision, v verdict) {
+	if o.shadowSource == "" {
+		return
+	}
+	if err := o.shadow.RecordPrimary(ctx, p, shadowSide("risk-engine", rd, v)); err != nil {
+		o.log.Error("failed to record primary decision for shadow comparison", zap.Error(err), zap.String("payment_id", p.ID))
+		return
+	}
+	if o.shadowSource != ShadowRules {
+		return
+	}
+
+	err := func() error {
+		candidate, err := o.EvaluateLocally(ctx, p)
+		if err != nil {
+			return fmt.Errorf("evaluate: %w", err)
+		}
+		cv, err := o.decide(ctx, p, candidate)
+		if err != nil {
+			return fmt.Errorf("decide: %w", err)
+		}
+		return o.shadow.RecordShadow(ctx, p, shadowSide("rules", candidate, cv))
+	}()
+	if err != nil {
+		o.log.Error("shadow evaluation failed", zap.Error(err), zap.String("payment_id", p.ID))
+	}
+}
+
+func shadowSide(source string, rd RiskDecision, v verdict) repo.ShadowSide {
+	return repo.ShadowSide{
+		Source:        source,
+		Decision:      rd.Decision,
+		Status:        v.status,
+		Score:         rd.Score,
+		Reason:        v.reason,
+		PolicyVersion: v.policyVersion,
+		DecidedAt:     time.Now(),
+	}
+}
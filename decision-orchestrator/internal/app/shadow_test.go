@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/rules"
+	"go.uber.org/zap"
+)
+
+// memShadow keeps comparisons in memory and sets Agree once both sides are
+// in, as ShadowRepo does.
+type memShadow struct {
+	comparisons map[string]*repo.ShadowComparison
+	err         error
+}
+
+func newMemShadow() *memShadow {
+	return &memShadow{comparisons: map[string]*repo.ShadowComparison{}}
+}
+
+func (m *memShadow) RecordPrimary(_ context.Context, p repo.Payment, side repo.ShadowSide) error {
+	return m.record(p, func(c *repo.ShadowComparison) { c.Primary = &side })
+}
+
+func (m *memShadow) RecordShadow(_ context.Context, p repo.Payment, side repo.ShadowSide) error {
+	return m.record(p, func(c *repo.ShadowComparison) { c.Shadow = &side })
+}
+
+func (m *memShadow) record(p repo.Payment, set func(*repo.ShadowComparison)) error {
+	if m.err != nil {
+		return m.err
+	}
+	c, ok := m.comparisons[p.ID]
+	if !ok {
+		c = &repo.ShadowComparison{PaymentID: p.ID, MerchantID: p.MerchantID}
+		m.comparisons[p.ID] = c
+	}
+	set(c)
+	if c.Primary != nil && c.Shadow != nil {
+		agree := c.Primary.Status == c.Shadow.Status
+		c.Agree = &agree
+	}
+	return nil
+}
+
+type failingRules struct{}
+
+func (failingRules) Evaluate(context.Context, repo.Payment) (rules.Decision, error) {
+	return rules.Decision{}, errors.New("velocity store unavailable")
+}
+
+func TestCompareShadow(t *testing.T) {
+	declineAll := ruleFunc(func(repo.Payment) rules.Decision {
+		return rules.Decision{Decision: repo.StatusDeclined, Score: 0.9, Reason: "rules: merchant_blocked"}
+	})
+	approveAll := ruleFunc(func(repo.Payment) rules.Decision {
+		return rules.Decision{Decision: repo.StatusApproved, Score: 0.1, Reason: "rules: no rule fired"}
+	})
+	p := repo.Payment{ID: "p-1", MerchantID: "m-1"}
+	rd := RiskDecision{PaymentID: "p-1", Decision: string(repo.StatusApproved), Score: 0.2, Reason: "engine"}
+	v := verdict{status: repo.StatusApproved, reason: "engine"}
+
+	tests := []struct {
+		name       string
+		source     string
+		rules      RuleEvaluator
+		recordErr  error
+		compared   bool
+		shadow     repo.PaymentStatus
+		wantAgree  *bool
+		wantSource string
+	}{
+		{name: "shadowing off", source: "", rules: declineAll},
+		{name: "topic source waits for its message", source: ShadowTopic, rules: declineAll, compared: true},
+		{name: "rules disagree", source: ShadowRules, rules: declineAll, compared: true, shadow: repo.StatusDeclined, wantAgree: ptr(false), wantSource: "rules"},
+		{name: "rules agree", source: ShadowRules, rules: approveAll, compared: true, shadow: repo.StatusApproved, wantAgree: ptr(true), wantSource: "rules"},
+		{name: "rules fail", source: ShadowRules, rules: failingRules{}, compared: true},
+		{name: "no rules loaded", source: ShadowRules, compared: true},
+		{name: "store down", source: ShadowRules, rules: declineAll, recordErr: errors.New("connection reset")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemShadow()
+			store.err = tt.recordErr
+			o := &Orchestrator{log: zap.NewNop(), shadow: store, shadowSource: tt.source, policies: merchantPolicies{}}
+			if tt.rules != nil {
+				o.rules = tt.rules
+			}
+
+			o.compareShadow(context.Background(), p, rd, v)
+
+			c, ok := store.comparisons[p.ID]
+			if ok != tt.compared {
+				t.Fatalf("comparison stored = %v, want %v", ok, tt.compared)
+			}
+			if !ok {
+				return
+			}
+			if c.Primary == nil || c.Primary.Source != "risk-engine" || c.Primary.Status != repo.StatusApproved || c.Primary.Score != 0.2 {
+				t.Errorf("primary = %+v, want the risk engine's approval", c.Primary)
+			}
+			if tt.shadow == "" {
+				if c.Shadow != nil || c.Agree != nil {
+					t.Errorf("shadow = %+v, agree = %v; want neither", c.Shadow, c.Agree)
+				}
+				return
+			}
+			if c.Shadow == nil || c.Shadow.Source != tt.wantSource || c.Shadow.Status != tt.shadow {
+				t.Fatalf("shadow = %+v, want %s from %s", c.Shadow, tt.shadow, tt.wantSource)
+			}
+			if c.Agree == nil || *c.Agree != *tt.wantAgree {
+				t.Errorf("agree = %v, want %v", c.Agree, *tt.wantAgree)
+			}
+		})
+	}
+}
+
+// The candidate goes through the merchant policy like the real decision, so
+// sources that disagree on the verdict may still agree on the status.
+func TestCompareShadowAppliesMerchantPolicy(t *testing.T) {
+	store := newMemShadow()
+	o := &Orchestrator{
+		log:          zap.NewNop(),
+		shadow:       store,
+		shadowSource: ShadowRules,
+		policies:     merchantPolicies{"m-1": {MerchantID: "m-1", Version: 2, ApproveBelow: 0.5, DeclineFrom: 0.95}},
+		rules: ruleFunc(func(repo.Payment) rules.Decision {
+			return rules.Decision{Decision: repo.StatusDeclined, Score: 0.4}
+		}),
+	}
+	p := repo.Payment{ID: "p-1", MerchantID: "m-1"}
+	o.compareShadow(context.Background(), p, RiskDecision{Decision: "APPROVED", Score: 0.3}, verdict{status: repo.StatusApproved, policyVersion: 2})
+
+	c := store.comparisons[p.ID]
+	if c == nil || c.Shadow == nil || c.Shadow.Status != repo.StatusApproved || c.Shadow.Decision != "DECLINED" || c.Shadow.PolicyVersion != 2 {
+		t.Fatalf("comparison = %+v, want the rules' DECLINED mapped to APPROVED by policy 2", c)
+	}
+	if c.Agree == nil || !*c.Agree {
+		t.Errorf("agree = %v, want true", c.Agree)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
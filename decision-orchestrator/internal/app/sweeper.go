@@ -0,0 +1,274 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/events"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/lease"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/outbox"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/reasons"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const (
+	TimeoutDecline      = "decline"
+	TimeoutApproveUnder = "approve_under"
+	TimeoutReview       = "review"
+	// TimeoutRules asks the local rule engine for the decision instead.
+	TimeoutRules = "rules"
+)
+
+// TimeoutPolicy decides what happens to a payment the risk engine never
+// answered for within SLA. ApproveUnder is in minor units of Currency, the
+// reporting currency, so one threshold holds across payment currencies.
+type TimeoutPolicy struct {
+	SLA          time.Duration
+	Action       string
+	ApproveUnder int64
+	Currency     string
+}
+
+func (p TimeoutPolicy) Validate() error {
+	if p.SLA <= 0 {
+		return errors.New("timeout SLA must be positive")
+	}
+	switch p.Action {
+	case TimeoutDecline, TimeoutReview, TimeoutRules:
+	case TimeoutApproveUnder:
+		if p.ApproveUnder <= 0 {
+			return errors.New("approve_under policy requires a positive amount")
+		}
+		if p.Currency == "" {
+			return errors.New("approve_under policy requires the reporting currency")
+		}
+	default:
+		return fmt.Errorf("unknown timeout policy %q", p.Action)
+	}
+	return nil
+}
+
+func (p TimeoutPolicy) decide(pay repo.Payment) repo.PaymentStatus {
+	switch p.Action {
+	case TimeoutApproveUnder:
+		// a payment that cannot be valued in the reporting currency is declined
+		if amount, ok := pay.ReportingValue(p.Currency); ok && amount < p.ApproveUnder {
+			return repo.StatusApproved
+		}
+		return repo.StatusDeclined
+	case TimeoutReview:
+		return repo.StatusReview
+	default:
+		return repo.StatusDeclined
+	}
+}
+
+// Sweeper periodically applies the timeout policy to payments stuck in
+// PENDING. It is meant to run under a lease; the lease is re-checked before
+// each write, and the write itself is fenced on the lease token so a paused
+// leader cannot touch a payment a newer term has written. Transitions are
+// also conditional on the payment's status, so none is decided twice. A
+// payment remembers its decision event until the event is in the outbox, and
+// each sweep first writes the events an earlier one lost. Expired challenges
+// are left to ChallengeExpirer.
+type Sweeper struct {
+	log      *zap.Logger
+	orch     *Orchestrator
+	policy   TimeoutPolicy
+	interval time.Duration
+	batch    int
+	timedOut metric.Int64Counter
+}
+
+func NewSweeper(l *zap.Logger, orch *Orchestrator, policy TimeoutPolicy, interval time.Duration, batch int) (*Sweeper, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	if policy.Action == TimeoutRules && orch.rules == nil {
+		return nil, errors.New("rules timeout policy requires a rules file")
+	}
+	counter, err := otel.Meter("decision-orchestrator").Int64Counter(
+		"payments.decision_timeouts",
+		metric.WithDescription("Payments finalized by the timeout sweeper, by fallback status"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Sweeper{
+		log:      l,
+		orch:     orch,
+		policy:   policy,
+		interval: interval,
+		batch:    batch,
+		timedOut: counter,
+	}, nil
+}
+
+func (s *Sweeper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			n, err := s.Sweep(ctx)
+			if err != nil {
+				s.log.Error("timeout sweep failed", zap.Error(err))
+				continue
+			}
+			if n > 0 {
+				s.log.Info("timed out pending payments", zap.Int("count", n))
+			}
+		}
+	}
+}
+
+// Sweep processes one batch of overdue payments and returns how many this
+// replica finalized.
+func (s *Sweeper) Sweep(ctx context.Context) (int, error) {
+	if err := s.resume(ctx); err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-s.policy.SLA)
+	pending, err := s.orch.payments.FindPendingBefore(ctx, cutoff, s.batch)
+	if err != nil {
+		return 0, err
+	}
+
+	var finalized int
+	for _, p := range pending {
+		ok, err := s.timeout(ctx, p)
+		if errors.Is(err, lease.ErrLost) {
+			return finalized, err
+		}
+		if err != nil {
+			s.log.Error("failed to time out payment", zap.Error(err), zap.String("payment_id", p.ID))
+			continue
+		}
+		if ok {
+			finalized++
+		}
+	}
+
+	return finalized, nil
+}
+
+func (s *Sweeper) timeout(ctx context.Context, p repo.Payment) (bool, error) {
+	status, score, reason, exp, err := s.fallback(ctx, p)
+	if err != nil {
+		return false, err
+	}
+
+	if err := lease.Check(ctx); err != nil {
+		return false, err
+	}
+	eventID := p.ID + ":timeout:" + p.CorrelationID
+	err = s.orch.payments.TimeOut(ctx, p.ID, status, score, reason, exp, eventID)
+	if errors.Is(err, repo.ErrStaleStatus) {
+		// decided meanwhile, either by the risk engine or another replica
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	s.orch.record(ctx, audit.Record{
+		PaymentID:      p.ID,
+		Kind:           audit.KindDecision,
+		Actor:          audit.ActorSweeper,
+		PreviousStatus: string(repo.StatusPending),
+		NewStatus:      string(status),
+		Score:          score,
+		Reason:         reason,
+		CorrelationID:  p.CorrelationID,
+		ReasonCodes:    exp.ReasonCodes,
+	})
+	s.timedOut.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("status", string(status)),
+		attribute.String("policy", s.policy.Action),
+	))
+
+	p.Status, p.RiskScore, p.RiskReason = status, score, reason
+	p.ReasonCodes, p.Contributions = exp.ReasonCodes, exp.Contributions
+	p.TimeoutEvent = eventID
+	return true, s.emit(ctx, p)
+}
+
+// resume writes the events of payments an earlier sweep finalized but did
+// not get into the outbox.
+func (s *Sweeper) resume(ctx context.Context) error {
+	unwritten, err := s.orch.payments.FindPendingTimeoutEvents(ctx, s.batch)
+	if err != nil {
+		return err
+	}
+	for _, p := range unwritten {
+		if err := lease.Check(ctx); err != nil {
+			return err
+		}
+		if err := s.emit(ctx, p); err != nil {
+			s.log.Error("failed to write timeout event", zap.Error(err), zap.String("payment_id", p.ID))
+		}
+	}
+	return nil
+}
+
+// emit stores and publishes the timeout event of a payment the sweeper
+// finalized, then clears the payment's pending event. The event ID is fixed
+// per payment, so writing it again is a no-op.
+func (s *Sweeper) emit(ctx context.Context, p repo.Payment) error {
+	event := timeoutEvent(p)
+	if token, ok := lease.Token(ctx); ok {
+		event.Headers["fencing_token"] = token
+	}
+	if err := s.orch.outbox.Insert(ctx, event); err != nil && !errors.Is(err, outbox.ErrDuplicateEvent) {
+		return err
+	}
+	if err := s.orch.payments.TimeoutEventWritten(ctx, p.ID); err != nil {
+		return err
+	}
+	return s.orch.publish(ctx, event)
+}
+
+// timeoutEvent builds the decision event of a payment as the sweeper stored
+// it.
+func timeoutEvent(p repo.Payment) outbox.OutboxEvent {
+	experimentID, arm := experimentOf(p)
+	return finalizedEvent(p.TimeoutEvent, events.PaymentDecisionFinalized{
+		PaymentID:     p.ID,
+		Status:        string(p.Status),
+		Score:         p.RiskScore,
+		Reason:        p.RiskReason,
+		CorrelationID: p.CorrelationID,
+		TimedOut:      true,
+		ExperimentID:  experimentID,
+		ExperimentArm: arm,
+		MerchantID:    p.MerchantID,
+		ReasonCodes:   p.ReasonCodes,
+		Contributions: eventContributions(p.Contributions),
+	})
+}
+
+func (s *Sweeper) fallback(ctx context.Context, p repo.Payment) (repo.PaymentStatus, float64, string, repo.Explanation, error) {
+	reason := fmt.Sprintf("timeout: no risk decision within %s (policy %s)", s.policy.SLA, s.policy.Action)
+	exp := repo.Explanation{ReasonCodes: []string{reasons.DecisionTimeout}}
+	if s.policy.Action != TimeoutRules {
+		return s.policy.decide(p), 0, reason, exp, nil
+	}
+
+	rd, err := s.orch.EvaluateLocally(ctx, p)
+	if err != nil {
+		return "", 0, "", repo.Explanation{}, err
+	}
+	exp.ReasonCodes = append(exp.ReasonCodes, rd.ReasonCodes...)
+	exp.Contributions = rd.Contributions
+	return repo.PaymentStatus(rd.Decision), rd.Score, reason + ": " + rd.Reason, exp, nil
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/events"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/reasons"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/rules"
+)
+
+// ruleFunc is a RuleEvaluator backed by a function.
+type ruleFunc func(p repo.Payment) rules.Decision
+
+func (f ruleFunc) Evaluate(_ context.Context, p repo.Payment) (rules.Decision, error) {
+	return f(p), nil
+}
+
+func TestTimeoutPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy TimeoutPolicy
+		ok     bool
+	}{
+		{"decline", TimeoutPolicy{SLA: time.Minute, Action: TimeoutDecline}, true},
+		{"review", TimeoutPolicy{SLA: time.Minute, Action: TimeoutReview}, true},
+		{"rules", TimeoutPolicy{SLA: time.Minute, Action: TimeoutRules}, true},
+		{"approve under", TimeoutPolicy{SLA: time.Minute, Action: TimeoutApproveUnder, ApproveUnder: 5000, Currency: "USD"}, true},
+		{"no sla", TimeoutPolicy{Action: TimeoutDecline}, false},
+		{"unknown action", TimeoutPolicy{SLA: time.Minute, Action: "approve"}, false},
+		{"approve under without an amount", TimeoutPolicy{SLA: time.Minute, Action: TimeoutApproveUnder, Currency: "USD"}, false},
+		{"approve under without a currency", TimeoutPolicy{SLA: time.Minute, Action: TimeoutApproveUnder, ApproveUnder: 5000}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err == nil) != tt.ok {
+				t.Errorf("Validate = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTimeoutPolicyDecide(t *testing.T) {
+	approveUnder := TimeoutPolicy{SLA: time.Minute, Action: TimeoutApproveUnder, ApproveUnder: 5000, Currency: "USD"}
+	tests := []struct {
+		name    string
+		policy  TimeoutPolicy
+		payment repo.Payment
+		want    repo.PaymentStatus
+	}{
+		{"decline", TimeoutPolicy{Action: TimeoutDecline}, repo.Payment{Amount: 1, Currency: "USD"}, repo.StatusDeclined},
+		{"review", TimeoutPolicy{Action: TimeoutReview}, repo.Payment{Amount: 1, Currency: "USD"}, repo.StatusReview},
+		{"under in the reporting currency", approveUnder, repo.Payment{Amount: 4999, Currency: "usd"}, repo.StatusApproved},
+		{"at the threshold", approveUnder, repo.Payment{Amount: 5000, Currency: "USD"}, repo.StatusDeclined},
+		{
+			name:    "converted amount under",
+			policy:  approveUnder,
+			payment: repo.Payment{Amount: 4000, Currency: "EUR", Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 4400}},
+			want:    repo.StatusApproved,
+		},
+		{
+			// 4000 yen is far less than 4000 cents, but 4000 JPY is about 27 USD
+			name:    "few minor units, large converted amount",
+			policy:  approveUnder,
+			payment: repo.Payment{Amount: 4000, Currency: "JPY", Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 2680}},
+			want:    repo.StatusApproved,
+		},
+		{
+			name:    "converted amount over",
+			policy:  approveUnder,
+			payment: repo.Payment{Amount: 1000, Currency: "KWD", Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 325000}},
+			want:    repo.StatusDeclined,
+		},
+		{"not converted", approveUnder, repo.Payment{Amount: 10, Currency: "SEK"}, repo.StatusDeclined},
+		{
+			name:    "converted into another currency",
+			policy:  approveUnder,
+			payment: repo.Payment{Amount: 10, Currency: "SEK", Reporting: &repo.ReportingAmount{Currency: "EUR", Amount: 1}},
+			want:    repo.StatusDeclined,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.decide(tt.payment); got != tt.want {
+				t.Errorf("decide = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSweeperFallback(t *testing.T) {
+	p := repo.Payment{ID: "p-1", Amount: 100, Currency: "USD", CorrelationID: "c-1"}
+
+	s := &Sweeper{orch: &Orchestrator{}, policy: TimeoutPolicy{SLA: 2 * time.Minute, Action: TimeoutReview}}
+	status, score, reason, exp, err := s.fallback(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != repo.StatusReview || score != 0 || reason != "timeout: no risk decision within 2m0s (policy review)" {
+		t.Errorf("fallback = %s, %v, %q; want REVIEW with the timeout reason", status, score, reason)
+	}
+	if !reflect.DeepEqual(exp.ReasonCodes, []string{reasons.DecisionTimeout}) || exp.Contributions != nil {
+		t.Errorf("explanation = %+v, want only %s", exp, reasons.DecisionTimeout)
+	}
+
+	s.policy.Action = TimeoutRules
+	s.orch.rules = ruleFunc(func(repo.Payment) rules.Decision {
+		return rules.Decision{
+			Decision:    repo.StatusDeclined,
+			Score:       0.9,
+			Reason:      "amount_over_limit",
+			ReasonCodes: []string{"amount_over_limit"},
+			Hits:        []rules.Hit{{Rule: "limit", ReasonCode: "amount_over_limit", Score: 0.9}},
+		}
+	})
+	status, score, reason, exp, err = s.fallback(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != repo.StatusDeclined || score != 0.9 || !strings.HasSuffix(reason, "(policy rules): amount_over_limit") {
+		t.Errorf("fallback = %s, %v, %q; want the rule engine's decision", status, score, reason)
+	}
+	if !reflect.DeepEqual(exp.ReasonCodes, []string{reasons.DecisionTimeout, "amount_over_limit"}) {
+		t.Errorf("reason codes = %v, want the timeout code then the rule's", exp.ReasonCodes)
+	}
+	if !reflect.DeepEqual(exp.Contributions, []repo.Contribution{{Feature: "rule:limit", Value: 0.9}}) {
+		t.Errorf("contributions = %v, want the rule hit", exp.Contributions)
+	}
+}
+
+func TestTimeoutEvent(t *testing.T) {
+	// a payment as the sweeper stored it, before its event was written
+	p := repo.Payment{
+		ID:            "p-1",
+		MerchantID:    "m-1",
+		CorrelationID: "c-1",
+		Status:        repo.StatusDeclined,
+		RiskReason:    "timeout: no risk decision within 2m0s (policy decline)",
+		ReasonCodes:   []string{reasons.DecisionTimeout},
+		Experiment:    &repo.ExperimentAssignment{ExperimentID: "exp-1", Arm: "treatment"},
+		TimeoutEvent:  "p-1:timeout:c-1",
+	}
+	event := timeoutEvent(p)
+	if event.ID != "p-1:timeout:c-1" || event.AggregateID != "p-1" || event.CorrelationID != "c-1" || event.Published {
+		t.Errorf("event = %+v, want the unpublished timeout event of p-1", event)
+	}
+
+	var got events.PaymentDecisionFinalized
+	if err := json.Unmarshal(event.Payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := events.PaymentDecisionFinalized{
+		SchemaVersion: events.Default().Current(events.TypePaymentDecisionFinalized),
+		Type:          events.TypePaymentDecisionFinalized,
+		PaymentID:     "p-1",
+		Status:        string(repo.StatusDeclined),
+		Reason:        p.RiskReason,
+		CorrelationID: "c-1",
+		OccurredAt:    got.OccurredAt,
+		TimedOut:      true,
+		ExperimentID:  "exp-1",
+		ExperimentArm: "treatment",
+		MerchantID:    "m-1",
+		ReasonCodes:   []string{reasons.DecisionTimeout},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("payload = %+v, want %+v", got, want)
+	}
+
+	// rebuilding the event later yields the same ID, so the outbox keeps one
+	if again := timeoutEvent(p); again.ID != event.ID {
+		t.Errorf("rebuilt event ID = %s, want %s", again.ID, event.ID)
+	}
+}
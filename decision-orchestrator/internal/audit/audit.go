@@ -0,0 +1,223 @@
+package audit
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type Actor string
+
+const (
+	ActorRiskEngine Actor = "risk_engine"
+	ActorAnalyst    Actor = "analyst"
+	ActorSweeper    Actor = "sweeper"
+	ActorIntake     Actor = "intake"
+	ActorSystem     Actor = "system"
+	ActorFeedback   Actor = "feedback"
+)
+
+type Kind string
+
+const (
+	KindDecision     Kind = "decision"
+	KindOverride     Kind = "override"
+	KindCompensation Kind = "compensation"
+	KindTransition   Kind = "transition"
+	KindLabel        Kind = "label"
+	KindRefund       Kind = "refund"
+)
+
+type KafkaCoordinates struct {
+	Topic     string `bson:"topic" json:"topic"`
+	Partition int    `bson:"partition" json:"partition"`
+	Offset    int64  `bson:"offset" json:"offset"`
+}
+
+// Record is one immutable entry in a payment's decision history. Seq orders
+// records within a payment and is assigned on append; Hash covers the record
+// and the previous record's hash, chaining the payment's history.
+type Record struct {
+	ID             string            `bson:"_id" json:"id"`
+	PaymentID      string            `bson:"payment_id" json:"payment_id"`
+	Seq            int64             `bson:"seq" json:"seq"`
+	Kind           Kind              `bson:"kind" json:"kind"`
+	Actor          Actor             `bson:"actor" json:"actor"`
+	ActorID        string            `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	ApprovedBy     string            `bson:"approved_by,omitempty" json:"approved_by,omitempty"`
+	PreviousStatus string            `bson:"previous_status" json:"previous_status"`
+	NewStatus      string            `bson:"new_status" json:"new_status"`
+	Score          float64           `bson:"score" json:"score"`
+	Reason         string            `bson:"reason" json:"reason"`
+	CorrelationID  string            `bson:"correlation_id" json:"correlation_id"`
+	Kafka          *KafkaCoordinates `bson:"kafka,omitempty" json:"kafka,omitempty"`
+	PolicyVersion  int64             `bson:"policy_version,omitempty" json:"policy_version,omitempty"`
+	ReasonCodes    []string          `bson:"reason_codes,omitempty" json:"reason_codes,omitempty"`
+	ModelVersion   string            `bson:"model_version,omitempty" json:"model_version,omitempty"`
+	ModelScore     float64           `bson:"model_score,omitempty" json:"model_score,omitempty"`
+	RecordedAt     time.Time         `bson:"recorded_at" json:"recorded_at"`
+	PrevHash       string            `bson:"prev_hash" json:"prev_hash"`
+	Hash           string            `bson:"hash" json:"hash"`
+}
+
+// AuditRepo only ever inserts into payment_decisions; there is deliberately
+// no update or delete.
+type AuditRepo struct {
+	col *mongo.Collection
+}
+
+func NewAuditRepo(db *mongo.Database) *AuditRepo {
+	return &AuditRepo{col: db.Collection("payment_decisions")}
+}
+
+func (r *AuditRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "payment_id", Value: 1}, {Key: "seq", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "recorded_at", Value: 1}}},
+	})
+	return err
+}
+
+// Append stores rec as the next entry of its payment's timeline. The unique
+// (payment_id, seq) index serializes concurrent appends; losers retry with
+// the next sequence number.
+func (r *AuditRepo) Append(ctx context.Context, rec Record) (Record, error) {
+	rec.ID = uuid.NewString()
+	rec.RecordedAt = time.Now().UTC().Truncate(time.Millisecond)
+
+	for attempt := 0; attempt < 5; attempt++ {
+		last, err := r.last(ctx, rec.PaymentID)
+		if err != nil {
+			return Record{}, err
+		}
+		rec.Seq = 1
+		rec.PrevHash = ""
+		if last != nil {
+			rec.Seq = last.Seq + 1
+			rec.PrevHash = last.Hash
+		}
+		rec.Hash = hashRecord(rec)
+
+		_, err = r.col.InsertOne(ctx, rec)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return Record{}, err
+		}
+		return rec, nil
+	}
+	return Record{}, errors.New("audit append contended, giving up")
+}
+
+func (r *AuditRepo) Timeline(ctx context.Context, paymentID string) ([]Record, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}})
+	cur, err := r.col.Find(ctx, bson.M{"payment_id": paymentID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	records := []Record{}
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Scan walks records ordered by (payment_id, seq), optionally limited to one
+// payment, which is the order ChainVerifier expects.
+func (r *AuditRepo) Scan(ctx context.Context, paymentID string, fn func(Record) error) error {
+	filter := bson.M{}
+	if paymentID != "" {
+		filter["payment_id"] = paymentID
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "payment_id", Value: 1}, {Key: "seq", Value: 1}})
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var rec Record
+		if err := cur.Decode(&rec); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// DayHashes returns the hashes of all records appended on the given UTC day
+// in (payment_id, seq) order: the leaves of that day's Merkle tree.
+func (r *AuditRepo) DayHashes(ctx context.Context, day time.Time) ([][]byte, error) {
+	start := day.UTC().Truncate(24 * time.Hour)
+	filter := bson.M{"recorded_at": bson.M{"$gte": start, "$lt": start.Add(24 * time.Hour)}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "payment_id", Value: 1}, {Key: "seq", Value: 1}}).
+		SetProjection(bson.M{"hash": 1})
+
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var leaves [][]byte
+	for cur.Next(ctx) {
+		var rec struct {
+			Hash string `bson:"hash"`
+		}
+		if err := cur.Decode(&rec); err != nil {
+			return nil, err
+		}
+		leaf, err := hex.DecodeString(rec.Hash)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves, cur.Err()
+}
+
+// FirstDay returns the UTC day of the oldest record; false means there are
+// no records yet.
+func (r *AuditRepo) FirstDay(ctx context.Context) (time.Time, bool, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "recorded_at", Value: 1}}).SetProjection(bson.M{"recorded_at": 1})
+	var rec struct {
+		RecordedAt time.Time `bson:"recorded_at"`
+	}
+	err := r.col.FindOne(ctx, bson.M{}, opts).Decode(&rec)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return rec.RecordedAt.UTC().Truncate(24 * time.Hour), true, nil
+}
+
+func (r *AuditRepo) last(ctx context.Context, paymentID string) (*Record, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+	var rec Record
+	err := r.col.FindOne(ctx, bson.M{"payment_id": paymentID}, opts).Decode(&rec)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
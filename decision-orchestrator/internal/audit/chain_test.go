@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// chain builds a correctly chained history for one payment.
+func chain(paymentID string, n int) []Record {
+	at := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	records := make([]Record, n)
+	prev := ""
+	for i := range records {
+		rec := Record{
+			ID:             paymentID + "-" + string(rune('a'+i)),
+			PaymentID:      paymentID,
+			Seq:            int64(i + 1),
+			Kind:           KindDecision,
+			Actor:          ActorRiskEngine,
+			PreviousStatus: "PENDING",
+			NewStatus:      "APPROVED",
+			Score:          0.25,
+			Reason:         "low risk",
+			CorrelationID:  "c-1",
+			RecordedAt:     at.Add(time.Duration(i) * time.Minute),
+			PrevHash:       prev,
+		}
+		rec.Hash = hashRecord(rec)
+		prev = rec.Hash
+		records[i] = rec
+	}
+	return records
+}
+
+func TestHashRecord(t *testing.T) {
+	rec := chain("p-1", 1)[0]
+	if len(rec.Hash) != 64 {
+		t.Fatalf("hash %q is not hex encoded sha256", rec.Hash)
+	}
+
+	// stored and read back in another zone, or with the hash filled in, it
+	// hashes the same
+	same := rec
+	same.RecordedAt = rec.RecordedAt.In(time.FixedZone("CEST", 2*3600))
+	if hashRecord(same) != rec.Hash {
+		t.Error("hash depends on the time zone of recorded_at")
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Record)
+	}{
+		{"status", func(r *Record) { r.NewStatus = "DECLINED" }},
+		{"score", func(r *Record) { r.Score = 0.26 }},
+		{"reason", func(r *Record) { r.Reason = "high risk" }},
+		{"actor", func(r *Record) { r.Actor = ActorAnalyst }},
+		{"approver", func(r *Record) { r.ApprovedBy = "analyst-2" }},
+		{"seq", func(r *Record) { r.Seq = 2 }},
+		{"prev hash", func(r *Record) { r.PrevHash = strings.Repeat("0", 64) }},
+		{"recorded at", func(r *Record) { r.RecordedAt = r.RecordedAt.Add(time.Millisecond) }},
+		{"reason codes", func(r *Record) { r.ReasonCodes = []string{"amount_over_limit"} }},
+		{"kafka offset", func(r *Record) { r.Kafka = &KafkaCoordinates{Topic: "risk", Offset: 7} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := rec
+			tt.modify(&changed)
+			if hashRecord(changed) == rec.Hash {
+				t.Errorf("changing the %s kept the hash", tt.name)
+			}
+		})
+	}
+}
+
+func TestChainVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		records func() []Record
+		breaks  []string
+	}{
+		{
+			name: "intact chains of two payments",
+			records: func() []Record {
+				return append(chain("p-1", 3), chain("p-2", 2)...)
+			},
+		},
+		{
+			name: "edited record",
+			records: func() []Record {
+				rs := chain("p-1", 3)
+				rs[1].NewStatus = "DECLINED"
+				return rs
+			},
+			breaks: []string{"hash mismatch"},
+		},
+		{
+			name: "edited record with its hash recomputed",
+			records: func() []Record {
+				rs := chain("p-1", 3)
+				rs[1].NewStatus = "DECLINED"
+				rs[1].Hash = hashRecord(rs[1])
+				return rs
+			},
+			breaks: []string{"prev_hash"},
+		},
+		{
+			name: "deleted record",
+			records: func() []Record {
+				rs := chain("p-1", 3)
+				return []Record{rs[0], rs[2]}
+			},
+			breaks: []string{"sequence gap", "prev_hash"},
+		},
+		{
+			name: "history starting late",
+			records: func() []Record {
+				return chain("p-1", 3)[1:]
+			},
+			breaks: []string{"sequence gap", "prev_hash"},
+		},
+		{
+			name: "unchained record",
+			records: func() []Record {
+				rs := chain("p-1", 2)
+				rs[1].Hash = ""
+				return rs
+			},
+			breaks: []string{"not chained"},
+		},
+		{
+			name: "break in one payment leaves the next intact",
+			records: func() []Record {
+				rs := chain("p-1", 2)
+				rs[0].Reason = "edited"
+				return append(rs, chain("p-2", 2)...)
+			},
+			breaks: []string{"hash mismatch"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := tt.records()
+			var v ChainVerifier
+			for _, rec := range records {
+				v.Add(rec)
+			}
+			if v.Checked != len(records) {
+				t.Errorf("Checked = %d, want %d", v.Checked, len(records))
+			}
+			if len(v.Breaks) != len(tt.breaks) {
+				t.Fatalf("Breaks = %+v, want %v", v.Breaks, tt.breaks)
+			}
+			for i, b := range v.Breaks {
+				if !strings.Contains(b.Problem, tt.breaks[i]) {
+					t.Errorf("break %d = %q, want %q", i, b.Problem, tt.breaks[i])
+				}
+				if b.PaymentID != "p-1" {
+					t.Errorf("break %d is on %s, want p-1", i, b.PaymentID)
+				}
+			}
+		})
+	}
+}
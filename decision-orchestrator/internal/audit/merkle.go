@@ -0,0 +1,37 @@
+package audit
+
+import "crypto/sha256"
+
+// MerkleRoot builds a binary hash tree over the leaves using distinct leaf
+// and node prefixes (as in RFC 6962); an odd node is promoted unchanged to
+// the next level. An empty set hashes to sha256 of the empty string.
+func MerkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	}
+
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		sum := sha256.Sum256(append([]byte{0x00}, leaf...))
+		level[i] = sum[:]
+	}
+
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			buf := make([]byte, 0, 1+2*sha256.Size)
+			buf = append(buf, 0x01)
+			buf = append(buf, level[i]...)
+			buf = append(buf, level[i+1]...)
+			sum := sha256.Sum256(buf)
+			next = append(next, sum[:])
+		}
+		level = next
+	}
+	return level[0]
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// ctLeaves are the leaves of the Certificate Transparency reference tests
+// for RFC 6962 tree hashes.
+var ctLeaves = [][]byte{
+	{},
+	{0x00},
+	{0x10},
+	{0x20, 0x21},
+	{0x30, 0x31},
+	{0x40, 0x41, 0x42, 0x43},
+	{0x50, 0x51, 0x52, 0x53, 0x54, 0x55, 0x56, 0x57},
+	{0x60, 0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f},
+}
+
+func TestMerkleRootKnownAnswers(t *testing.T) {
+	// roots of the first n leaves, covering every odd count up to 7
+	want := []string{
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+	for n, root := range want {
+		if got := hex.EncodeToString(MerkleRoot(ctLeaves[:n+1])); got != root {
+			t.Errorf("MerkleRoot of %d leaves = %s, want %s", n+1, got, root)
+		}
+	}
+}
+
+func TestMerkleRootPrefixes(t *testing.T) {
+	empty := sha256.Sum256(nil)
+	if got := MerkleRoot(nil); !bytes.Equal(got, empty[:]) {
+		t.Errorf("MerkleRoot of no leaves = %x, want sha256 of the empty string", got)
+	}
+
+	leaf := []byte("record")
+	leafHash := sha256.Sum256(append([]byte{0x00}, leaf...))
+	if got := MerkleRoot([][]byte{leaf}); !bytes.Equal(got, leafHash[:]) {
+		t.Errorf("MerkleRoot of one leaf = %x, want its 0x00 prefixed hash", got)
+	}
+
+	// a leaf that looks like an interior node must not collide with it
+	a, b := []byte("a"), []byte("b")
+	root := MerkleRoot([][]byte{a, b})
+	ha := sha256.Sum256(append([]byte{0x00}, a...))
+	hb := sha256.Sum256(append([]byte{0x00}, b...))
+	forged := append(append([]byte{}, ha[:]...), hb[:]...)
+	if bytes.Equal(MerkleRoot([][]byte{forged}), root) {
+		t.Error("a single leaf of two concatenated leaf hashes has the root of the two leaves")
+	}
+
+	if bytes.Equal(MerkleRoot([][]byte{b, a}), root) {
+		t.Error("reordering leaves kept the root")
+	}
+}
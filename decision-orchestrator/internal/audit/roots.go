@@ -0,0 +1,256 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+const dayLayout = "2006-01-02"
+
+// DailyRoot is the Merkle root over every audit record appended on one UTC
+// day, signed so it can be handed to auditors and checked independently.
+type DailyRoot struct {
+	Day        string    `bson:"_id" json:"day"`
+	Root       string    `bson:"root" json:"root"`
+	LeafCount  int       `bson:"leaf_count" json:"leaf_count"`
+	Algorithm  string    `bson:"algorithm" json:"algorithm"`
+	KeyID      string    `bson:"key_id" json:"key_id"`
+	Signature  []byte    `bson:"signature" json:"signature"`
+	ComputedAt time.Time `bson:"computed_at" json:"computed_at"`
+}
+
+// SignedMessage is the exact byte string the signature covers.
+func (d DailyRoot) SignedMessage() []byte {
+	return []byte("payments-audit-root:v1:" + d.Day + ":" + d.Root + ":" + strconv.Itoa(d.LeafCount))
+}
+
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// LoadSigner reads a PKCS#8 PEM encoded Ed25519 private key.
+func LoadSigner(path string) (*Signer, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("audit signing key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("audit signing key must be ed25519")
+	}
+	return &Signer{key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}, nil
+}
+
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+func (s *Signer) Sign(d DailyRoot) DailyRoot {
+	d.Algorithm = "ed25519"
+	d.KeyID = s.keyID
+	d.Signature = ed25519.Sign(s.key, d.SignedMessage())
+	return d
+}
+
+// KeyID is the first 8 bytes of the SHA-256 of the public key, hex encoded.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+func VerifyRoot(pub ed25519.PublicKey, d DailyRoot) bool {
+	return d.Algorithm == "ed25519" && ed25519.Verify(pub, d.SignedMessage(), d.Signature)
+}
+
+type RootRepo struct {
+	col *mongo.Collection
+}
+
+func NewRootRepo(db *mongo.Database) *RootRepo {
+	return &RootRepo{col: db.Collection("audit_roots")}
+}
+
+func (r *RootRepo) Insert(ctx context.Context, d DailyRoot) error {
+	_, err := r.col.InsertOne(ctx, d)
+	return err
+}
+
+func (r *RootRepo) Get(ctx context.Context, day string) (*DailyRoot, error) {
+	var d DailyRoot
+	if err := r.col.FindOne(ctx, bson.M{"_id": day}).Decode(&d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// List returns roots for days in [from, to], both formatted as YYYY-MM-DD.
+func (r *RootRepo) List(ctx context.Context, from, to string) ([]DailyRoot, error) {
+	filter := bson.M{}
+	rng := bson.M{}
+	if from != "" {
+		rng["$gte"] = from
+	}
+	if to != "" {
+		rng["$lte"] = to
+	}
+	if len(rng) > 0 {
+		filter["_id"] = rng
+	}
+
+	cur, err := r.col.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	roots := []DailyRoot{}
+	if err := cur.All(ctx, &roots); err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
+func (r *RootRepo) latest(ctx context.Context) (*DailyRoot, error) {
+	var d DailyRoot
+	err := r.col.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func ComputeRoot(ctx context.Context, records *AuditRepo, day time.Time) (DailyRoot, error) {
+	leaves, err := records.DayHashes(ctx, day)
+	if err != nil {
+		return DailyRoot{}, err
+	}
+	return DailyRoot{
+		Day:        day.UTC().Format(dayLayout),
+		Root:       hex.EncodeToString(MerkleRoot(leaves)),
+		LeafCount:  len(leaves),
+		ComputedAt: time.Now().UTC(),
+	}, nil
+}
+
+// RootJob seals every finished UTC day that has no root yet, starting with
+// the day of the first audit record. Days are only sealed once grace has
+// passed after midnight so late appends are included.
+// It should run on a single replica.
+type RootJob struct {
+	log      *zap.Logger
+	records  *AuditRepo
+	roots    *RootRepo
+	signer   *Signer
+	interval time.Duration
+	grace    time.Duration
+}
+
+func NewRootJob(l *zap.Logger, records *AuditRepo, roots *RootRepo, signer *Signer, interval time.Duration) *RootJob {
+	return &RootJob{
+		log:      l,
+		records:  records,
+		roots:    roots,
+		signer:   signer,
+		interval: interval,
+		grace:    5 * time.Minute,
+	}
+}
+
+func (j *RootJob) Run(ctx context.Context) error {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.sealPending(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			j.log.Error("audit root sealing failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *RootJob) sealPending(ctx context.Context) error {
+	latest, err := j.roots.latest(ctx)
+	if err != nil {
+		return err
+	}
+	var first time.Time
+	if latest == nil {
+		day, ok, err := j.records.FirstDay(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		first = day
+	}
+	day, last, err := unsealedDays(latest, first, time.Now(), j.grace)
+	if err != nil {
+		return err
+	}
+
+	for ; !day.After(last); day = day.Add(24 * time.Hour) {
+		root, err := ComputeRoot(ctx, j.records, day)
+		if err != nil {
+			return err
+		}
+		if err := j.roots.Insert(ctx, j.signer.Sign(root)); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			return fmt.Errorf("store root for %s: %w", root.Day, err)
+		}
+		j.log.Info("audit day sealed", zap.String("day", root.Day), zap.String("root", root.Root), zap.Int("records", root.LeafCount))
+	}
+	return nil
+}
+
+// unsealedDays returns the first and last day to seal: from the day after
+// the latest root, or from the day of the first record when nothing was
+// sealed yet, up to the last day that ended grace ago. The range is empty
+// when the first day is after the last.
+func unsealedDays(latest *DailyRoot, firstRecord, now time.Time, grace time.Duration) (time.Time, time.Time, error) {
+	last := now.UTC().Add(-grace).Truncate(24 * time.Hour).Add(-24 * time.Hour)
+	if latest == nil {
+		return firstRecord.UTC().Truncate(24 * time.Hour), last, nil
+	}
+	prev, err := time.Parse(dayLayout, latest.Day)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return prev.Add(24 * time.Hour), last, nil
+}
@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testSigner(t *testing.T) *Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "audit.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSigner(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSignAndVerifyRoot(t *testing.T) {
+	s := testSigner(t)
+	root := s.Sign(DailyRoot{Day: "2026-06-01", Root: "ab12", LeafCount: 3})
+	if root.Algorithm != "ed25519" || root.KeyID != KeyID(s.PublicKey()) || len(root.KeyID) != 16 {
+		t.Errorf("signed root = %+v, want ed25519 with the signer's key id", root)
+	}
+	if !VerifyRoot(s.PublicKey(), root) {
+		t.Fatal("VerifyRoot rejected a root signed with its key")
+	}
+
+	other := testSigner(t)
+	if VerifyRoot(other.PublicKey(), root) {
+		t.Error("VerifyRoot accepted a root with another key")
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*DailyRoot)
+	}{
+		{"day", func(d *DailyRoot) { d.Day = "2026-06-02" }},
+		{"root", func(d *DailyRoot) { d.Root = "ab13" }},
+		{"leaf count", func(d *DailyRoot) { d.LeafCount = 4 }},
+		{"algorithm", func(d *DailyRoot) { d.Algorithm = "rsa" }},
+		{"signature", func(d *DailyRoot) { d.Signature = append([]byte{}, d.Signature[:len(d.Signature)-1]...) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := root
+			tt.modify(&changed)
+			if VerifyRoot(s.PublicKey(), changed) {
+				t.Errorf("VerifyRoot accepted a root with a changed %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestLoadSignerErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"missing file", filepath.Join(dir, "missing.pem")},
+		{"not pem", write("raw.key", []byte("not a key"))},
+		{"not pkcs8", write("junk.pem", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("junk")}))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadSigner(tt.path); err == nil {
+				t.Error("LoadSigner succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestUnsealedDays(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse(dayLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	now := time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC)
+	grace := 5 * time.Minute
+	tests := []struct {
+		name        string
+		latest      *DailyRoot
+		firstRecord time.Time
+		now         time.Time
+		from, last  string
+	}{
+		{
+			name:        "first run starts at the first record",
+			firstRecord: time.Date(2026, 6, 3, 17, 30, 0, 0, time.UTC),
+			now:         now,
+			from:        "2026-06-03",
+			last:        "2026-06-09",
+		},
+		{
+			name:        "first record in another zone",
+			firstRecord: time.Date(2026, 6, 4, 1, 0, 0, 0, time.FixedZone("CEST", 2*3600)),
+			now:         now,
+			from:        "2026-06-03",
+			last:        "2026-06-09",
+		},
+		{
+			name:   "continues after the latest root",
+			latest: &DailyRoot{Day: "2026-06-07"},
+			now:    now,
+			from:   "2026-06-08",
+			last:   "2026-06-09",
+		},
+		{
+			name:   "up to date",
+			latest: &DailyRoot{Day: "2026-06-09"},
+			now:    now,
+			from:   "2026-06-10",
+			last:   "2026-06-09",
+		},
+		{
+			name:   "yesterday waits for grace after midnight",
+			latest: &DailyRoot{Day: "2026-06-08"},
+			now:    time.Date(2026, 6, 10, 0, 4, 0, 0, time.UTC),
+			from:   "2026-06-09",
+			last:   "2026-06-08",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, last, err := unsealedDays(tt.latest, tt.firstRecord, tt.now, grace)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !from.Equal(day(tt.from)) || !last.Equal(day(tt.last)) {
+				t.Errorf("unsealedDays = %s..%s, want %s..%s", from.Format(dayLayout), last.Format(dayLayout), tt.from, tt.last)
+			}
+		})
+	}
+
+	if _, _, err := unsealedDays(&DailyRoot{Day: "June 7"}, time.Time{}, now, grace); err == nil {
+		t.Error("unsealedDays accepted a root with a malformed day")
+	}
+}
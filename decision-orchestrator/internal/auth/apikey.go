@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type apiKey struct {
+	ID     string `json:"id"`
+	SHA256 string `json:"sha256"`
+	Role   string `json:"role"`
+}
+
+// APIKeys authenticates static keys sent in the X-API-Key header. The key
+// file only holds SHA-256 digests of the keys:
+//
+//	{"keys": [{"id": "ops-dashboard", "sha256": "<hex>", "role": "viewer"}]}
+type APIKeys struct {
+	keys []loadedKey
+}
+
+type loadedKey struct {
+	digest []byte
+	p      Principal
+}
+
+func LoadAPIKeys(path string) (*APIKeys, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Keys []apiKey `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, err
+	}
+
+	a := &APIKeys{}
+	for _, k := range file.Keys {
+		digest, err := hex.DecodeString(k.SHA256)
+		if err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("key %q: sha256 must be 64 hex characters", k.ID)
+		}
+		role, err := ParseRole(k.Role)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.ID, err)
+		}
+		a.keys = append(a.keys, loadedKey{digest: digest, p: Principal{Subject: k.ID, Role: role, Method: "api_key"}})
+	}
+	return a, nil
+}
+
+func (a *APIKeys) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	sum := sha256.Sum256([]byte(key))
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], k.digest) == 1 {
+			p := k.p
+			return &p, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
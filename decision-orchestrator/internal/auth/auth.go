@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type Role string
+
+const (
+	RoleViewer  Role = "viewer"
+	RoleAnalyst Role = "analyst"
+	RoleAdmin   Role = "admin"
+	// RolePublic marks routes that need no credentials at all.
+	RolePublic Role = "public"
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleAnalyst: 2, RoleAdmin: 3}
+
+func ParseRole(s string) (Role, error) {
+	r := Role(s)
+	if _, ok := roleRank[r]; !ok {
+		return "", fmt.Errorf("unknown role %q", s)
+	}
+	return r, nil
+}
+
+// Allows reports whether r includes the permissions of required. Roles are
+// ordered: admin can do everything an analyst can, an analyst everything a
+// viewer can.
+func (r Role) Allows(required Role) bool {
+	if required == RolePublic {
+		return true
+	}
+	have, ok := roleRank[r]
+	return ok && have >= roleRank[required]
+}
+
+type Principal struct {
+	Subject string `json:"subject"`
+	Role    Role   `json:"role"`
+	Method  string `json:"method"`
+}
+
+var (
+	// ErrNoCredentials means the request carries nothing this authenticator
+	// understands, so the next one in the chain should be tried.
+	ErrNoCredentials      = errors.New("no credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Chain tries each authenticator in turn until one recognizes the request.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return nil, ErrNoCredentials
+}
+
+type Settings struct {
+	APIKeysFile string
+	HMACSecret  string
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+	RoleClaim   string
+}
+
+// New builds the chain of every authenticator that has settings.
+func New(s Settings) (Chain, error) {
+	var chain Chain
+	if s.APIKeysFile != "" {
+		keys, err := LoadAPIKeys(s.APIKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("api keys: %w", err)
+		}
+		chain = append(chain, keys)
+	}
+	if s.HMACSecret != "" {
+		chain = append(chain, NewServiceTokens([]byte(s.HMACSecret)))
+	}
+	if s.JWKSFile != "" {
+		jwt, err := LoadJWTVerifier(s.JWKSFile, s.JWTIssuer, s.JWTAudience, s.RoleClaim)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: %w", err)
+		}
+		chain = append(chain, jwt)
+	}
+	return chain, nil
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
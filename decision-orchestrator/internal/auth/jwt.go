@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const clockSkew = 30 * time.Second
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWTVerifier authenticates bearer JWTs signed by a key in a local JWKS
+// file and issued by the configured issuer for the configured audience.
+// RS256, ES256 and EdDSA are accepted; the role is read from the configured
+// claim, which may hold a single role or a list.
+type JWTVerifier struct {
+	keys      map[string]crypto.PublicKey
+	issuer    string
+	audience  string
+	roleClaim string
+	now       func() time.Time
+}
+
+func LoadJWTVerifier(path, issuer, audience, roleClaim string) (*JWTVerifier, error) {
+	if issuer == "" || audience == "" {
+		return nil, errors.New("issuer and audience are required")
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no keys in set")
+	}
+	if roleClaim == "" {
+		roleClaim = "role"
+	}
+	return &JWTVerifier{keys: keys, issuer: issuer, audience: audience, roleClaim: roleClaim, now: time.Now}, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	b := func(s string) []byte {
+		v, _ := base64.RawURLEncoding.DecodeString(s)
+		return v
+	}
+	switch {
+	case k.Kty == "RSA":
+		n, e := b(k.N), b(k.E)
+		if len(n) == 0 || len(e) == 0 {
+			return nil, errors.New("rsa key needs n and e")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, y := b(k.X), b(k.Y)
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("P-256 key needs 32 byte x and y")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x := b(k.X)
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("Ed25519 key needs 32 byte x")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s %s", k.Kty, k.Crv)
+	}
+}
+
+func (v *JWTVerifier) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearer(r)
+	if !ok || strings.HasPrefix(token, serviceTokenPrefix) {
+		return nil, ErrNoCredentials
+	}
+	claims, err := v.verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidCredentials)
+	}
+	role, ok := highestRole(claims[v.roleClaim])
+	if !ok {
+		return nil, fmt.Errorf("%w: no known role in %q", ErrInvalidCredentials, v.roleClaim)
+	}
+	return &Principal{Subject: sub, Role: role, Method: "jwt"}, nil
+}
+
+func (v *JWTVerifier) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	key, ok := v.keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", header.Kid)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.New("missing exp")
+	}
+	if now.Add(-clockSkew).Unix() >= int64(exp) {
+		return nil, errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Unix() < int64(nbf) {
+		return nil, errors.New("token not valid yet")
+	}
+	if claims["iss"] != v.issuer {
+		return nil, errors.New("unexpected issuer")
+	}
+	if !hasAudience(claims["aud"], v.audience) {
+		return nil, errors.New("unexpected audience")
+	}
+	return claims, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	digest := sha256.Sum256(signed)
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg == "RS256" {
+			return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig)
+		}
+	case *ecdsa.PublicKey:
+		if alg == "ES256" {
+			if len(sig) != 64 {
+				return errors.New("bad ES256 signature length")
+			}
+			r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+			if !ecdsa.Verify(k, digest[:], r, s) {
+				return errors.New("bad signature")
+			}
+			return nil
+		}
+	case ed25519.PublicKey:
+		if alg == "EdDSA" {
+			if !ed25519.Verify(k, signed, sig) {
+				return errors.New("bad signature")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("algorithm %q does not match key", alg)
+}
+
+func decodeSegment(seg string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func hasAudience(claim any, want string) bool {
+	switch aud := claim.(type) {
+	case string:
+		return aud == want
+	case []any:
+		for _, a := range aud {
+			if a == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func highestRole(claim any) (Role, bool) {
+	var candidates []any
+	switch c := claim.(type) {
+	case string:
+		candidates = []any{c}
+	case []any:
+		candidates = c
+	}
+
+	var best Role
+	for _, c := range candidates {
+		s, _ := c.(string)
+		role, err := ParseRole(s)
+		if err == nil && roleRank[role] > roleRank[best] {
+			best = role
+		}
+	}
+	return best, best != ""
+}
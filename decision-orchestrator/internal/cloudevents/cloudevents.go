@@ -0,0 +1,127 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	SpecVersion = "1.0"
+
+	ModeStructured = "structured"
+	ModeBinary     = "binary"
+
+	StructuredContentType = "application/cloudevents+json; charset=UTF-8"
+)
+
+var ErrInvalidEvent = errors.New("cloudevent requires id, source and type")
+
+type Event struct {
+	ID              string
+	Source          string
+	Type            string
+	Subject         string
+	Time            time.Time
+	DataContentType string
+	Data            []byte
+	Extensions      map[string]string
+}
+
+func New(id, source, eventType string) Event {
+	return Event{
+		ID:         id,
+		Source:     source,
+		Type:       eventType,
+		Time:       time.Now().UTC(),
+		Extensions: map[string]string{},
+	}
+}
+
+func (e Event) Validate() error {
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return ErrInvalidEvent
+	}
+	return nil
+}
+
+// MarshalJSON renders the event in the structured JSON format. JSON data is
+// embedded as-is, anything else travels base64 encoded in data_base64.
+func (e Event) MarshalJSON() ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	out := map[string]any{
+		"specversion": SpecVersion,
+		"id":          e.ID,
+		"source":      e.Source,
+		"type":        e.Type,
+	}
+	if e.Subject != "" {
+		out["subject"] = e.Subject
+	}
+	if !e.Time.IsZero() {
+		out["time"] = e.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if e.DataContentType != "" {
+		out["datacontenttype"] = e.DataContentType
+	}
+	for k, v := range e.Extensions {
+		out[k] = v
+	}
+	if len(e.Data) > 0 {
+		if isJSON(e.DataContentType) && json.Valid(e.Data) {
+			out["data"] = json.RawMessage(e.Data)
+		} else {
+			out["data_base64"] = e.Data
+		}
+	}
+	return json.Marshal(out)
+}
+
+// KafkaMessage maps the event onto a Kafka value and headers following the
+// CloudEvents Kafka protocol binding.
+func (e Event) KafkaMessage(mode string) ([]byte, []kafka.Header, error) {
+	switch mode {
+	case ModeStructured:
+		value, err := json.Marshal(e)
+		if err != nil {
+			return nil, nil, err
+		}
+		return value, []kafka.Header{{Key: "content-type", Value: []byte(StructuredContentType)}}, nil
+	case ModeBinary:
+		if err := e.Validate(); err != nil {
+			return nil, nil, err
+		}
+		headers := []kafka.Header{
+			{Key: "ce_specversion", Value: []byte(SpecVersion)},
+			{Key: "ce_id", Value: []byte(e.ID)},
+			{Key: "ce_source", Value: []byte(e.Source)},
+			{Key: "ce_type", Value: []byte(e.Type)},
+		}
+		if e.Subject != "" {
+			headers = append(headers, kafka.Header{Key: "ce_subject", Value: []byte(e.Subject)})
+		}
+		if !e.Time.IsZero() {
+			headers = append(headers, kafka.Header{Key: "ce_time", Value: []byte(e.Time.UTC().Format(time.RFC3339Nano))})
+		}
+		if e.DataContentType != "" {
+			headers = append(headers, kafka.Header{Key: "content-type", Value: []byte(e.DataContentType)})
+		}
+		for k, v := range e.Extensions {
+			headers = append(headers, kafka.Header{Key: "ce_" + k, Value: []byte(v)})
+		}
+		return e.Data, headers, nil
+	default:
+		return nil, nil, errors.New("unknown cloudevents mode: " + mode)
+	}
+}
+
+func isJSON(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return ct == "" || ct == "application/json" || ct == "text/json" || strings.HasSuffix(ct, "+json")
+}
@@ -0,0 +1,131 @@
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func sample() Event {
+	e := New("evt-1", "decision-orchestrator", "PaymentDecisionFinalized")
+	e.Subject = "p-1"
+	e.Time = time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC)
+	e.DataContentType = "application/json"
+	e.Data = []byte(`{"payment_id":"p-1"}`)
+	e.Extensions["schemaversion"] = "2"
+	return e
+}
+
+func TestMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		wantData    string
+		wantBase64  bool
+	}{
+		{"json data is embedded", "application/json", []byte(`{"a":1}`), `{"a":1}`, false},
+		{"missing content type is json", "", []byte(`{"a":1}`), `{"a":1}`, false},
+		{"+json suffix is json", "application/vnd.payment+json; charset=utf-8", []byte(`[1]`), `[1]`, false},
+		{"invalid json is base64", "application/json", []byte(`{`), "", true},
+		{"binary data is base64", "application/avro", []byte{0x00, 0x01, 0xff}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := sample()
+			e.DataContentType, e.Data = tt.contentType, tt.data
+			raw, err := json.Marshal(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if string(doc["specversion"]) != `"1.0"` || string(doc["id"]) != `"evt-1"` || string(doc["schemaversion"]) != `"2"` {
+				t.Errorf("attributes = %s", raw)
+			}
+			if string(doc["time"]) != `"2026-01-02T03:04:05.0000006Z"` {
+				t.Errorf("time = %s", doc["time"])
+			}
+			if tt.wantBase64 {
+				var b64 string
+				if err := json.Unmarshal(doc["data_base64"], &b64); err != nil {
+					t.Fatalf("data_base64 missing in %s", raw)
+				}
+				if b64 != base64.StdEncoding.EncodeToString(tt.data) {
+					t.Errorf("data_base64 = %s", b64)
+				}
+				if _, ok := doc["data"]; ok {
+					t.Errorf("both data and data_base64 in %s", raw)
+				}
+				return
+			}
+			if string(doc["data"]) != tt.wantData {
+				t.Errorf("data = %s, want %s", doc["data"], tt.wantData)
+			}
+		})
+	}
+}
+
+func TestMarshalJSONInvalid(t *testing.T) {
+	for _, e := range []Event{{Source: "s", Type: "t"}, {ID: "i", Type: "t"}, {ID: "i", Source: "s"}} {
+		if _, err := json.Marshal(e); err == nil {
+			t.Errorf("Marshal(%+v) succeeded, want ErrInvalidEvent", e)
+		}
+	}
+}
+
+func TestKafkaMessage(t *testing.T) {
+	e := sample()
+
+	value, headers, err := e.KafkaMessage(ModeStructured)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 1 || headers[0].Key != "content-type" || string(headers[0].Value) != StructuredContentType {
+		t.Errorf("structured headers = %v", headers)
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(value, &doc); err != nil || string(doc["data"]) != string(e.Data) {
+		t.Errorf("structured value = %s, %v", value, err)
+	}
+
+	value, headers, err = e.KafkaMessage(ModeBinary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(value) != string(e.Data) {
+		t.Errorf("binary value = %s, want the data", value)
+	}
+	got := map[string]string{}
+	for _, h := range headers {
+		got[h.Key] = string(h.Value)
+	}
+	want := map[string]string{
+		"ce_specversion":   "1.0",
+		"ce_id":            "evt-1",
+		"ce_source":        "decision-orchestrator",
+		"ce_type":          "PaymentDecisionFinalized",
+		"ce_subject":       "p-1",
+		"ce_time":          "2026-01-02T03:04:05.0000006Z",
+		"ce_schemaversion": "2",
+		"content-type":     "application/json",
+	}
+	if len(got) != len(want) {
+		t.Errorf("binary headers = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("header %s = %q, want %q", k, got[k], v)
+		}
+	}
+
+	if _, _, err := e.KafkaMessage("batched"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+	if _, _, err := (Event{}).KafkaMessage(ModeBinary); err == nil {
+		t.Error("binary mode accepted an invalid event")
+	}
+}
@@ -0,0 +1,182 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hamba/avro/v2"
+)
+
+func decodeJSON(payload []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// fromJSON coerces a generic JSON document into the Go types the avro
+// encoder expects for the given schema.
+func fromJSON(s avro.Schema, v any) (any, error) {
+	switch s := s.(type) {
+	case *avro.RefSchema:
+		return fromJSON(s.Schema(), v)
+	case *avro.RecordSchema:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected object, got %T", s.FullName(), v)
+		}
+		out := make(map[string]any, len(s.Fields()))
+		for _, f := range s.Fields() {
+			fv, ok := m[f.Name()]
+			if !ok {
+				if !f.HasDefault() {
+					return nil, fmt.Errorf("%s: missing field %q", s.FullName(), f.Name())
+				}
+				out[f.Name()] = f.Default()
+				continue
+			}
+			nv, err := fromJSON(f.Type(), fv)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", s.FullName(), f.Name(), err)
+			}
+			out[f.Name()] = nv
+		}
+		return out, nil
+	case *avro.ArraySchema:
+		items, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected array, got %T", v)
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			nv, err := fromJSON(s.Items(), item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = nv
+		}
+		return out, nil
+	case *avro.MapSchema:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected object, got %T", v)
+		}
+		out := make(map[string]any, len(m))
+		for k, item := range m {
+			nv, err := fromJSON(s.Values(), item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = nv
+		}
+		return out, nil
+	case *avro.UnionSchema:
+		if v == nil && s.Nullable() {
+			return nil, nil
+		}
+		for _, t := range s.Types() {
+			if t.Type() == avro.Null {
+				continue
+			}
+			if nv, err := fromJSON(t, v); err == nil {
+				return nv, nil
+			}
+		}
+		return nil, fmt.Errorf("value %v matches no union branch", v)
+	case *avro.EnumSchema:
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected enum symbol, got %T", v)
+		}
+		return str, nil
+	case *avro.FixedSchema:
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %T", v)
+		}
+		return []byte(str), nil
+	case *avro.PrimitiveSchema:
+		return primitiveFromJSON(s, v)
+	}
+	return nil, fmt.Errorf("unsupported avro schema %s", s.Type())
+}
+
+func primitiveFromJSON(s *avro.PrimitiveSchema, v any) (any, error) {
+	if ls := s.Logical(); ls != nil {
+		switch ls.Type() {
+		case avro.TimestampMillis, avro.TimestampMicros:
+			switch t := v.(type) {
+			case string:
+				return time.Parse(time.RFC3339Nano, t)
+			case json.Number:
+				ms, err := t.Int64()
+				if err != nil {
+					return nil, err
+				}
+				if ls.Type() == avro.TimestampMicros {
+					return time.UnixMicro(ms).UTC(), nil
+				}
+				return time.UnixMilli(ms).UTC(), nil
+			}
+			return nil, fmt.Errorf("expected timestamp, got %T", v)
+		}
+	}
+
+	switch s.Type() {
+	case avro.Null:
+		if v != nil {
+			return nil, fmt.Errorf("expected null, got %T", v)
+		}
+		return nil, nil
+	case avro.Boolean:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected boolean, got %T", v)
+		}
+		return b, nil
+	case avro.Int, avro.Long:
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("expected integer, got %T", v)
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return nil, err
+		}
+		if s.Type() == avro.Int {
+			return int(i), nil
+		}
+		return i, nil
+	case avro.Float, avro.Double:
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("expected number, got %T", v)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, err
+		}
+		if s.Type() == avro.Float {
+			return float32(f), nil
+		}
+		return f, nil
+	case avro.String:
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %T", v)
+		}
+		return str, nil
+	case avro.Bytes:
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %T", v)
+		}
+		return []byte(str), nil
+	}
+	return nil, fmt.Errorf("unsupported avro type %s", s.Type())
+}
@@ -0,0 +1,177 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/schema"
+	"github.com/segmentio/kafka-go"
+)
+
+const SchemaIDHeader = "schema_id"
+
+type Encoded struct {
+	Value       []byte
+	ContentType string
+	SchemaID    int
+}
+
+func (e Encoded) Headers() []kafka.Header {
+	if e.SchemaID == 0 {
+		return nil
+	}
+	return []kafka.Header{{Key: SchemaIDHeader, Value: []byte(strconv.Itoa(e.SchemaID))}}
+}
+
+// Topics picks a codec per topic. Producers always encode with the latest
+// schema of the topic's subject; consumers decode with whatever schema ID the
+// message carries. A nil *Topics passes JSON through untouched.
+type Topics struct {
+	registry schema.Registry
+	codecs   map[string]Codec
+
+	mu   sync.Mutex
+	byID map[int]Codec
+}
+
+func NewTopics(registry schema.Registry, formats map[string]string) (*Topics, error) {
+	t := &Topics{registry: registry, codecs: map[string]Codec{}, byID: map[int]Codec{}}
+	for topic, format := range formats {
+		if registry == nil {
+			if schema.Format(format) != schema.FormatJSON {
+				return nil, fmt.Errorf("topic %s: %s codec requires a schema registry", topic, format)
+			}
+			t.codecs[topic] = JSON()
+			continue
+		}
+
+		s, err := registry.Latest(schema.TopicSubject(topic))
+		if errors.Is(err, schema.ErrNotFound) && schema.Format(format) == schema.FormatJSON {
+			t.codecs[topic] = JSON()
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("topic %s: %w", topic, err)
+		}
+		if string(s.Format) != format {
+			return nil, fmt.Errorf("topic %s configured as %s but subject %s is %s", topic, format, s.Subject, s.Format)
+		}
+		c, err := New(s)
+		if err != nil {
+			return nil, fmt.Errorf("topic %s: %w", topic, err)
+		}
+		t.codecs[topic] = c
+		t.byID[s.ID] = c
+	}
+	return t, nil
+}
+
+func (t *Topics) Encode(topic string, payload []byte) (Encoded, error) {
+	c := t.forTopic(topic)
+	value, err := c.Encode(payload)
+	if err != nil {
+		return Encoded{}, fmt.Errorf("encode %s: %w", topic, err)
+	}
+	return Encoded{Value: value, ContentType: c.ContentType(), SchemaID: c.SchemaID()}, nil
+}
+
+func (t *Topics) Decode(topic string, headers []kafka.Header, value []byte) ([]byte, error) {
+	c := t.forTopic(topic)
+	for _, h := range headers {
+		if h.Key != SchemaIDHeader {
+			continue
+		}
+		id, err := strconv.Atoi(string(h.Value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema id header %q", h.Value)
+		}
+		if c, err = t.forID(id); err != nil {
+			return nil, err
+		}
+	}
+	return c.Decode(value)
+}
+
+func (t *Topics) forTopic(topic string) Codec {
+	if t == nil {
+		return JSON()
+	}
+	if c, ok := t.codecs[topic]; ok {
+		return c
+	}
+	return JSON()
+}
+
+func (t *Topics) forID(id int) (Codec, error) {
+	if t == nil || t.registry == nil {
+		return nil, fmt.Errorf("schema id %d received but no registry configured", id)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if c, ok := t.byID[id]; ok {
+		return c, nil
+	}
+	s, err := t.registry.ByID(id)
+	if err != nil {
+		return nil, err
+	}
+	c, err := New(s)
+	if err != nil {
+		return nil, err
+	}
+	t.byID[id] = c
+	return c, nil
+}
+
+// ParseTopicFormats reads "topic=format" pairs separated by commas.
+func ParseTopicFormats(spec string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		topic, format, ok := strings.Cut(pair, "=")
+		if !ok || topic == "" {
+			return nil, fmt.Errorf("invalid topic codec %q", pair)
+		}
+		switch schema.Format(format) {
+		case schema.FormatJSON, schema.FormatAvro, schema.FormatProtobuf:
+		default:
+			return nil, fmt.Errorf("unknown codec %q for topic %s", format, topic)
+		}
+		out[topic] = format
+	}
+	return out, nil
+}
+
+// Load builds the per-topic codecs, loading the file registry at path (if
+// any) and refusing to start when registered schema versions are
+// incompatible.
+func Load(registryPath, spec string) (*Topics, error) {
+	formats, err := ParseTopicFormats(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var registry schema.Registry
+	if registryPath != "" {
+		fr, err := schema.LoadFile(registryPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := schema.CheckCompatibility(fr); err != nil {
+			return nil, err
+		}
+		registry = fr
+	}
+	return NewTopics(registry, formats)
+}
+
+// ContentType reports the codec content type configured for a topic.
+func (t *Topics) ContentType(topic string) string {
+	return t.forTopic(topic).ContentType()
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/money"
 	"github.com/spf13/viper"
 )
 
@@ -16,12 +20,57 @@ type Config struct {
 	KafkaGroupID      string
 	OutboxTopic       string
 	RiskDecisionTopic string
+	RiskRequestTopic  string
 	ProducerAcks      string
 	ProducerRetries   int
 	ProducerTimeout   time.Duration
 	OTELExporter      string
 	OTELEndpoint      string
 	HTTPAddr          string
+	EventFormat       string
+	CloudEventsMode   string
+	TopicCodecs       string
+	SchemaRegistry    string
+	SweeperEnabled    bool
+	SweeperInterval   time.Duration
+	SweeperBatch      int
+	RelayEnabled      bool
+	RelayInterval     time.Duration
+	RelayGrace        time.Duration
+	DecisionSLA       time.Duration
+	TimeoutPolicy     string
+	TimeoutApproveMax int64
+	InstanceID        string
+	LeaseTTL          time.Duration
+	AuditSigningKey   string
+	AuditRootInterval time.Duration
+	DualControlAbove  int64
+	AuthDisabled      bool
+	AuthAPIKeysFile   string
+	AuthHMACSecret    string
+	AuthJWKSFile      string
+	AuthJWTIssuer     string
+	AuthJWTAudience   string
+	AuthRoleClaim     string
+	RulesFile         string
+	RulesSource       string
+	RulesReload       time.Duration
+	ListsReload       time.Duration
+	VelocityStore     string
+	ShadowMode        string
+	ShadowTopic       string
+	ExperimentsReload time.Duration
+	ReasonCodesFile   string
+	ChargebackTopic   string
+	RefundTopic       string
+	ChallengeTopic    string
+	ChallengeWindow   time.Duration
+	ExportHashKey     string
+	ModelFile         string
+	ModelReload       time.Duration
+	ReportingCurrency string
+	FXRatesFile       string
+	FXReload          time.Duration
 }
 
 func Load() (*Config, error) {
@@ -38,12 +87,58 @@ func Load() (*Config, error) {
 	v.SetDefault("KAFKA_GROUP_ID", "decision-orchestrator")
 	v.SetDefault("OUTBOX_TOPIC", "payments.outbox")
 	v.SetDefault("RISK_DECISION_TOPIC", "risk.decisions")
+	v.SetDefault("RISK_REQUEST_TOPIC", "risk.requests")
 	v.SetDefault("PRODUCER_ACKS", "all")
 	v.SetDefault("PRODUCER_RETRIES", 5)
 	v.SetDefault("PRODUCER_TIMEOUT", 5*time.Second)
 	v.SetDefault("OTEL_EXPORTER", "otlp")
 	v.SetDefault("OTEL_ENDPOINT", "otel-collector:4317")
 	v.SetDefault("HTTP_ADDR", ":8082")
+	v.SetDefault("EVENT_FORMAT", "json")
+	v.SetDefault("CLOUDEVENTS_MODE", "structured")
+	v.SetDefault("TOPIC_CODECS", "")
+	v.SetDefault("SCHEMA_REGISTRY_PATH", "")
+	v.SetDefault("SWEEPER_ENABLED", true)
+	v.SetDefault("SWEEPER_INTERVAL", 15*time.Second)
+	v.SetDefault("SWEEPER_BATCH", 100)
+	v.SetDefault("OUTBOX_RELAY_ENABLED", true)
+	v.SetDefault("OUTBOX_RELAY_INTERVAL", 10*time.Second)
+	v.SetDefault("OUTBOX_RELAY_GRACE", 30*time.Second)
+	v.SetDefault("DECISION_SLA", 2*time.Minute)
+	v.SetDefault("TIMEOUT_POLICY", "decline")
+	v.SetDefault("TIMEOUT_APPROVE_UNDER", 0)
+	hostname, _ := os.Hostname()
+	v.SetDefault("INSTANCE_ID", hostname)
+	v.SetDefault("LEASE_TTL", 15*time.Second)
+	v.SetDefault("AUDIT_SIGNING_KEY_FILE", "")
+	v.SetDefault("AUDIT_ROOT_INTERVAL", time.Hour)
+	v.SetDefault("OVERRIDE_DUAL_CONTROL_ABOVE", 100000)
+	v.SetDefault("AUTH_DISABLED", false)
+	v.SetDefault("AUTH_API_KEYS_FILE", "")
+	v.SetDefault("AUTH_HMAC_SECRET", "")
+	v.SetDefault("AUTH_JWKS_FILE", "")
+	v.SetDefault("AUTH_JWT_ISSUER", "")
+	v.SetDefault("AUTH_JWT_AUDIENCE", "")
+	v.SetDefault("AUTH_ROLE_CLAIM", "role")
+	v.SetDefault("RULES_FILE", "")
+	v.SetDefault("RULES_SOURCE", "file")
+	v.SetDefault("RULES_RELOAD_INTERVAL", 30*time.Second)
+	v.SetDefault("LISTS_RELOAD_INTERVAL", 30*time.Second)
+	v.SetDefault("VELOCITY_STORE", "mongo")
+	v.SetDefault("SHADOW_MODE", "")
+	v.SetDefault("SHADOW_DECISION_TOPIC", "risk.decisions.shadow")
+	v.SetDefault("EXPERIMENTS_RELOAD_INTERVAL", 30*time.Second)
+	v.SetDefault("REASON_CODES_FILE", "")
+	v.SetDefault("CHARGEBACK_TOPIC", "payments.chargebacks")
+	v.SetDefault("REFUND_TOPIC", "payments.refunds")
+	v.SetDefault("CHALLENGE_RESULT_TOPIC", "payments.challenge-results")
+	v.SetDefault("CHALLENGE_WINDOW", 15*time.Minute)
+	v.SetDefault("EXPORT_HASH_KEY", "")
+	v.SetDefault("MODEL_FILE", "")
+	v.SetDefault("MODEL_RELOAD_INTERVAL", 30*time.Second)
+	v.SetDefault("REPORTING_CURRENCY", "USD")
+	v.SetDefault("FX_RATES_FILE", "")
+	v.SetDefault("FX_RELOAD_INTERVAL", time.Minute)
 
 	cfg := &Config{
 		AppName:           v.GetString("APP_NAME"),
@@ -55,12 +150,82 @@ func Load() (*Config, error) {
 		KafkaGroupID:      v.GetString("KAFKA_GROUP_ID"),
 		OutboxTopic:       v.GetString("OUTBOX_TOPIC"),
 		RiskDecisionTopic: v.GetString("RISK_DECISION_TOPIC"),
+		RiskRequestTopic:  v.GetString("RISK_REQUEST_TOPIC"),
 		ProducerAcks:      v.GetString("PRODUCER_ACKS"),
 		ProducerRetries:   v.GetInt("PRODUCER_RETRIES"),
 		ProducerTimeout:   v.GetDuration("PRODUCER_TIMEOUT"),
 		OTELExporter:      v.GetString("OTEL_EXPORTER"),
 		OTELEndpoint:      v.GetString("OTEL_ENDPOINT"),
 		HTTPAddr:          v.GetString("HTTP_ADDR"),
+		EventFormat:       v.GetString("EVENT_FORMAT"),
+		CloudEventsMode:   v.GetString("CLOUDEVENTS_MODE"),
+		TopicCodecs:       v.GetString("TOPIC_CODECS"),
+		SchemaRegistry:    v.GetString("SCHEMA_REGISTRY_PATH"),
+		SweeperEnabled:    v.GetBool("SWEEPER_ENABLED"),
+		SweeperInterval:   v.GetDuration("SWEEPER_INTERVAL"),
+		SweeperBatch:      v.GetInt("SWEEPER_BATCH"),
+		RelayEnabled:      v.GetBool("OUTBOX_RELAY_ENABLED"),
+		RelayInterval:     v.GetDuration("OUTBOX_RELAY_INTERVAL"),
+		RelayGrace:        v.GetDuration("OUTBOX_RELAY_GRACE"),
+		DecisionSLA:       v.GetDuration("DECISION_SLA"),
+		TimeoutPolicy:     v.GetString("TIMEOUT_POLICY"),
+		TimeoutApproveMax: v.GetInt64("TIMEOUT_APPROVE_UNDER"),
+		InstanceID:        v.GetString("INSTANCE_ID"),
+		LeaseTTL:          v.GetDuration("LEASE_TTL"),
+		AuditSigningKey:   v.GetString("AUDIT_SIGNING_KEY_FILE"),
+		AuditRootInterval: v.GetDuration("AUDIT_ROOT_INTERVAL"),
+		DualControlAbove:  v.GetInt64("OVERRIDE_DUAL_CONTROL_ABOVE"),
+		AuthDisabled:      v.GetBool("AUTH_DISABLED"),
+		AuthAPIKeysFile:   v.GetString("AUTH_API_KEYS_FILE"),
+		AuthHMACSecret:    v.GetString("AUTH_HMAC_SECRET"),
+		AuthJWKSFile:      v.GetString("AUTH_JWKS_FILE"),
+		AuthJWTIssuer:     v.GetString("AUTH_JWT_ISSUER"),
+		AuthJWTAudience:   v.GetString("AUTH_JWT_AUDIENCE"),
+		AuthRoleClaim:     v.GetString("AUTH_ROLE_CLAIM"),
+		RulesFile:         v.GetString("RULES_FILE"),
+		RulesSource:       v.GetString("RULES_SOURCE"),
+		RulesReload:       v.GetDuration("RULES_RELOAD_INTERVAL"),
+		ListsReload:       v.GetDuration("LISTS_RELOAD_INTERVAL"),
+		VelocityStore:     v.GetString("VELOCITY_STORE"),
+		ShadowMode:        v.GetString("SHADOW_MODE"),
+		ShadowTopic:       v.GetString("SHADOW_DECISION_TOPIC"),
+		ExperimentsReload: v.GetDuration("EXPERIMENTS_RELOAD_INTERVAL"),
+		ReasonCodesFile:   v.GetString("REASON_CODES_FILE"),
+		ChargebackTopic:   v.GetString("CHARGEBACK_TOPIC"),
+		RefundTopic:       v.GetString("REFUND_TOPIC"),
+		ChallengeTopic:    v.GetString("CHALLENGE_RESULT_TOPIC"),
+		ChallengeWindow:   v.GetDuration("CHALLENGE_WINDOW"),
+		ExportHashKey:     v.GetString("EXPORT_HASH_KEY"),
+		ModelFile:         v.GetString("MODEL_FILE"),
+		ModelReload:       v.GetDuration("MODEL_RELOAD_INTERVAL"),
+		ReportingCurrency: strings.ToUpper(v.GetString("REPORTING_CURRENCY")),
+		FXRatesFile:       v.GetString("FX_RATES_FILE"),
+		FXReload:          v.GetDuration("FX_RELOAD_INTERVAL"),
+	}
+
+	if cfg.EventFormat != "json" && cfg.EventFormat != "cloudevents" {
+		return nil, errors.New("EVENT_FORMAT must be json or cloudevents")
+	}
+	if cfg.CloudEventsMode != "structured" && cfg.CloudEventsMode != "binary" {
+		return nil, errors.New("CLOUDEVENTS_MODE must be structured or binary")
+	}
+	if cfg.RulesSource != "file" && cfg.RulesSource != "mongo" {
+		return nil, errors.New("RULES_SOURCE must be file or mongo")
+	}
+	if cfg.VelocityStore != "mongo" && cfg.VelocityStore != "memory" {
+		return nil, errors.New("VELOCITY_STORE must be mongo or memory")
+	}
+	if cfg.ShadowMode != "" && cfg.ShadowMode != "topic" && cfg.ShadowMode != "rules" {
+		return nil, errors.New("SHADOW_MODE must be empty, topic or rules")
+	}
+	if cfg.ChallengeWindow <= 0 {
+		return nil, errors.New("CHALLENGE_WINDOW must be positive")
+	}
+	if _, err := money.Lookup(cfg.ReportingCurrency); err != nil {
+		return nil, errors.New("REPORTING_CURRENCY must be an ISO 4217 currency code")
+	}
+	if cfg.AuthJWKSFile != "" && (cfg.AuthJWTIssuer == "" || cfg.AuthJWTAudience == "") {
+		return nil, errors.New("AUTH_JWKS_FILE requires AUTH_JWT_ISSUER and AUTH_JWT_AUDIENCE")
 	}
 	return cfg, nil
 }
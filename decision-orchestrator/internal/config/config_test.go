@@ -0,0 +1,33 @@
+package config
+
+import "testing"
+
+func TestLoadRequiresJWTIssuerAndAudience(t *testing.T) {
+	tests := []struct {
+		name     string
+		jwks     string
+		issuer   string
+		audience string
+		ok       bool
+	}{
+		{"no jwks", "", "", "", true},
+		{"jwks with issuer and audience", "/etc/auth/jwks.json", "https://idp.example.com", "payments", true},
+		{"jwks without issuer", "/etc/auth/jwks.json", "", "payments", false},
+		{"jwks without audience", "/etc/auth/jwks.json", "https://idp.example.com", "", false},
+		{"jwks alone", "/etc/auth/jwks.json", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ORCH_AUTH_JWKS_FILE", tt.jwks)
+			t.Setenv("ORCH_AUTH_JWT_ISSUER", tt.issuer)
+			t.Setenv("ORCH_AUTH_JWT_AUDIENCE", tt.audience)
+			_, err := Load()
+			if tt.ok && err != nil {
+				t.Errorf("Load: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Error("Load succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package dataset
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+type Type int
+
+const (
+	TypeString Type = iota
+	TypeInt64
+	TypeDouble
+	TypeBool
+	TypeTimestamp
+)
+
+// Record is one payment joined with the audit records that decided it.
+type Record struct {
+	repo.Payment `bson:",inline"`
+	Decisions    []DecisionRef `bson:"decisions"`
+}
+
+// DecisionRef is the part of a decision or override audit record the
+// dataset needs.
+type DecisionRef struct {
+	Kind       audit.Kind  `bson:"kind"`
+	Actor      audit.Actor `bson:"actor"`
+	NewStatus  string      `bson:"new_status"`
+	RecordedAt time.Time   `bson:"recorded_at"`
+}
+
+type Column struct {
+	Name  string
+	Type  Type
+	value func(Record) any
+}
+
+// Columns is the full dataset layout before the PII schema is applied.
+// Nullable facts are flattened: timestamps are zero and labels false when
+// absent, with labelled telling the two apart.
+var Columns = []Column{
+	{"payment_id", TypeString, func(r Record) any { return r.ID }},
+	{"created_at", TypeTimestamp, func(r Record) any { return r.CreatedAt }},
+	{"user_id", TypeString, func(r Record) any { return r.UserID }},
+	{"merchant_id", TypeString, func(r Record) any { return r.MerchantID }},
+	{"instrument_fingerprint", TypeString, func(r Record) any { return r.InstrumentFingerprint }},
+	{"correlation_id", TypeString, func(r Record) any { return r.CorrelationID }},
+	{"amount", TypeInt64, func(r Record) any { return r.Amount }},
+	{"refunded_amount", TypeInt64, func(r Record) any { return r.RefundedAmount }},
+	{"currency", TypeString, func(r Record) any { return r.Currency }},
+	{"reporting_amount", TypeInt64, func(r Record) any { amount, _ := reporting(r); return amount }},
+	{"reporting_currency", TypeString, func(r Record) any { _, currency := reporting(r); return currency }},
+	{"status", TypeString, func(r Record) any { return string(r.Status) }},
+	{"risk_score", TypeDouble, func(r Record) any { return r.RiskScore }},
+	{"risk_reason", TypeString, func(r Record) any { return r.RiskReason }},
+	{"model_version", TypeString, func(r Record) any { v, _ := model(r); return v }},
+	{"model_score", TypeDouble, func(r Record) any { _, s := model(r); return s }},
+	{"reason_codes", TypeString, func(r Record) any { return strings.Join(r.ReasonCodes, "|") }},
+	{"contributions", TypeString, contributions},
+	{"policy_version", TypeInt64, func(r Record) any { return r.PolicyVersion }},
+	{"experiment_id", TypeString, func(r Record) any { id, _ := experiment(r); return id }},
+	{"experiment_arm", TypeString, func(r Record) any { _, arm := experiment(r); return arm }},
+	{"decided_by", TypeString, func(r Record) any { return string(firstDecision(r).Actor) }},
+	{"decided_at", TypeTimestamp, func(r Record) any { return firstDecision(r).RecordedAt }},
+	{"decided_status", TypeString, func(r Record) any { return firstDecision(r).NewStatus }},
+	{"overridden", TypeBool, overridden},
+	{"labelled", TypeBool, func(r Record) any { return len(r.Labels) > 0 }},
+	{"fraud", TypeBool, func(r Record) any { return r.Fraud != nil && *r.Fraud }},
+	{"chargebacked", TypeBool, func(r Record) any { return r.Chargebacked }},
+	{"label_count", TypeInt64, func(r Record) any { return int64(len(r.Labels)) }},
+	{"first_label_at", TypeTimestamp, firstLabelAt},
+}
+
+// model returns the local model's version and score; payments decided
+// without a model get an empty version and a zero score.
+func model(r Record) (string, float64) {
+	if r.Model == nil {
+		return "", 0
+	}
+	return r.Model.Version, r.Model.Score
+}
+
+// reporting returns the converted amount; payments without one get a zero
+// amount and an empty currency.
+func reporting(r Record) (int64, string) {
+	if r.Reporting == nil {
+		return 0, ""
+	}
+	return r.Reporting.Amount, r.Reporting.Currency
+}
+
+func experiment(r Record) (string, string) {
+	if r.Experiment == nil {
+		return "", ""
+	}
+	return r.Experiment.ExperimentID, r.Experiment.Arm
+}
+
+// firstDecision is the automated decision, before any analyst override.
+func firstDecision(r Record) DecisionRef {
+	for _, d := range r.Decisions {
+		if d.Kind == audit.KindDecision {
+			return d
+		}
+	}
+	return DecisionRef{}
+}
+
+func overridden(r Record) any {
+	for _, d := range r.Decisions {
+		if d.Kind == audit.KindOverride {
+			return true
+		}
+	}
+	return false
+}
+
+func contributions(r Record) any {
+	if len(r.Contributions) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(r.Contributions)
+	return string(b)
+}
+
+func firstLabelAt(r Record) any {
+	var first time.Time
+	for _, l := range r.Labels {
+		if first.IsZero() || l.OccurredAt.Before(first) {
+			first = l.OccurredAt
+		}
+	}
+	return first
+}
@@ -0,0 +1,184 @@
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const checkpointFile = "_checkpoint.json"
+
+var (
+	ErrExportExists   = errors.New("output directory already holds an export, resume it or pick another directory")
+	ErrResumeMismatch = errors.New("checkpoint was written for a different range, format or schema")
+)
+
+// Checkpoint is rewritten after every completed part, so an interrupted
+// export resumes after the last record of the last part on disk.
+type Checkpoint struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Format  string    `json:"format"`
+	Columns []string  `json:"columns"`
+	After   Position  `json:"after"`
+	Parts   int       `json:"parts"`
+	Rows    int64     `json:"rows"`
+	Done    bool      `json:"done"`
+}
+
+// Exporter writes a range of records into numbered part files in a
+// directory, each holding at most PartRows rows.
+type Exporter struct {
+	log        *zap.Logger
+	source     *Source
+	projection *Projection
+	format     string
+	dir        string
+
+	PartRows  int
+	BatchSize int
+}
+
+func NewExporter(log *zap.Logger, source *Source, projection *Projection, format, dir string) (*Exporter, error) {
+	if format != FormatCSV && format != FormatJSONL && format != FormatParquet {
+		return nil, ErrUnknownFormat
+	}
+	return &Exporter{
+		log:        log,
+		source:     source,
+		projection: projection,
+		format:     format,
+		dir:        dir,
+		PartRows:   100000,
+		BatchSize:  1000,
+	}, nil
+}
+
+// Run exports records created in [from, to). With resume set it continues
+// from the checkpoint in the directory; without it the directory must not
+// hold an earlier export.
+func (e *Exporter) Run(ctx context.Context, from, to time.Time, resume bool) (Checkpoint, error) {
+	if err := os.MkdirAll(e.dir, 0o755); err != nil {
+		return Checkpoint{}, err
+	}
+	cp, err := e.start(from, to, resume)
+	if err != nil {
+		return cp, err
+	}
+
+	for !cp.Done {
+		n, last, err := e.writePart(ctx, cp)
+		if err != nil {
+			return cp, err
+		}
+		if n == 0 {
+			cp.Done = true
+		} else {
+			cp.After = last
+			cp.Parts++
+			cp.Rows += int64(n)
+			e.log.Info("export part written", zap.Int("part", cp.Parts), zap.Int("rows", n), zap.Int64("total_rows", cp.Rows))
+		}
+		if err := e.save(cp); err != nil {
+			return cp, err
+		}
+	}
+	return cp, nil
+}
+
+func (e *Exporter) start(from, to time.Time, resume bool) (Checkpoint, error) {
+	fresh := Checkpoint{From: from.UTC(), To: to.UTC(), Format: e.format}
+	for _, c := range e.projection.Columns() {
+		fresh.Columns = append(fresh.Columns, c.Name)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(e.dir, checkpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return fresh, e.save(fresh)
+	}
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if !resume {
+		return Checkpoint{}, ErrExportExists
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(raw, &cp); err != nil {
+		return Checkpoint{}, fmt.Errorf("read checkpoint: %w", err)
+	}
+	if !cp.From.Equal(fresh.From) || !cp.To.Equal(fresh.To) || cp.Format != fresh.Format || !slices.Equal(cp.Columns, fresh.Columns) {
+		return Checkpoint{}, ErrResumeMismatch
+	}
+	return cp, nil
+}
+
+// writePart writes the next part to a temporary file and renames it into
+// place once complete, so a crash never leaves a truncated part behind.
+func (e *Exporter) writePart(ctx context.Context, cp Checkpoint) (int, Position, error) {
+	name := filepath.Join(e.dir, fmt.Sprintf("part-%05d.%s", cp.Parts+1, e.format))
+	f, err := os.Create(name + ".tmp")
+	if err != nil {
+		return 0, Position{}, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	w, err := NewWriter(e.format, f, e.projection.Columns())
+	if err != nil {
+		return 0, Position{}, err
+	}
+
+	n, after := 0, cp.After
+	for n < e.PartRows {
+		records, err := e.source.Next(ctx, cp.From, cp.To, after, min(e.BatchSize, e.PartRows-n))
+		if err != nil {
+			return 0, Position{}, err
+		}
+		for _, r := range records {
+			if err := w.Write(e.projection.Row(r)); err != nil {
+				return 0, Position{}, err
+			}
+			after = Position{CreatedAt: r.CreatedAt, ID: r.ID}
+			n++
+		}
+		if len(records) == 0 {
+			break
+		}
+	}
+	if n == 0 {
+		return 0, cp.After, nil
+	}
+
+	if err := w.Close(); err != nil {
+		return 0, Position{}, err
+	}
+	if err := f.Sync(); err != nil {
+		return 0, Position{}, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, Position{}, err
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		return 0, Position{}, err
+	}
+	return n, after, nil
+}
+
+func (e *Exporter) save(cp Checkpoint) error {
+	raw, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(e.dir, checkpointFile)
+	if err := os.WriteFile(path+".tmp", raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,333 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// parquetWriter writes a single row group file with one uncompressed,
+// PLAIN encoded data page per column: the subset of Parquet every reader
+// supports, small enough not to need a dependency. Timestamps are optional
+// so a missing time is null; every other column is required. Rows are held
+// in memory until Close, so callers bound the file size by splitting the
+// export into parts.
+type parquetWriter struct {
+	w    io.Writer
+	cols []Column
+	rows [][]any
+}
+
+func newParquetWriter(w io.Writer, cols []Column) *parquetWriter {
+	return &parquetWriter{w: w, cols: cols}
+}
+
+func (p *parquetWriter) Write(row []any) error {
+	p.rows = append(p.rows, row)
+	return nil
+}
+
+// Parquet enum values, from parquet.thrift.
+const (
+	pqBoolean   = 0
+	pqInt64     = 2
+	pqDouble    = 5
+	pqByteArray = 6
+
+	pqRequired = 0
+	pqOptional = 1
+
+	pqUTF8            = 0
+	pqTimestampMillis = 9
+
+	pqPlain = 0
+	pqRLE   = 3
+
+	pqDataPage     = 0
+	pqUncompressed = 0
+)
+
+var parquetMagic = []byte("PAR1")
+
+type chunkMeta struct {
+	offset int64
+	size   int64
+}
+
+func (p *parquetWriter) Close() error {
+	var out bytes.Buffer
+	out.Write(parquetMagic)
+
+	chunks := make([]chunkMeta, len(p.cols))
+	if len(p.rows) > 0 {
+		for i := range p.cols {
+			data := p.page(i)
+			header := newCompact()
+			header.i32(1, pqDataPage)
+			header.i32(2, int32(len(data)))
+			header.i32(3, int32(len(data)))
+			header.beginStruct(5)
+			header.i32(1, int32(len(p.rows)))
+			header.i32(2, pqPlain)
+			header.i32(3, pqRLE)
+			header.i32(4, pqRLE)
+			header.end()
+			header.end()
+
+			chunks[i] = chunkMeta{offset: int64(out.Len()), size: int64(header.buf.Len() + len(data))}
+			out.Write(header.buf.Bytes())
+			out.Write(data)
+		}
+	}
+
+	footer := p.footer(chunks)
+	out.Write(footer)
+	binary.Write(&out, binary.LittleEndian, uint32(len(footer)))
+	out.Write(parquetMagic)
+
+	_, err := p.w.Write(out.Bytes())
+	return err
+}
+
+func optional(c Column) bool {
+	return c.Type == TypeTimestamp
+}
+
+// page encodes column i: definition levels for optional columns, then the
+// non-null values.
+func (p *parquetWriter) page(i int) []byte {
+	var buf bytes.Buffer
+	col := p.cols[i]
+	if optional(col) {
+		defined := make([]bool, len(p.rows))
+		for r, row := range p.rows {
+			defined[r] = !row[i].(time.Time).IsZero()
+		}
+		levels := bitPackedRun(defined)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(levels)))
+		buf.Write(levels)
+	}
+
+	switch col.Type {
+	case TypeString:
+		for _, row := range p.rows {
+			s := row[i].(string)
+			binary.Write(&buf, binary.LittleEndian, uint32(len(s)))
+			buf.WriteString(s)
+		}
+	case TypeInt64:
+		for _, row := range p.rows {
+			binary.Write(&buf, binary.LittleEndian, row[i].(int64))
+		}
+	case TypeDouble:
+		for _, row := range p.rows {
+			binary.Write(&buf, binary.LittleEndian, math.Float64bits(row[i].(float64)))
+		}
+	case TypeBool:
+		values := make([]bool, len(p.rows))
+		for r, row := range p.rows {
+			values[r] = row[i].(bool)
+		}
+		buf.Write(packBits(values))
+	case TypeTimestamp:
+		for _, row := range p.rows {
+			if t := row[i].(time.Time); !t.IsZero() {
+				binary.Write(&buf, binary.LittleEndian, t.UnixMilli())
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// bitPackedRun encodes 1-bit levels as a single bit-packed run of the
+// RLE/bit-packing hybrid encoding.
+func bitPackedRun(bits []bool) []byte {
+	groups := (len(bits) + 7) / 8
+	header := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	return append(header, packBits(bits)...)
+}
+
+func packBits(bits []bool) []byte {
+	out := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
+
+func (p *parquetWriter) footer(chunks []chunkMeta) []byte {
+	m := newCompact()
+	m.i32(1, 1)
+
+	m.list(2, ctStruct, len(p.cols)+1)
+	m.elemStruct()
+	m.binary(4, "schema")
+	m.i32(5, int32(len(p.cols)))
+	m.end()
+	for _, c := range p.cols {
+		physical, converted := parquetType(c.Type)
+		m.elemStruct()
+		m.i32(1, physical)
+		if optional(c) {
+			m.i32(3, pqOptional)
+		} else {
+			m.i32(3, pqRequired)
+		}
+		m.binary(4, c.Name)
+		if converted >= 0 {
+			m.i32(6, converted)
+		}
+		m.end()
+	}
+
+	m.i64(3, int64(len(p.rows)))
+
+	groups := 0
+	if len(p.rows) > 0 {
+		groups = 1
+	}
+	m.list(4, ctStruct, groups)
+	if groups == 1 {
+		var total int64
+		for _, ch := range chunks {
+			total += ch.size
+		}
+		m.elemStruct()
+		m.list(1, ctStruct, len(p.cols))
+		for i, c := range p.cols {
+			physical, _ := parquetType(c.Type)
+			m.elemStruct()
+			m.i64(2, chunks[i].offset)
+			m.beginStruct(3)
+			m.i32(1, physical)
+			m.list(2, ctI32, 2)
+			m.elemI32(pqPlain)
+			m.elemI32(pqRLE)
+			m.list(3, ctBinary, 1)
+			m.elemBinary(c.Name)
+			m.i32(4, pqUncompressed)
+			m.i64(5, int64(len(p.rows)))
+			m.i64(6, chunks[i].size)
+			m.i64(7, chunks[i].size)
+			m.i64(9, chunks[i].offset)
+			m.end()
+			m.end()
+		}
+		m.i64(2, total)
+		m.i64(3, int64(len(p.rows)))
+		m.end()
+	}
+
+	m.binary(6, "payments-risk-decisioning datasetexport")
+	m.end()
+	return m.buf.Bytes()
+}
+
+// parquetType returns the physical and converted type of t; -1 means no
+// converted type.
+func parquetType(t Type) (int32, int32) {
+	switch t {
+	case TypeString:
+		return pqByteArray, pqUTF8
+	case TypeDouble:
+		return pqDouble, -1
+	case TypeBool:
+		return pqBoolean, -1
+	case TypeTimestamp:
+		return pqInt64, pqTimestampMillis
+	}
+	return pqInt64, -1
+}
+
+// Thrift compact protocol type ids.
+const (
+	ctI32    = 5
+	ctI64    = 6
+	ctBinary = 8
+	ctList   = 9
+	ctStruct = 12
+)
+
+// compact writes the Thrift compact protocol, just enough of it for Parquet
+// page headers and file metadata. last tracks the previous field id of each
+// open struct, which field headers are encoded relative to.
+type compact struct {
+	buf  bytes.Buffer
+	last []int16
+}
+
+func newCompact() *compact {
+	return &compact{last: []int16{0}}
+}
+
+func (c *compact) field(id int16, typ byte) {
+	top := &c.last[len(c.last)-1]
+	if d := id - *top; d > 0 && d <= 15 {
+		c.buf.WriteByte(byte(d)<<4 | typ)
+	} else {
+		c.buf.WriteByte(typ)
+		c.varint(zigzag(int64(id)))
+	}
+	*top = id
+}
+
+func (c *compact) varint(v uint64) {
+	c.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func (c *compact) i32(id int16, v int32) {
+	c.field(id, ctI32)
+	c.varint(zigzag(int64(v)))
+}
+
+func (c *compact) i64(id int16, v int64) {
+	c.field(id, ctI64)
+	c.varint(zigzag(v))
+}
+
+func (c *compact) binary(id int16, s string) {
+	c.field(id, ctBinary)
+	c.elemBinary(s)
+}
+
+func (c *compact) beginStruct(id int16) {
+	c.field(id, ctStruct)
+	c.last = append(c.last, 0)
+}
+
+// end closes the innermost struct, including the top level one.
+func (c *compact) end() {
+	c.buf.WriteByte(0)
+	c.last = c.last[:len(c.last)-1]
+}
+
+func (c *compact) list(id int16, elem byte, n int) {
+	c.field(id, ctList)
+	if n < 15 {
+		c.buf.WriteByte(byte(n)<<4 | elem)
+		return
+	}
+	c.buf.WriteByte(0xf0 | elem)
+	c.varint(uint64(n))
+}
+
+func (c *compact) elemStruct() {
+	c.last = append(c.last, 0)
+}
+
+func (c *compact) elemI32(v int32) {
+	c.varint(zigzag(int64(v)))
+}
+
+func (c *compact) elemBinary(s string) {
+	c.varint(uint64(len(s)))
+	c.buf.WriteString(s)
+}
@@ -0,0 +1,141 @@
+package dataset
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+type Action string
+
+const (
+	ActionKeep Action = "keep"
+	ActionHash Action = "hash"
+	ActionDrop Action = "drop"
+)
+
+var ErrInvalidSchema = errors.New("invalid export schema")
+
+// Schema says what happens to each column on export. Columns not listed are
+// kept. Hashing is keyed so identifiers cannot be recovered by hashing
+// candidate values, yet stay joinable across exports made with the same key.
+type Schema struct {
+	Fields map[string]Action `json:"fields"`
+}
+
+// DefaultSchema pseudonymises the payer and drops free text that may echo
+// list entries or other identifiers.
+func DefaultSchema() Schema {
+	return Schema{Fields: map[string]Action{
+		"user_id":                ActionHash,
+		"instrument_fingerprint": ActionHash,
+		"correlation_id":         ActionDrop,
+		"risk_reason":            ActionDrop,
+	}}
+}
+
+// LoadSchema reads a schema file; an empty path yields DefaultSchema.
+func LoadSchema(path string) (Schema, error) {
+	if path == "" {
+		return DefaultSchema(), nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Schema{}, err
+	}
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return Schema{}, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return s, s.Validate()
+}
+
+func (s Schema) Validate() error {
+	known := map[string]Type{}
+	for _, c := range Columns {
+		known[c.Name] = c.Type
+	}
+	for name, action := range s.Fields {
+		typ, ok := known[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown column %s", ErrInvalidSchema, name)
+		}
+		switch action {
+		case ActionKeep, ActionDrop:
+		case ActionHash:
+			if typ != TypeString {
+				return fmt.Errorf("%w: only string columns can be hashed, not %s", ErrInvalidSchema, name)
+			}
+		default:
+			return fmt.Errorf("%w: column %s has unknown action %q", ErrInvalidSchema, name, action)
+		}
+	}
+	return nil
+}
+
+func (s Schema) hashes() bool {
+	for _, a := range s.Fields {
+		if a == ActionHash {
+			return true
+		}
+	}
+	return false
+}
+
+// Projection turns records into output rows according to a schema.
+type Projection struct {
+	columns []Column
+	hashed  []bool
+	key     []byte
+}
+
+// NewProjection applies s to Columns. key is required when any column is
+// hashed.
+func NewProjection(s Schema, key []byte) (*Projection, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if s.hashes() && len(key) == 0 {
+		return nil, fmt.Errorf("%w: a hash key is required to hash columns", ErrInvalidSchema)
+	}
+	p := &Projection{key: key}
+	for _, c := range Columns {
+		action := s.Fields[c.Name]
+		if action == ActionDrop {
+			continue
+		}
+		p.columns = append(p.columns, c)
+		p.hashed = append(p.hashed, action == ActionHash)
+	}
+	return p, nil
+}
+
+func (p *Projection) Columns() []Column {
+	return p.columns
+}
+
+func (p *Projection) Row(r Record) []any {
+	row := make([]any, len(p.columns))
+	for i, c := range p.columns {
+		v := c.value(r)
+		if p.hashed[i] {
+			v = p.hash(v.(string))
+		}
+		row[i] = v
+	}
+	return row
+}
+
+// hash leaves empty values empty so "not provided" stays distinguishable.
+func (p *Projection) hash(v string) string {
+	if v == "" {
+		return ""
+	}
+	m := hmac.New(sha256.New, p.key)
+	m.Write([]byte(v))
+	return hex.EncodeToString(m.Sum(nil))
+}
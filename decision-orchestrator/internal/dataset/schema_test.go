@@ -0,0 +1,122 @@
+package dataset
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  map[string]Action
+		wantErr bool
+	}{
+		{"default", DefaultSchema().Fields, false},
+		{"empty", nil, false},
+		{"keep and drop any column", map[string]Action{"amount": ActionDrop, "created_at": ActionKeep}, false},
+		{"unknown column", map[string]Action{"email": ActionDrop}, true},
+		{"hash a number", map[string]Action{"amount": ActionHash}, true},
+		{"unknown action", map[string]Action{"user_id": "mask"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Schema{Fields: tt.fields}.Validate()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Validate = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("Validate = %v, want ErrInvalidSchema", err)
+			}
+		})
+	}
+}
+
+func TestNewProjectionNeedsKeyToHash(t *testing.T) {
+	if _, err := NewProjection(DefaultSchema(), nil); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("NewProjection without a key = %v, want ErrInvalidSchema", err)
+	}
+	if _, err := NewProjection(Schema{Fields: map[string]Action{"risk_reason": ActionDrop}}, nil); err != nil {
+		t.Errorf("NewProjection without hashed columns needs no key: %v", err)
+	}
+}
+
+func TestProjectionRow(t *testing.T) {
+	key := []byte("export-key")
+	p, err := NewProjection(DefaultSchema(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	r := Record{
+		Payment: repo.Payment{
+			ID:            "p-1",
+			UserID:        "u-1",
+			MerchantID:    "m-1",
+			CorrelationID: "c-1",
+			Amount:        1200,
+			Currency:      "USD",
+			Status:        repo.StatusDeclined,
+			RiskReason:    "deny list entry 4111",
+			CreatedAt:     created,
+		},
+		Decisions: []DecisionRef{
+			{Kind: audit.KindDecision, Actor: audit.ActorRiskEngine, NewStatus: "DECLINED", RecordedAt: created.Add(time.Second)},
+			{Kind: audit.KindOverride, Actor: audit.ActorAnalyst, NewStatus: "APPROVED", RecordedAt: created.Add(time.Hour)},
+		},
+	}
+	values := p.Row(r)
+	row := map[string]any{}
+	for i, c := range p.Columns() {
+		row[c.Name] = values[i]
+	}
+
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte("u-1"))
+	tests := []struct {
+		column string
+		want   any
+	}{
+		{"payment_id", "p-1"},
+		{"user_id", hex.EncodeToString(m.Sum(nil))},
+		// no fingerprint was stored, hashing keeps it empty
+		{"instrument_fingerprint", ""},
+		{"merchant_id", "m-1"},
+		{"amount", int64(1200)},
+		{"decided_status", "DECLINED"},
+		{"overridden", true},
+		{"labelled", false},
+	}
+	for _, tt := range tests {
+		got, ok := row[tt.column]
+		if !ok {
+			t.Errorf("column %s missing", tt.column)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.column, got, tt.want)
+		}
+	}
+	for _, dropped := range []string{"correlation_id", "risk_reason"} {
+		if _, ok := row[dropped]; ok {
+			t.Errorf("column %s exported, want it dropped", dropped)
+		}
+	}
+}
+
+func TestProjectionHashDependsOnKey(t *testing.T) {
+	a, _ := NewProjection(DefaultSchema(), []byte("key-a"))
+	b, _ := NewProjection(DefaultSchema(), []byte("key-b"))
+	if a.hash("u-1") == b.hash("u-1") {
+		t.Error("the same hash under different keys")
+	}
+	if a.hash("u-1") != a.hash("u-1") {
+		t.Error("hash is not stable under one key")
+	}
+}
@@ -0,0 +1,73 @@
+package dataset
+
+import (
+	"context"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Position is where a scan stopped: the (created_at, _id) of the last
+// record returned. The zero Position starts at the beginning of the range.
+type Position struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+type Source struct {
+	payments *mongo.Collection
+}
+
+func NewSource(db *mongo.Database) *Source {
+	return &Source{payments: db.Collection("payments")}
+}
+
+// Next returns up to limit decided payments created in [from, to) after pos,
+// oldest first, each joined with its decision and override audit records.
+// Pending payments are left out: their outcome is not known yet.
+func (s *Source) Next(ctx context.Context, from, to time.Time, after Position, limit int) ([]Record, error) {
+	match := bson.M{
+		"created_at": bson.M{"$gte": from, "$lt": to},
+		"status":     bson.M{"$ne": repo.StatusPending},
+	}
+	if after.ID != "" {
+		match["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$gt": after.CreatedAt}},
+			bson.M{"created_at": after.CreatedAt, "_id": bson.M{"$gt": after.ID}},
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "payment_decisions",
+			"let":  bson.M{"pid": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"$expr": bson.M{"$eq": bson.A{"$payment_id", "$$pid"}},
+					"kind":  bson.M{"$in": bson.A{audit.KindDecision, audit.KindOverride}},
+				}},
+				bson.M{"$sort": bson.M{"seq": 1}},
+				bson.M{"$project": bson.M{"_id": 0, "kind": 1, "actor": 1, "new_status": 1, "recorded_at": 1}},
+			},
+			"as": "decisions",
+		}}},
+	}
+
+	cur, err := s.payments.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	records := make([]Record, 0, limit)
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
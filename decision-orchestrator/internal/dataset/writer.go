@@ -0,0 +1,118 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+var ErrUnknownFormat = errors.New("format must be csv, jsonl or parquet")
+
+// Writer writes rows produced by a Projection. Close flushes buffered rows
+// but leaves the underlying writer open.
+type Writer interface {
+	Write(row []any) error
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer, cols []Column) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, cols)
+	case FormatJSONL:
+		return &jsonlWriter{w: w, cols: cols}, nil
+	case FormatParquet:
+		return newParquetWriter(w, cols), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	fields []string
+}
+
+func newCSVWriter(w io.Writer, cols []Column) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), fields: make([]string, len(cols))}
+	for i, c := range cols {
+		cw.fields[i] = c.Name
+	}
+	return cw, cw.w.Write(cw.fields)
+}
+
+func (c *csvWriter) Write(row []any) error {
+	for i, v := range row {
+		c.fields[i] = formatValue(v)
+	}
+	return c.w.Write(c.fields)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+	return ""
+}
+
+// jsonlWriter writes one object per line with keys in column order, which
+// encoding/json does not do for maps.
+type jsonlWriter struct {
+	w    io.Writer
+	cols []Column
+	buf  bytes.Buffer
+}
+
+func (j *jsonlWriter) Write(row []any) error {
+	j.buf.Reset()
+	j.buf.WriteByte('{')
+	for i, v := range row {
+		if i > 0 {
+			j.buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(j.cols[i].Name)
+		j.buf.Write(key)
+		j.buf.WriteByte(':')
+		if t, ok := v.(time.Time); ok && t.IsZero() {
+			v = nil
+		}
+		val, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		j.buf.Write(val)
+	}
+	j.buf.WriteString("}\n")
+	_, err := j.w.Write(j.buf.Bytes())
+	return err
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,132 @@
+package events
+
+import "time"
+
+const TypePaymentDecisionFinalized = "PaymentDecisionFinalized"
+
+// PaymentDecisionFinalized is the current (v2) shape of the final decision
+// event. v1 used "correlation" and "ts" for the last two fields.
+type PaymentDecisionFinalized struct {
+	SchemaVersion int            `json:"schema_version"`
+	Type          string         `json:"type"`
+	PaymentID     string         `json:"payment_id"`
+	Status        string         `json:"status"`
+	Score         float64        `json:"score"`
+	Reason        string         `json:"reason"`
+	CorrelationID string         `json:"correlation_id"`
+	OccurredAt    time.Time      `json:"occurred_at"`
+	TimedOut      bool           `json:"timed_out,omitempty"`
+	PolicyVersion int64          `json:"policy_version,omitempty"`
+	ExperimentID  string         `json:"experiment_id,omitempty"`
+	ExperimentArm string         `json:"experiment_arm,omitempty"`
+	MerchantID    string         `json:"merchant_id,omitempty"`
+	ReasonCodes   []string       `json:"reason_codes,omitempty"`
+	Contributions []Contribution `json:"contributions,omitempty"`
+}
+
+type Contribution struct {
+	Feature string  `json:"feature"`
+	Value   float64 `json:"value"`
+}
+
+var defaultUpcasters = newDefaultUpcasters()
+
+// Default returns the upcasters for every event type this service knows.
+func Default() *Upcasters {
+	return defaultUpcasters
+}
+
+func newDefaultUpcasters() *Upcasters {
+	u := NewUpcasters()
+	u.Register(TypePaymentDecisionFinalized, 1, func(doc map[string]any) (map[string]any, error) {
+		rename(doc, "correlation", "correlation_id")
+		rename(doc, "ts", "occurred_at")
+		return doc, nil
+	})
+	return u
+}
+
+const TypeRiskEvaluationRequested = "RiskEvaluationRequested"
+
+// RiskEvaluationRequested asks the risk engine to score a new payment. The
+// engine answers on the risk decisions topic with the same correlation ID.
+type RiskEvaluationRequested struct {
+	SchemaVersion int       `json:"schema_version"`
+	Type          string    `json:"type"`
+	PaymentID     string    `json:"payment_id"`
+	UserID        string    `json:"user_id"`
+	MerchantID    string    `json:"merchant_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	CorrelationID string    `json:"correlation_id"`
+	OccurredAt    time.Time `json:"occurred_at"`
+
+	InstrumentFingerprint string `json:"instrument_fingerprint,omitempty"`
+	ExperimentID          string `json:"experiment_id,omitempty"`
+	ExperimentArm         string `json:"experiment_arm,omitempty"`
+}
+
+const TypePaymentDecisionOverridden = "PaymentDecisionOverridden"
+
+// PaymentDecisionOverridden is emitted when an analyst changes a payment's
+// decision. ApprovedBy is set when the override needed a second approver.
+type PaymentDecisionOverridden struct {
+	SchemaVersion  int       `json:"schema_version"`
+	Type           string    `json:"type"`
+	PaymentID      string    `json:"payment_id"`
+	OverrideID     string    `json:"override_id"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	ReasonCode     string    `json:"reason_code"`
+	Note           string    `json:"note,omitempty"`
+	RequestedBy    string    `json:"requested_by"`
+	ApprovedBy     string    `json:"approved_by,omitempty"`
+	CorrelationID  string    `json:"correlation_id"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+const (
+	TypePaymentRefunded = "PaymentRefunded"
+	TypePaymentReversed = "PaymentReversed"
+)
+
+// PaymentRefunded is emitted, as PaymentRefunded or PaymentReversed by
+// kind, for every refund recorded against an approved payment. Status and
+// RefundedAmount describe the payment after the refund.
+type PaymentRefunded struct {
+	SchemaVersion  int       `json:"schema_version"`
+	Type           string    `json:"type"`
+	PaymentID      string    `json:"payment_id"`
+	RefundID       string    `json:"refund_id"`
+	Kind           string    `json:"kind"`
+	Amount         int64     `json:"amount"`
+	Currency       string    `json:"currency"`
+	RefundedAmount int64     `json:"refunded_amount"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	Reason         string    `json:"reason,omitempty"`
+	MerchantID     string    `json:"merchant_id"`
+	CorrelationID  string    `json:"correlation_id"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+const TypePaymentChallengeRequired = "PaymentChallengeRequired"
+
+// PaymentChallengeRequired tells the merchant to run step-up authentication
+// (for example 3-D Secure) before ExpiresAt. The payment is finalized by a
+// PaymentDecisionFinalized event once the result arrives or the challenge
+// expires.
+type PaymentChallengeRequired struct {
+	SchemaVersion int       `json:"schema_version"`
+	Type          string    `json:"type"`
+	PaymentID     string    `json:"payment_id"`
+	MerchantID    string    `json:"merchant_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	Score         float64   `json:"score"`
+	Reason        string    `json:"reason"`
+	ReasonCodes   []string  `json:"reason_codes,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CorrelationID string    `json:"correlation_id"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
@@ -0,0 +1,132 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestUpcastPaymentDecisionFinalized(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     int
+		payload     string
+		correlation string
+		occurredAt  string
+	}{
+		{
+			name:        "v1 payload",
+			version:     1,
+			payload:     `{"type":"PaymentDecisionFinalized","payment_id":"p-1","status":"APPROVED","correlation":"c-1","ts":"2026-01-02T03:04:05Z"}`,
+			correlation: "c-1",
+			occurredAt:  "2026-01-02T03:04:05Z",
+		},
+		{
+			name:        "unversioned payload is v1",
+			version:     0,
+			payload:     `{"type":"PaymentDecisionFinalized","payment_id":"p-1","status":"DECLINED","correlation":"c-2","ts":"2026-01-02T03:04:05Z"}`,
+			correlation: "c-2",
+			occurredAt:  "2026-01-02T03:04:05Z",
+		},
+		{
+			name:        "v1 payload that already has the v2 field keeps it",
+			version:     1,
+			payload:     `{"type":"PaymentDecisionFinalized","payment_id":"p-1","correlation":"old","correlation_id":"c-3","ts":"2026-01-02T03:04:05Z"}`,
+			correlation: "c-3",
+			occurredAt:  "2026-01-02T03:04:05Z",
+		},
+		{
+			name:        "v2 payload is untouched",
+			version:     2,
+			payload:     `{"schema_version":2,"type":"PaymentDecisionFinalized","payment_id":"p-1","correlation_id":"c-4","occurred_at":"2026-01-02T03:04:05Z"}`,
+			correlation: "c-4",
+			occurredAt:  "2026-01-02T03:04:05Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, version, err := Default().Upcast(TypePaymentDecisionFinalized, tt.version, []byte(tt.payload))
+			if err != nil {
+				t.Fatalf("Upcast: %v", err)
+			}
+			if version != 2 {
+				t.Errorf("version = %d, want 2", version)
+			}
+			var doc map[string]any
+			if err := json.Unmarshal(out, &doc); err != nil {
+				t.Fatal(err)
+			}
+			for _, old := range []string{"correlation", "ts"} {
+				if _, ok := doc[old]; ok {
+					t.Errorf("v1 field %q still present in %s", old, out)
+				}
+			}
+			var e PaymentDecisionFinalized
+			if err := json.Unmarshal(out, &e); err != nil {
+				t.Fatal(err)
+			}
+			if e.SchemaVersion != 2 {
+				t.Errorf("schema_version = %d, want 2", e.SchemaVersion)
+			}
+			if e.CorrelationID != tt.correlation {
+				t.Errorf("correlation_id = %q, want %q", e.CorrelationID, tt.correlation)
+			}
+			if got := e.OccurredAt.Format(time.RFC3339); got != tt.occurredAt {
+				t.Errorf("occurred_at = %s, want %s", got, tt.occurredAt)
+			}
+		})
+	}
+}
+
+func TestUpcastErrors(t *testing.T) {
+	u := NewUpcasters()
+	u.Register("Gapped", 2, func(doc map[string]any) (map[string]any, error) { return doc, nil })
+
+	tests := []struct {
+		name      string
+		eventType string
+		version   int
+		payload   string
+	}{
+		{"newer than supported", TypePaymentDecisionFinalized, 3, `{}`},
+		{"missing step", "Gapped", 1, `{}`},
+		{"invalid json", TypePaymentDecisionFinalized, 1, `{`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up := Default()
+			if tt.eventType == "Gapped" {
+				up = u
+			}
+			if _, _, err := up.Upcast(tt.eventType, tt.version, []byte(tt.payload)); err == nil {
+				t.Error("Upcast succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestUpcastUnknownTypePassesThrough(t *testing.T) {
+	payload := []byte(`{"a":1}`)
+	out, version, err := Default().Upcast("SomethingElse", 1, payload)
+	if err != nil || version != 1 || string(out) != string(payload) {
+		t.Errorf("Upcast = %s, %d, %v; want payload unchanged at v1", out, version, err)
+	}
+}
+
+func TestPayloadVersion(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    int
+	}{
+		{`{"schema_version":2}`, 2},
+		{`{"type":"x"}`, 1},
+		{`{"schema_version":0}`, 1},
+		{`{"schema_version":"2"}`, 1},
+		{`not json`, 1},
+	}
+	for _, tt := range tests {
+		if got := PayloadVersion([]byte(tt.payload)); got != tt.want {
+			t.Errorf("PayloadVersion(%s) = %d, want %d", tt.payload, got, tt.want)
+		}
+	}
+}
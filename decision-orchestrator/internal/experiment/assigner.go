@@ -0,0 +1,78 @@
+package experiment
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+// Assigner keeps the active experiments in memory so intake does not query
+// for them on every payment. Newly started or stopped experiments take
+// effect within one reload interval.
+type Assigner struct {
+	log      *zap.Logger
+	repo     *ExperimentRepo
+	interval time.Duration
+	active   atomic.Pointer[activeSet]
+}
+
+type activeSet struct {
+	global     *Experiment
+	byMerchant map[string]*Experiment
+}
+
+func NewAssigner(l *zap.Logger, r *ExperimentRepo, interval time.Duration) *Assigner {
+	a := &Assigner{log: l, repo: r, interval: interval}
+	a.active.Store(&activeSet{byMerchant: map[string]*Experiment{}})
+	return a
+}
+
+func (a *Assigner) Load(ctx context.Context) error {
+	exps, err := a.repo.List(ctx, StatusActive)
+	if err != nil {
+		return err
+	}
+	set := &activeSet{byMerchant: map[string]*Experiment{}}
+	for i := range exps {
+		e := &exps[i]
+		if e.MerchantID == "" {
+			set.global = e
+		} else {
+			set.byMerchant[e.MerchantID] = e
+		}
+	}
+	a.active.Store(set)
+	return nil
+}
+
+func (a *Assigner) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.Load(ctx); err != nil {
+				a.log.Error("experiment reload failed, keeping previous set", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Assign returns the arm p falls into, or nil when no experiment covers
+// its merchant.
+func (a *Assigner) Assign(p repo.Payment) *repo.ExperimentAssignment {
+	set := a.active.Load()
+	if e, ok := set.byMerchant[p.MerchantID]; ok {
+		return e.Assign(p)
+	}
+	if set.global != nil {
+		return set.global.Assign(p)
+	}
+	return nil
+}
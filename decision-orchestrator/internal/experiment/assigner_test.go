@@ -0,0 +1,48 @@
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+func TestAssignerAssign(t *testing.T) {
+	global := threeArms()
+	global.ID = "exp-global"
+	merchant := threeArms()
+	merchant.ID, merchant.MerchantID = "exp-m1", "m-1"
+
+	a := NewAssigner(zap.NewNop(), nil, time.Minute)
+	if got := a.Assign(repo.Payment{ID: "pay-1", MerchantID: "m-1"}); got != nil {
+		t.Fatalf("Assign with no experiments = %+v, want nil", got)
+	}
+
+	a.active.Store(&activeSet{byMerchant: map[string]*Experiment{"m-1": &merchant}})
+	if got := a.Assign(repo.Payment{ID: "pay-1", MerchantID: "m-2"}); got != nil {
+		t.Errorf("Assign for an uncovered merchant = %+v, want nil", got)
+	}
+
+	a.active.Store(&activeSet{global: &global, byMerchant: map[string]*Experiment{"m-1": &merchant}})
+	tests := []struct {
+		name       string
+		merchantID string
+		want       string
+	}{
+		{"merchant experiment wins over the global one", "m-1", "exp-m1"},
+		{"global experiment covers other merchants", "m-2", "exp-global"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := repo.Payment{ID: "pay-1", MerchantID: tt.merchantID}
+			got := a.Assign(p)
+			if got == nil || got.ExperimentID != tt.want {
+				t.Fatalf("Assign = %+v, want experiment %s", got, tt.want)
+			}
+			if again := a.Assign(p); *again != *got {
+				t.Errorf("second Assign = %+v, want %+v", again, got)
+			}
+		})
+	}
+}
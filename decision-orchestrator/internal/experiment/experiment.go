@@ -0,0 +1,290 @@
+package experiment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type Status string
+
+const (
+	StatusActive  Status = "active"
+	StatusStopped Status = "stopped"
+)
+
+// Bucketing keys. Bucketing by user keeps a user in the same arm across
+// payments; bucketing by payment spreads each user's traffic.
+const (
+	BucketByPayment = "payment"
+	BucketByUser    = "user"
+)
+
+var (
+	ErrExperimentNotFound = errors.New("experiment not found")
+	ErrInvalidExperiment  = errors.New("invalid experiment")
+	ErrExperimentConflict = errors.New("another experiment is already active for this scope")
+	ErrNotActive          = errors.New("experiment is not active")
+)
+
+// Arm is one slice of traffic. Weight is a percentage; a nil Policy makes
+// the arm a control that keeps the merchant's regular policy.
+type Arm struct {
+	Name   string          `bson:"name" json:"name"`
+	Weight int             `bson:"weight" json:"weight"`
+	Policy *repo.ArmPolicy `bson:"policy,omitempty" json:"policy,omitempty"`
+}
+
+// Experiment splits new payments of one merchant, or of all merchants when
+// MerchantID is empty, into arms. At most one experiment is active per
+// scope; a merchant's own experiment wins over a global one.
+type Experiment struct {
+	ID         string     `bson:"_id" json:"id"`
+	Name       string     `bson:"name" json:"name"`
+	MerchantID string     `bson:"merchant_id" json:"merchant_id,omitempty"`
+	BucketBy   string     `bson:"bucket_by" json:"bucket_by"`
+	Arms       []Arm      `bson:"arms" json:"arms"`
+	Status     Status     `bson:"status" json:"status"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	CreatedBy  string     `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	StoppedAt  *time.Time `bson:"stopped_at,omitempty" json:"stopped_at,omitempty"`
+}
+
+func (e Experiment) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidExperiment)
+	}
+	if e.BucketBy != BucketByPayment && e.BucketBy != BucketByUser {
+		return fmt.Errorf("%w: bucket_by must be %s or %s", ErrInvalidExperiment, BucketByPayment, BucketByUser)
+	}
+	if len(e.Arms) < 2 {
+		return fmt.Errorf("%w: at least two arms are required", ErrInvalidExperiment)
+	}
+	names := map[string]bool{}
+	total := 0
+	for _, a := range e.Arms {
+		if a.Name == "" || names[a.Name] {
+			return fmt.Errorf("%w: arm names must be set and unique", ErrInvalidExperiment)
+		}
+		names[a.Name] = true
+		if a.Weight <= 0 {
+			return fmt.Errorf("%w: arm %s weight must be positive", ErrInvalidExperiment, a.Name)
+		}
+		total += a.Weight
+		if a.Policy != nil {
+			if err := a.Policy.Validate(); err != nil {
+				return fmt.Errorf("%w: arm %s: %v", ErrInvalidExperiment, a.Name, err)
+			}
+		}
+	}
+	if total != 100 {
+		return fmt.Errorf("%w: arm weights sum to %d, want 100", ErrInvalidExperiment, total)
+	}
+	return nil
+}
+
+// Assign buckets p deterministically: the same experiment and key always
+// land in the same arm, on every replica.
+func (e Experiment) Assign(p repo.Payment) *repo.ExperimentAssignment {
+	key := p.ID
+	if e.BucketBy == BucketByUser {
+		key = p.UserID
+	}
+	sum := sha256.Sum256([]byte(e.ID + ":" + key))
+	bucket := int(binary.BigEndian.Uint64(sum[:8]) % 100)
+
+	arm := e.Arms[len(e.Arms)-1]
+	for _, a := range e.Arms {
+		if bucket < a.Weight {
+			arm = a
+			break
+		}
+		bucket -= a.Weight
+	}
+	return &repo.ExperimentAssignment{ExperimentID: e.ID, Arm: arm.Name, Policy: arm.Policy}
+}
+
+type ExperimentRepo struct {
+	col      *mongo.Collection
+	payments *mongo.Collection
+}
+
+func NewExperimentRepo(db *mongo.Database) *ExperimentRepo {
+	return &ExperimentRepo{col: db.Collection("experiments"), payments: db.Collection("payments")}
+}
+
+func (r *ExperimentRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "merchant_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": StatusActive}),
+		},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func (r *ExperimentRepo) Create(ctx context.Context, e Experiment) (*Experiment, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	e.ID = uuid.NewString()
+	e.Status = StatusActive
+	e.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	e.StoppedAt = nil
+
+	_, err := r.col.InsertOne(ctx, e)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrExperimentConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *ExperimentRepo) Get(ctx context.Context, id string) (*Experiment, error) {
+	var e Experiment
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&e)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrExperimentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// List returns experiments newest first, optionally only those in status.
+func (r *ExperimentRepo) List(ctx context.Context, status Status) ([]Experiment, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	cur, err := r.col.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []Experiment{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Stop ends an active experiment. Payments already assigned keep their arm.
+func (r *ExperimentRepo) Stop(ctx context.Context, id string) (*Experiment, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$set": bson.M{"status": StatusStopped, "stopped_at": time.Now().UTC().Truncate(time.Millisecond)}}
+
+	var e Experiment
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": StatusActive}, update, opts).Decode(&e)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, getErr := r.Get(ctx, id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrNotActive
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ArmResult summarizes the outcomes of one arm's payments. ChargebackRate
+// is over approved payments, the only ones that can be charged back.
+type ArmResult struct {
+	Arm            string           `json:"arm"`
+	Payments       int64            `json:"payments"`
+	ByStatus       map[string]int64 `json:"by_status"`
+	Decided        int64            `json:"decided"`
+	ApprovalRate   float64          `json:"approval_rate"`
+	Chargebacks    int64            `json:"chargebacks"`
+	Fraud          int64            `json:"fraud"`
+	ChargebackRate float64          `json:"chargeback_rate"`
+}
+
+// Results reports per arm outcomes for every payment assigned to the
+// experiment, including feedback labels received since. The approval rate
+// is over payments that reached a decision.
+func (r *ExperimentRepo) Results(ctx context.Context, id string) ([]ArmResult, error) {
+	e, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := r.payments.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"experiment.experiment_id": id}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         bson.M{"arm": "$experiment.arm", "status": "$status"},
+			"count":       bson.M{"$sum": 1},
+			"chargebacks": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$chargebacked", true}}, 1, 0}}},
+			"fraud":       bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$fraud", true}}, 1, 0}}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	byArm := map[string]*ArmResult{}
+	for _, a := range e.Arms {
+		byArm[a.Name] = &ArmResult{Arm: a.Name, ByStatus: map[string]int64{}}
+	}
+	for cur.Next(ctx) {
+		var row struct {
+			ID struct {
+				Arm    string `bson:"arm"`
+				Status string `bson:"status"`
+			} `bson:"_id"`
+			Count       int64 `bson:"count"`
+			Chargebacks int64 `bson:"chargebacks"`
+			Fraud       int64 `bson:"fraud"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return nil, err
+		}
+		res, ok := byArm[row.ID.Arm]
+		if !ok {
+			continue
+		}
+		res.Payments += row.Count
+		res.ByStatus[row.ID.Status] += row.Count
+		res.Chargebacks += row.Chargebacks
+		res.Fraud += row.Fraud
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]ArmResult, 0, len(e.Arms))
+	for _, a := range e.Arms {
+		res := byArm[a.Name]
+		res.Decided = res.Payments - res.ByStatus[string(repo.StatusPending)]
+		var approved int64
+		for _, s := range repo.ApprovedStatuses {
+			approved += res.ByStatus[string(s)]
+		}
+		if res.Decided > 0 {
+			res.ApprovalRate = float64(approved) / float64(res.Decided)
+		}
+		if approved > 0 {
+			res.ChargebackRate = float64(res.Chargebacks) / float64(approved)
+		}
+		out = append(out, *res)
+	}
+	return out, nil
+}
@@ -0,0 +1,164 @@
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+func threeArms() Experiment {
+	return Experiment{
+		ID:       "exp-1",
+		Name:     "stricter declines",
+		BucketBy: BucketByPayment,
+		Arms: []Arm{
+			{Name: "control", Weight: 50},
+			{Name: "strict", Weight: 30, Policy: &repo.ArmPolicy{ApproveBelow: 0.2, DeclineFrom: 0.6}},
+			{Name: "lenient", Weight: 20, Policy: &repo.ArmPolicy{ApproveBelow: 0.4, DeclineFrom: 0.9}},
+		},
+	}
+}
+
+func TestExperimentValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Experiment)
+	}{
+		{"no name", func(e *Experiment) { e.Name = "" }},
+		{"unknown bucketing", func(e *Experiment) { e.BucketBy = "merchant" }},
+		{"one arm", func(e *Experiment) { e.Arms = []Arm{{Name: "all", Weight: 100}} }},
+		{"unnamed arm", func(e *Experiment) { e.Arms[1].Name = "" }},
+		{"duplicate arm", func(e *Experiment) { e.Arms[2].Name = "control" }},
+		{"zero weight", func(e *Experiment) { e.Arms[2].Weight, e.Arms[0].Weight = 0, 70 }},
+		{"weights under 100", func(e *Experiment) { e.Arms[0].Weight = 40 }},
+		{"weights over 100", func(e *Experiment) { e.Arms[0].Weight = 60 }},
+		{"bad arm policy", func(e *Experiment) { e.Arms[1].Policy = &repo.ArmPolicy{ApproveBelow: 0.8, DeclineFrom: 0.2} }},
+	}
+	if err := threeArms().Validate(); err != nil {
+		t.Fatalf("Validate of a valid experiment: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := threeArms()
+			tt.modify(&e)
+			if err := e.Validate(); !errors.Is(err, ErrInvalidExperiment) {
+				t.Errorf("Validate = %v, want ErrInvalidExperiment", err)
+			}
+		})
+	}
+}
+
+func TestAssignIsDeterministic(t *testing.T) {
+	e := threeArms()
+	for i := range 200 {
+		p := repo.Payment{ID: fmt.Sprintf("pay-%d", i), UserID: "u-1"}
+		first := e.Assign(p)
+		// a copy stands in for the same experiment loaded on another replica
+		again := threeArms().Assign(p)
+		if first.Arm != again.Arm {
+			t.Fatalf("payment %s assigned %+v, then %+v", p.ID, first, again)
+		}
+		if first.ExperimentID != "exp-1" {
+			t.Fatalf("experiment id = %q, want exp-1", first.ExperimentID)
+		}
+	}
+}
+
+// Assignments are persisted with payments and compared across deploys, so
+// the hashing must not change. Buckets are the first 8 bytes of
+// sha256("exp-1:<payment id>") mod 100, computed independently.
+func TestAssignIsStable(t *testing.T) {
+	e := threeArms()
+	tests := []struct {
+		paymentID string
+		arm       string
+	}{
+		{"pay-1", "control"},  // bucket 14
+		{"pay-3", "control"},  // bucket 49, the last of control
+		{"pay-2", "strict"},   // bucket 59
+		{"pay-0", "strict"},   // bucket 79, the last of strict
+		{"pay-29", "lenient"}, // bucket 83
+		{"pay-7", "lenient"},  // bucket 98
+	}
+	for _, tt := range tests {
+		got := e.Assign(repo.Payment{ID: tt.paymentID})
+		if got.Arm != tt.arm {
+			t.Errorf("Assign(%s) = %s, want %s", tt.paymentID, got.Arm, tt.arm)
+		}
+		if (got.Policy == nil) != (tt.arm == "control") {
+			t.Errorf("Assign(%s) policy = %+v, want the %s arm's", tt.paymentID, got.Policy, tt.arm)
+		}
+	}
+}
+
+func TestAssignFollowsWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+	}{
+		{"even", []int{50, 50}},
+		{"skewed", []int{90, 10}},
+		{"three arms", []int{50, 30, 20}},
+		{"tiny arm", []int{1, 99}},
+	}
+	const n = 20000
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Experiment{ID: "exp-" + tt.name, BucketBy: BucketByPayment}
+			for i, w := range tt.weights {
+				e.Arms = append(e.Arms, Arm{Name: fmt.Sprintf("arm-%d", i), Weight: w})
+			}
+			counts := map[string]int{}
+			for i := range n {
+				counts[e.Assign(repo.Payment{ID: fmt.Sprintf("pay-%d", i)}).Arm]++
+			}
+			for _, a := range e.Arms {
+				share := float64(counts[a.Name]) / n * 100
+				if math.Abs(share-float64(a.Weight)) > 1.5 {
+					t.Errorf("arm %s got %.1f%% of traffic, want %d%%", a.Name, share, a.Weight)
+				}
+			}
+		})
+	}
+}
+
+func TestAssignBucketing(t *testing.T) {
+	byUser := threeArms()
+	byUser.BucketBy = BucketByUser
+	byPayment := threeArms()
+
+	userArms, paymentArms := map[string]bool{}, map[string]bool{}
+	for i := range 100 {
+		p := repo.Payment{ID: fmt.Sprintf("pay-%d", i), UserID: "u-42"}
+		userArms[byUser.Assign(p).Arm] = true
+		paymentArms[byPayment.Assign(p).Arm] = true
+	}
+	if len(userArms) != 1 {
+		t.Errorf("one user bucketed by user landed in %d arms, want 1", len(userArms))
+	}
+	if len(paymentArms) != 3 {
+		t.Errorf("one user bucketed by payment landed in %d arms, want all 3", len(paymentArms))
+	}
+}
+
+// Each experiment hashes its own ID in, so running a new experiment does not
+// give it the same split of users as the last one.
+func TestAssignIndependentAcrossExperiments(t *testing.T) {
+	a, b := threeArms(), threeArms()
+	b.ID = "exp-2"
+	same := 0
+	const n = 2000
+	for i := range n {
+		p := repo.Payment{ID: fmt.Sprintf("pay-%d", i)}
+		if a.Assign(p).Arm == b.Assign(p).Arm {
+			same++
+		}
+	}
+	// independent 50/30/20 splits coincide for 0.25+0.09+0.04 = 38%
+	if share := float64(same) / n; share < 0.33 || share > 0.43 {
+		t.Errorf("experiments agreed on %.0f%% of payments, want about 38%%", share*100)
+	}
+}
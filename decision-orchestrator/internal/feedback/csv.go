@@ -0,0 +1,79 @@
+package feedback
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var requiredColumns = []string{"id", "payment_id", "fraud", "occurred_at"}
+
+// ReadCSV parses a label import with a header row. Required columns are id,
+// payment_id, fraud and occurred_at (RFC3339); reason_code, amount and
+// source are optional. Rows without a source get defaultSource. fn is called
+// with the 1-based line number of each row.
+func ReadCSV(r io.Reader, defaultSource string, fn func(line int, m LabelMessage) error) error {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, c := range requiredColumns {
+		if _, ok := cols[c]; !ok {
+			return fmt.Errorf("missing column %q", c)
+		}
+	}
+	get := func(row []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			// a csv.ParseError names its line
+			return err
+		}
+		// quoted fields may span lines, so count from the reader
+		line, _ := cr.FieldPos(0)
+
+		m := LabelMessage{
+			ID:         get(row, "id"),
+			PaymentID:  get(row, "payment_id"),
+			Source:     get(row, "source"),
+			ReasonCode: get(row, "reason_code"),
+		}
+		if m.Source == "" {
+			m.Source = defaultSource
+		}
+		if m.Fraud, err = strconv.ParseBool(get(row, "fraud")); err != nil {
+			return fmt.Errorf("line %d: fraud must be true or false", line)
+		}
+		if m.OccurredAt, err = time.Parse(time.RFC3339, get(row, "occurred_at")); err != nil {
+			return fmt.Errorf("line %d: occurred_at must be RFC3339", line)
+		}
+		if raw := get(row, "amount"); raw != "" {
+			if m.Amount, err = strconv.ParseInt(raw, 10, 64); err != nil {
+				return fmt.Errorf("line %d: amount must be an integer in minor units", line)
+			}
+		}
+		if err := fn(line, m); err != nil {
+			return err
+		}
+	}
+}
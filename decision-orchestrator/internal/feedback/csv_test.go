@@ -0,0 +1,96 @@
+package feedback
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAll(t *testing.T, input string) ([]LabelMessage, []int, error) {
+	t.Helper()
+	var got []LabelMessage
+	var lines []int
+	err := ReadCSV(strings.NewReader(input), "bulk-import", func(line int, m LabelMessage) error {
+		got = append(got, m)
+		lines = append(lines, line)
+		return nil
+	})
+	return got, lines, err
+}
+
+func TestReadCSV(t *testing.T) {
+	input := "Payment_ID, id ,FRAUD,occurred_at,reason_code,amount,source\n" +
+		"p-1,l-1,true,2026-06-01T10:00:00Z,10.4,1250,visa\n" +
+		// quoted fields may hold commas, quotes and line breaks
+		"p-2,l-2,false,2026-06-02T11:30:00+02:00,\"friendly, \"\"family\"\" use\nconfirmed\",,\n" +
+		"p-3,l-3,1,2026-06-03T00:00:00Z,,, \n"
+
+	got, lines, err := readAll(t, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LabelMessage{
+		{ID: "l-1", PaymentID: "p-1", Source: "visa", Fraud: true, ReasonCode: "10.4", Amount: 1250, OccurredAt: time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)},
+		{ID: "l-2", PaymentID: "p-2", Source: "bulk-import", ReasonCode: "friendly, \"family\" use\nconfirmed", OccurredAt: time.Date(2026, 6, 2, 9, 30, 0, 0, time.UTC)},
+		{ID: "l-3", PaymentID: "p-3", Source: "bulk-import", Fraud: true, OccurredAt: time.Date(2026, 6, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("read %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.ID != w.ID || g.PaymentID != w.PaymentID || g.Source != w.Source || g.Fraud != w.Fraud ||
+			g.ReasonCode != w.ReasonCode || g.Amount != w.Amount || !g.OccurredAt.Equal(w.OccurredAt) {
+			t.Errorf("row %d = %+v, want %+v", i, g, w)
+		}
+	}
+	// the quoted line break makes the third record start on line 5
+	if lines[0] != 2 || lines[1] != 3 || lines[2] != 5 {
+		t.Errorf("record lines = %v, want [2 3 5]", lines)
+	}
+}
+
+func TestReadCSVHeaderOnly(t *testing.T) {
+	got, _, err := readAll(t, "id,payment_id,fraud,occurred_at\n")
+	if err != nil || len(got) != 0 {
+		t.Errorf("ReadCSV of a header only = %d rows, %v; want none and no error", len(got), err)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	const header = "id,payment_id,fraud,occurred_at,amount\n"
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty input", "", "read header"},
+		{"missing column", "id,payment_id,occurred_at\nl-1,p-1,2026-06-01T10:00:00Z\n", `missing column "fraud"`},
+		{"bad fraud flag", header + "l-1,p-1,yes,2026-06-01T10:00:00Z,\n", "line 2: fraud"},
+		{"bad time", header + "l-1,p-1,true,2026-06-01,\n", "line 2: occurred_at"},
+		{"bad amount", header + "l-1,p-1,true,2026-06-01T10:00:00Z,12.50\n", "line 2: amount"},
+		{"bad quoting", header + "l-1,p-1,true,2026-06-01T10:00:00Z,1\n\"l-2,p-2\n", "line 3"},
+		{"short row", header + "l-1,p-1\n", "record on line 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := readAll(t, tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ReadCSV = %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSVStopsOnCallbackError(t *testing.T) {
+	stop := errors.New("payment not found")
+	calls := 0
+	err := ReadCSV(strings.NewReader("id,payment_id,fraud,occurred_at\nl-1,p-1,true,2026-06-01T10:00:00Z\nl-2,p-2,true,2026-06-01T10:00:00Z\n"), "", func(int, LabelMessage) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("ReadCSV = %v after %d calls, want the callback's error after 1", err, calls)
+	}
+}
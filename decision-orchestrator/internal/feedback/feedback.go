@@ -0,0 +1,135 @@
+package feedback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	segmentioKafka "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidLabel = errors.New("invalid label")
+
+// LabelMessage is one entry on the chargebacks topic, also the shape of a
+// row in the bulk import.
+type LabelMessage struct {
+	ID         string    `json:"id"`
+	PaymentID  string    `json:"payment_id"`
+	Source     string    `json:"source"`
+	Fraud      bool      `json:"fraud"`
+	ReasonCode string    `json:"reason_code,omitempty"`
+	Amount     int64     `json:"amount,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (m LabelMessage) Validate() error {
+	switch {
+	case m.ID == "":
+		return fmt.Errorf("%w: id is required", ErrInvalidLabel)
+	case m.PaymentID == "":
+		return fmt.Errorf("%w: payment_id is required", ErrInvalidLabel)
+	case m.Amount < 0:
+		return fmt.Errorf("%w: amount must not be negative", ErrInvalidLabel)
+	case m.OccurredAt.IsZero():
+		return fmt.Errorf("%w: occurred_at is required", ErrInvalidLabel)
+	}
+	return nil
+}
+
+type Ingester struct {
+	log      *zap.Logger
+	payments *repo.PaymentRepo
+	audit    *audit.AuditRepo
+}
+
+func NewIngester(l *zap.Logger, payments *repo.PaymentRepo, auditTrail *audit.AuditRepo) *Ingester {
+	return &Ingester{log: l, payments: payments, audit: auditTrail}
+}
+
+// Attach labels a payment and records the label in its audit trail. The
+// payment's status is not changed. Labels already attached are skipped, so
+// redelivered messages and re-run imports are harmless; the boolean reports
+// whether the label was new.
+func (i *Ingester) Attach(ctx context.Context, m LabelMessage) (bool, error) {
+	if m.Source == "" {
+		m.Source = repo.LabelSourceChargeback
+	}
+	if err := m.Validate(); err != nil {
+		return false, err
+	}
+	p, err := i.payments.Get(ctx, m.PaymentID)
+	if err != nil {
+		return false, err
+	}
+	if m.Amount > p.Amount {
+		return false, fmt.Errorf("%w: amount %d exceeds payment amount %d", ErrInvalidLabel, m.Amount, p.Amount)
+	}
+
+	label := repo.Label{
+		ID:         m.ID,
+		Source:     strings.ToLower(m.Source),
+		Fraud:      m.Fraud,
+		ReasonCode: m.ReasonCode,
+		Amount:     m.Amount,
+		OccurredAt: m.OccurredAt.UTC(),
+		ReceivedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	added, err := i.payments.AddLabel(ctx, p.ID, label)
+	if err != nil || !added {
+		return false, err
+	}
+
+	verdict := "not fraud"
+	if label.Fraud {
+		verdict = "fraud"
+	}
+	reason := fmt.Sprintf("labelled %s by %s %s", verdict, label.Source, label.ID)
+	if label.ReasonCode != "" {
+		reason += " (reason " + label.ReasonCode + ")"
+	}
+	if _, err := i.audit.Append(ctx, audit.Record{
+		PaymentID:      p.ID,
+		Kind:           audit.KindLabel,
+		Actor:          audit.ActorFeedback,
+		ActorID:        label.Source,
+		PreviousStatus: string(p.Status),
+		NewStatus:      string(p.Status),
+		Score:          p.RiskScore,
+		Reason:         reason,
+		CorrelationID:  p.CorrelationID,
+		PolicyVersion:  p.PolicyVersion,
+	}); err != nil {
+		i.log.Error("failed to append audit record", zap.Error(err), zap.String("payment_id", p.ID), zap.String("kind", string(audit.KindLabel)))
+	}
+	return true, nil
+}
+
+// Handle consumes the chargebacks topic. Malformed messages and labels for
+// unknown payments are logged and skipped; storage errors are returned so
+// the message is retried.
+func (i *Ingester) Handle(ctx context.Context, msg segmentioKafka.Message) error {
+	var m LabelMessage
+	if err := json.Unmarshal(msg.Value, &m); err != nil {
+		i.log.Error("invalid label message", zap.Error(err))
+		return nil
+	}
+	added, err := i.Attach(ctx, m)
+	if errors.Is(err, ErrInvalidLabel) || errors.Is(err, repo.ErrPaymentNotFound) {
+		i.log.Warn("label skipped", zap.Error(err), zap.String("label_id", m.ID), zap.String("payment_id", m.PaymentID))
+		return nil
+	}
+	if err != nil {
+		i.log.Error("failed to attach label", zap.Error(err), zap.String("payment_id", m.PaymentID))
+		return err
+	}
+	if added {
+		i.log.Info("label attached", zap.String("payment_id", m.PaymentID), zap.String("label_id", m.ID), zap.Bool("fraud", m.Fraud))
+	}
+	return nil
+}
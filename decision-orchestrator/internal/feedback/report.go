@@ -0,0 +1,215 @@
+package feedback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	GroupByScoreBucket   = "score_bucket"
+	GroupByPolicyVersion = "policy_version"
+)
+
+var ErrInvalidGrouping = errors.New("group_by must be score_bucket or policy_version")
+
+// QualityRow measures decisions against fraud labels. A payment counts as
+// flagged when it was declined or sent to review. Payments without a fraud
+// label are treated as legitimate, and declined payments rarely get labels
+// at all, so precision is a lower bound. Amounts are in the report's
+// reporting currency and leave out the Unconverted payments.
+type QualityRow struct {
+	Group          string  `json:"group"`
+	Decided        int64   `json:"decided"`
+	Flagged        int64   `json:"flagged"`
+	Labelled       int64   `json:"labelled"`
+	Fraud          int64   `json:"fraud"`
+	TruePositives  int64   `json:"true_positives"`
+	FalsePositives int64   `json:"false_positives"`
+	FalseNegatives int64   `json:"false_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	DecidedAmount  int64   `json:"decided_amount"`
+	FlaggedAmount  int64   `json:"flagged_amount"`
+	FraudAmount    int64   `json:"fraud_amount"`
+	CaughtAmount   int64   `json:"caught_fraud_amount"`
+	MissedAmount   int64   `json:"missed_fraud_amount"`
+	Unconverted    int64   `json:"unconverted"`
+}
+
+func (q *QualityRow) add(o QualityRow) {
+	q.Decided += o.Decided
+	q.Flagged += o.Flagged
+	q.Labelled += o.Labelled
+	q.Fraud += o.Fraud
+	q.TruePositives += o.TruePositives
+	q.FalsePositives += o.FalsePositives
+	q.FalseNegatives += o.FalseNegatives
+	q.DecidedAmount += o.DecidedAmount
+	q.FlaggedAmount += o.FlaggedAmount
+	q.FraudAmount += o.FraudAmount
+	q.CaughtAmount += o.CaughtAmount
+	q.MissedAmount += o.MissedAmount
+	q.Unconverted += o.Unconverted
+}
+
+func (q *QualityRow) rates() {
+	if d := q.TruePositives + q.FalsePositives; d > 0 {
+		q.Precision = float64(q.TruePositives) / float64(d)
+	}
+	if d := q.TruePositives + q.FalseNegatives; d > 0 {
+		q.Recall = float64(q.TruePositives) / float64(d)
+	}
+}
+
+type QualityReport struct {
+	GroupBy  string       `json:"group_by"`
+	Currency string       `json:"currency"`
+	From     time.Time    `json:"from,omitempty"`
+	To       time.Time    `json:"to,omitempty"`
+	Rows     []QualityRow `json:"rows"`
+	Total    QualityRow   `json:"total"`
+}
+
+type Reporter struct {
+	payments *mongo.Collection
+	currency string
+}
+
+// NewReporter reports amounts in currency, the reporting currency payments
+// are converted to at intake.
+func NewReporter(db *mongo.Database, currency string) *Reporter {
+	return &Reporter{payments: db.Collection("payments"), currency: currency}
+}
+
+// Quality reports precision and recall of payments created in [from, to),
+// grouped by tenth of risk score or by merchant policy version.
+func (r *Reporter) Quality(ctx context.Context, groupBy string, from, to time.Time) (QualityReport, error) {
+	var key any
+	switch groupBy {
+	case GroupByScoreBucket:
+		key = bson.M{"$min": bson.A{bson.M{"$floor": bson.M{"$multiply": bson.A{"$risk_score", 10}}}, 9}}
+	case GroupByPolicyVersion:
+		key = bson.M{"$ifNull": bson.A{"$policy_version", 0}}
+	default:
+		return QualityReport{}, ErrInvalidGrouping
+	}
+
+	decided := bson.A{repo.StatusDeclined, repo.StatusReview}
+	for _, s := range repo.ApprovedStatuses {
+		decided = append(decided, s)
+	}
+	match := bson.M{"status": bson.M{"$in": decided}}
+	created := bson.M{}
+	if !from.IsZero() {
+		created["$gte"] = from
+	}
+	if !to.IsZero() {
+		created["$lt"] = to
+	}
+	if len(created) > 0 {
+		match["created_at"] = created
+	}
+
+	flagged := bson.M{"$in": bson.A{"$status", bson.A{repo.StatusDeclined, repo.StatusReview}}}
+	fraud := bson.M{"$eq": bson.A{"$fraud", true}}
+	count := func(cond any) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{cond, 1, 0}}}
+	}
+	// payments converted before a change of reporting currency count as
+	// unconverted rather than mixing currencies
+	converted := bson.M{"$eq": bson.A{"$reporting.currency", r.currency}}
+	amount := func(cond any) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$and": bson.A{converted, cond}}, "$reporting.amount", 0}}}
+	}
+	cur, err := r.payments.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      key,
+			"decided":  bson.M{"$sum": 1},
+			"flagged":  count(flagged),
+			"labelled": count(bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$labels", bson.A{}}}}, 0}}),
+			"fraud":    count(fraud),
+			"tp":       count(bson.M{"$and": bson.A{flagged, fraud}}),
+			"fn":       count(bson.M{"$and": bson.A{bson.M{"$not": bson.A{flagged}}, fraud}}),
+
+			"decided_amount": amount(true),
+			"flagged_amount": amount(flagged),
+			"fraud_amount":   amount(fraud),
+			"tp_amount":      amount(bson.M{"$and": bson.A{flagged, fraud}}),
+			"fn_amount":      amount(bson.M{"$and": bson.A{bson.M{"$not": bson.A{flagged}}, fraud}}),
+			"unconverted":    count(bson.M{"$not": bson.A{converted}}),
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return QualityReport{}, err
+	}
+	defer cur.Close(ctx)
+
+	var groups []qualityGroup
+	if err := cur.All(ctx, &groups); err != nil {
+		return QualityReport{}, err
+	}
+	report := QualityReport{GroupBy: groupBy, Currency: r.currency, From: from, To: to}
+	report.summarize(groups)
+	return report, nil
+}
+
+// qualityGroup is one group of the aggregation in Quality.
+type qualityGroup struct {
+	Key      float64 `bson:"_id"`
+	Decided  int64   `bson:"decided"`
+	Flagged  int64   `bson:"flagged"`
+	Labelled int64   `bson:"labelled"`
+	Fraud    int64   `bson:"fraud"`
+	TP       int64   `bson:"tp"`
+	FN       int64   `bson:"fn"`
+
+	DecidedAmount int64 `bson:"decided_amount"`
+	FlaggedAmount int64 `bson:"flagged_amount"`
+	FraudAmount   int64 `bson:"fraud_amount"`
+	TPAmount      int64 `bson:"tp_amount"`
+	FNAmount      int64 `bson:"fn_amount"`
+	Unconverted   int64 `bson:"unconverted"`
+}
+
+// summarize fills the report's rows from groups and adds them up into the
+// total. The total's rates come from its summed counts, not from averaging
+// the rows' rates.
+func (qr *QualityReport) summarize(groups []qualityGroup) {
+	qr.Rows = make([]QualityRow, 0, len(groups))
+	qr.Total = QualityRow{}
+	for _, g := range groups {
+		q := QualityRow{
+			Decided:        g.Decided,
+			Flagged:        g.Flagged,
+			Labelled:       g.Labelled,
+			Fraud:          g.Fraud,
+			TruePositives:  g.TP,
+			FalsePositives: g.Flagged - g.TP,
+			FalseNegatives: g.FN,
+			DecidedAmount:  g.DecidedAmount,
+			FlaggedAmount:  g.FlaggedAmount,
+			FraudAmount:    g.FraudAmount,
+			CaughtAmount:   g.TPAmount,
+			MissedAmount:   g.FNAmount,
+			Unconverted:    g.Unconverted,
+		}
+		if qr.GroupBy == GroupByScoreBucket {
+			q.Group = fmt.Sprintf("%.1f-%.1f", g.Key/10, (g.Key+1)/10)
+		} else {
+			q.Group = fmt.Sprintf("v%d", int64(g.Key))
+		}
+		q.rates()
+		qr.Total.add(q)
+		qr.Rows = append(qr.Rows, q)
+	}
+	qr.Total.Group = "total"
+	qr.Total.rates()
+}
@@ -0,0 +1,84 @@
+package feedback
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	groups := []qualityGroup{
+		// low scores: mostly approved, the fraud here was missed
+		{Key: 0, Decided: 100, Flagged: 0, Labelled: 4, Fraud: 4, TP: 0, FN: 4, DecidedAmount: 50000, FraudAmount: 3000, FNAmount: 3000},
+		// middle: some flagged, some fraud caught
+		{Key: 5, Decided: 40, Flagged: 20, Labelled: 10, Fraud: 10, TP: 8, FN: 2, DecidedAmount: 40000, FlaggedAmount: 22000, FraudAmount: 9000, TPAmount: 7000, FNAmount: 2000, Unconverted: 3},
+		// top bucket: everything flagged, nothing labelled
+		{Key: 9, Decided: 10, Flagged: 10, DecidedAmount: 8000, FlaggedAmount: 8000},
+	}
+	r := QualityReport{GroupBy: GroupByScoreBucket}
+	r.summarize(groups)
+
+	tests := []struct {
+		group     string
+		fp        int64
+		precision float64
+		recall    float64
+	}{
+		{"0.0-0.1", 0, 0, 0},
+		{"0.5-0.6", 12, 0.4, 0.8},
+		{"0.9-1.0", 10, 0, 0},
+	}
+	if len(r.Rows) != len(tests) {
+		t.Fatalf("rows = %d, want %d", len(r.Rows), len(tests))
+	}
+	for i, tt := range tests {
+		row := r.Rows[i]
+		if row.Group != tt.group || row.FalsePositives != tt.fp || !near(row.Precision, tt.precision) || !near(row.Recall, tt.recall) {
+			t.Errorf("row %d = %s fp %d precision %v recall %v, want %s fp %d precision %v recall %v",
+				i, row.Group, row.FalsePositives, row.Precision, row.Recall, tt.group, tt.fp, tt.precision, tt.recall)
+		}
+	}
+	if r.Rows[1].CaughtAmount != 7000 || r.Rows[1].MissedAmount != 2000 || r.Rows[1].Unconverted != 3 {
+		t.Errorf("middle row amounts = %+v, want 7000 caught, 2000 missed, 3 unconverted", r.Rows[1])
+	}
+
+	total := r.Total
+	if total.Group != "total" || total.Decided != 150 || total.Flagged != 30 || total.Fraud != 14 ||
+		total.TruePositives != 8 || total.FalsePositives != 22 || total.FalseNegatives != 6 {
+		t.Errorf("total counts = %+v", total)
+	}
+	if total.DecidedAmount != 98000 || total.FlaggedAmount != 30000 || total.FraudAmount != 12000 ||
+		total.CaughtAmount != 7000 || total.MissedAmount != 5000 || total.Unconverted != 3 {
+		t.Errorf("total amounts = %+v", total)
+	}
+	// from the summed counts: 8/30 and 8/14, not the mean of the row rates
+	if !near(total.Precision, 8.0/30) || !near(total.Recall, 8.0/14) {
+		t.Errorf("total precision %v recall %v, want %v and %v", total.Precision, total.Recall, 8.0/30, 8.0/14)
+	}
+}
+
+func TestSummarizeByPolicyVersion(t *testing.T) {
+	r := QualityReport{GroupBy: GroupByPolicyVersion}
+	r.summarize([]qualityGroup{{Key: 0, Decided: 3}, {Key: 12, Decided: 5, Flagged: 1, TP: 1, Fraud: 1}})
+	if len(r.Rows) != 2 || r.Rows[0].Group != "v0" || r.Rows[1].Group != "v12" {
+		t.Fatalf("rows = %+v, want v0 and v12", r.Rows)
+	}
+	if r.Rows[1].Precision != 1 || r.Rows[1].Recall != 1 {
+		t.Errorf("v12 precision %v recall %v, want 1 and 1", r.Rows[1].Precision, r.Rows[1].Recall)
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	r := QualityReport{GroupBy: GroupByScoreBucket}
+	r.summarize(nil)
+	// rows encode as [] rather than null
+	if r.Rows == nil || len(r.Rows) != 0 {
+		t.Errorf("rows = %#v, want an empty slice", r.Rows)
+	}
+	if r.Total != (QualityRow{Group: "total"}) {
+		t.Errorf("total = %+v, want only the group name", r.Total)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
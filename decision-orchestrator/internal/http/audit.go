@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"go.uber.org/zap"
+)
+
+type TimelineReader interface {
+	Timeline(ctx context.Context, paymentID string) ([]audit.Record, error)
+}
+
+type RootLister interface {
+	List(ctx context.Context, from, to string) ([]audit.DailyRoot, error)
+}
+
+type AuditHandler struct {
+	log       *zap.Logger
+	timeline  TimelineReader
+	roots     RootLister
+	publicKey ed25519.PublicKey
+}
+
+func NewAuditHandler(log *zap.Logger, timeline TimelineReader) *AuditHandler {
+	return &AuditHandler{log: log, timeline: timeline}
+}
+
+// WithRoots enables the signed daily root export.
+func (h *AuditHandler) WithRoots(roots RootLister, publicKey ed25519.PublicKey) *AuditHandler {
+	h.roots = roots
+	h.publicKey = publicKey
+	return h
+}
+
+func (h *AuditHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /payments/{id}/decisions", h.list)
+	if h.roots != nil {
+		mux.HandleFunc("GET /audit/roots", h.listRoots)
+		mux.HandleFunc("GET /audit/public-key", h.getPublicKey)
+	}
+}
+
+func (h *AuditHandler) list(w http.ResponseWriter, r *http.Request) {
+	paymentID := r.PathValue("id")
+	records, err := h.timeline.Timeline(r.Context(), paymentID)
+	if err != nil {
+		h.log.Error("audit timeline lookup failed", zap.Error(err), zap.String("payment_id", paymentID))
+		writeError(w, http.StatusInternalServerError, "timeline lookup failed")
+		return
+	}
+	if len(records) == 0 {
+		writeError(w, http.StatusNotFound, "no decisions recorded for payment")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"payment_id": paymentID,
+		"decisions":  records,
+	})
+}
+
+func (h *AuditHandler) listRoots(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	roots, err := h.roots.List(r.Context(), q.Get("from"), q.Get("to"))
+	if err != nil {
+		h.log.Error("audit root listing failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "root listing failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"key_id": audit.KeyID(h.publicKey),
+		"roots":  roots,
+	})
+}
+
+func (h *AuditHandler) getPublicKey(w http.ResponseWriter, r *http.Request) {
+	der, err := x509.MarshalPKIXPublicKey(h.publicKey)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "public key unavailable")
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	_ = pem.Encode(w, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/experiment"
+	"go.uber.org/zap"
+)
+
+type ExperimentStore interface {
+	Create(ctx context.Context, e experiment.Experiment) (*experiment.Experiment, error)
+	Get(ctx context.Context, id string) (*experiment.Experiment, error)
+	List(ctx context.Context, status experiment.Status) ([]experiment.Experiment, error)
+	Stop(ctx context.Context, id string) (*experiment.Experiment, error)
+	Results(ctx context.Context, id string) ([]experiment.ArmResult, error)
+}
+
+type ExperimentHandler struct {
+	log   *zap.Logger
+	store ExperimentStore
+}
+
+func NewExperimentHandler(log *zap.Logger, store ExperimentStore) *ExperimentHandler {
+	return &ExperimentHandler{log: log, store: store}
+}
+
+func (h *ExperimentHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /experiments", h.list)
+	mux.HandleFunc("POST /experiments", h.create)
+	mux.HandleFunc("GET /experiments/{id}", h.get)
+	mux.HandleFunc("POST /experiments/{id}/stop", h.stop)
+	mux.HandleFunc("GET /experiments/{id}/results", h.results)
+}
+
+func (h *ExperimentHandler) list(w http.ResponseWriter, r *http.Request) {
+	exps, err := h.store.List(r.Context(), experiment.Status(r.URL.Query().Get("status")))
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"experiments": exps})
+}
+
+type experimentRequest struct {
+	Name       string           `json:"name"`
+	MerchantID string           `json:"merchant_id"`
+	BucketBy   string           `json:"bucket_by"`
+	Arms       []experiment.Arm `json:"arms"`
+}
+
+func (h *ExperimentHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req experimentRequest
+	if !decodeStrict(w, r, &req) {
+		return
+	}
+	by, _ := caller(r)
+	e, err := h.store.Create(r.Context(), experiment.Experiment{
+		Name:       req.Name,
+		MerchantID: req.MerchantID,
+		BucketBy:   req.BucketBy,
+		Arms:       req.Arms,
+		CreatedBy:  by,
+	})
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	h.log.Info("experiment started", zap.String("experiment_id", e.ID), zap.String("merchant_id", e.MerchantID), zap.String("by", by))
+	writeJSON(w, http.StatusCreated, e)
+}
+
+func (h *ExperimentHandler) get(w http.ResponseWriter, r *http.Request) {
+	e, err := h.store.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+func (h *ExperimentHandler) stop(w http.ResponseWriter, r *http.Request) {
+	e, err := h.store.Stop(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	by, _ := caller(r)
+	h.log.Info("experiment stopped", zap.String("experiment_id", e.ID), zap.String("by", by))
+	writeJSON(w, http.StatusOK, e)
+}
+
+func (h *ExperimentHandler) results(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	arms, err := h.store.Results(r.Context(), id)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"experiment_id": id,
+		"arms":          arms,
+	})
+}
+
+func (h *ExperimentHandler) fail(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, experiment.ErrInvalidExperiment):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, experiment.ErrExperimentNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, experiment.ErrExperimentConflict), errors.Is(err, experiment.ErrNotActive):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.log.Error("experiment operation failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "experiment operation failed")
+	}
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/app"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+type PaymentCreator interface {
+	CreatePayment(ctx context.Context, req app.CreatePaymentRequest, idempotencyKey string) (*repo.Payment, bool, error)
+}
+
+type IntakeHandler struct {
+	log     *zap.Logger
+	creator PaymentCreator
+}
+
+func NewIntakeHandler(log *zap.Logger, creator PaymentCreator) *IntakeHandler {
+	return &IntakeHandler{log: log, creator: creator}
+}
+
+func (h *IntakeHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /payments", h.create)
+}
+
+func (h *IntakeHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req app.CreatePaymentRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.CorrelationID == "" {
+		req.CorrelationID = r.Header.Get("X-Correlation-ID")
+	}
+
+	p, created, err := h.creator.CreatePayment(r.Context(), req, r.Header.Get("Idempotency-Key"))
+	switch {
+	case errors.Is(err, app.ErrInvalidPayment):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, app.ErrIdempotencyConflict):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		h.log.Error("payment intake failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "payment intake failed")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, map[string]any{
+		"payment_id":     p.ID,
+		"correlation_id": p.CorrelationID,
+		"status":         p.Status,
+	})
+}
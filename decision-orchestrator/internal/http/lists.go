@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/lists"
+	"go.uber.org/zap"
+)
+
+type ListStore interface {
+	Create(ctx context.Context, e lists.Entry) (*lists.Entry, error)
+	Get(ctx context.Context, id string) (*lists.Entry, error)
+	List(ctx context.Context, kind lists.Kind, action lists.Action) ([]lists.Entry, error)
+	Update(ctx context.Context, id string, action lists.Action, reason string, expiresAt *time.Time) (*lists.Entry, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type ListHandler struct {
+	log   *zap.Logger
+	store ListStore
+}
+
+func NewListHandler(log *zap.Logger, store ListStore) *ListHandler {
+	return &ListHandler{log: log, store: store}
+}
+
+func (h *ListHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /lists", h.list)
+	mux.HandleFunc("POST /lists", h.create)
+	mux.HandleFunc("GET /lists/{id}", h.get)
+	mux.HandleFunc("PUT /lists/{id}", h.update)
+	mux.HandleFunc("DELETE /lists/{id}", h.delete)
+}
+
+func (h *ListHandler) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	entries, err := h.store.List(r.Context(), lists.Kind(q.Get("kind")), lists.Action(q.Get("action")))
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"entries": entries})
+}
+
+type listEntryRequest struct {
+	Kind      lists.Kind   `json:"kind"`
+	Value     string       `json:"value"`
+	Action    lists.Action `json:"action"`
+	Reason    string       `json:"reason"`
+	ExpiresAt *time.Time   `json:"expires_at,omitempty"`
+}
+
+func (h *ListHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req listEntryRequest
+	if !decodeStrict(w, r, &req) {
+		return
+	}
+	by, _ := caller(r)
+	e, err := h.store.Create(r.Context(), lists.Entry{
+		Kind:      req.Kind,
+		Value:     req.Value,
+		Action:    req.Action,
+		Reason:    req.Reason,
+		CreatedBy: by,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, e)
+}
+
+func (h *ListHandler) get(w http.ResponseWriter, r *http.Request) {
+	e, err := h.store.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+func (h *ListHandler) update(w http.ResponseWriter, r *http.Request) {
+	var req listEntryRequest
+	if !decodeStrict(w, r, &req) {
+		return
+	}
+	e, err := h.store.Update(r.Context(), r.PathValue("id"), req.Action, req.Reason, req.ExpiresAt)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+func (h *ListHandler) delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.Delete(r.Context(), r.PathValue("id")); err != nil {
+		h.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ListHandler) fail(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, lists.ErrInvalidEntry):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, lists.ErrEntryNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, lists.ErrDuplicateEntry):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.log.Error("list operation failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "list operation failed")
+	}
+}
+
+func decodeStrict(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+type PolicyStore interface {
+	Latest(ctx context.Context, merchantID string) (*repo.MerchantPolicy, error)
+	Versions(ctx context.Context, merchantID string) ([]repo.MerchantPolicy, error)
+	Put(ctx context.Context, p repo.MerchantPolicy) (*repo.MerchantPolicy, error)
+}
+
+type MerchantPolicyHandler struct {
+	log      *zap.Logger
+	policies PolicyStore
+}
+
+func NewMerchantPolicyHandler(log *zap.Logger, policies PolicyStore) *MerchantPolicyHandler {
+	return &MerchantPolicyHandler{log: log, policies: policies}
+}
+
+func (h *MerchantPolicyHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /merchants/{id}/policy", h.get)
+	mux.HandleFunc("GET /merchants/{id}/policy/versions", h.versions)
+	mux.HandleFunc("PUT /merchants/{id}/policy", h.put)
+}
+
+func (h *MerchantPolicyHandler) get(w http.ResponseWriter, r *http.Request) {
+	p, err := h.policies.Latest(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repo.ErrPolicyNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		h.log.Error("merchant policy lookup failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "policy lookup failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (h *MerchantPolicyHandler) versions(w http.ResponseWriter, r *http.Request) {
+	merchantID := r.PathValue("id")
+	policies, err := h.policies.Versions(r.Context(), merchantID)
+	if err != nil {
+		h.log.Error("merchant policy lookup failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "policy lookup failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"merchant_id": merchantID,
+		"versions":    policies,
+	})
+}
+
+type policyRequest struct {
+	ApproveBelow float64 `json:"approve_below"`
+	DeclineFrom  float64 `json:"decline_from"`
+}
+
+func (h *MerchantPolicyHandler) put(w http.ResponseWriter, r *http.Request) {
+	var req policyRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	by, _ := caller(r)
+	p, err := h.policies.Put(r.Context(), repo.MerchantPolicy{
+		MerchantID:   r.PathValue("id"),
+		ApproveBelow: req.ApproveBelow,
+		DeclineFrom:  req.DeclineFrom,
+		CreatedBy:    by,
+	})
+	if errors.Is(err, repo.ErrInvalidPolicy) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		h.log.Error("merchant policy update failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "policy update failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/model"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+type LocalModel interface {
+	Current() (model.File, []string)
+	Score(ctx context.Context, p repo.Payment) (repo.ModelScore, error)
+}
+
+type ModelHandler struct {
+	log   *zap.Logger
+	model LocalModel
+}
+
+func NewModelHandler(log *zap.Logger, m LocalModel) *ModelHandler {
+	return &ModelHandler{log: log, model: m}
+}
+
+func (h *ModelHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /model", h.get)
+	mux.HandleFunc("POST /model/score", h.score)
+}
+
+func (h *ModelHandler) get(w http.ResponseWriter, r *http.Request) {
+	f, features := h.model.Current()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"version":  f.Version,
+		"type":     f.Type,
+		"features": features,
+	})
+}
+
+// score runs the active model on a hypothetical payment without storing
+// anything. Velocity features read the live counters as of created_at.
+func (h *ModelHandler) score(w http.ResponseWriter, r *http.Request) {
+	var p repo.Payment
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now().UTC()
+	}
+
+	ms, err := h.model.Score(r.Context(), p)
+	if err != nil {
+		h.log.Error("model scoring failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "scoring failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, ms)
+}
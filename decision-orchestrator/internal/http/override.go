@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/app"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/auth"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+type OverrideService interface {
+	RequestOverride(ctx context.Context, paymentID, analyst string, req app.OverrideRequest) (*repo.Override, error)
+	ApproveOverride(ctx context.Context, overrideID, approver string) (*repo.Override, error)
+	RejectOverride(ctx context.Context, overrideID, analyst string) (*repo.Override, error)
+}
+
+type OverrideHandler struct {
+	log       *zap.Logger
+	overrides OverrideService
+}
+
+func NewOverrideHandler(log *zap.Logger, overrides OverrideService) *OverrideHandler {
+	return &OverrideHandler{log: log, overrides: overrides}
+}
+
+func (h *OverrideHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /payments/{id}/override", h.request)
+	mux.HandleFunc("POST /overrides/{id}/approve", h.approve)
+	mux.HandleFunc("POST /overrides/{id}/reject", h.reject)
+}
+
+func (h *OverrideHandler) request(w http.ResponseWriter, r *http.Request) {
+	analyst, ok := caller(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "analyst identity required")
+		return
+	}
+
+	var req app.OverrideRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ov, err := h.overrides.RequestOverride(r.Context(), r.PathValue("id"), analyst, req)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	status := http.StatusOK
+	if ov.State == repo.OverridePendingApproval {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, ov)
+}
+
+func (h *OverrideHandler) approve(w http.ResponseWriter, r *http.Request) {
+	h.resolve(w, r, h.overrides.ApproveOverride)
+}
+
+func (h *OverrideHandler) reject(w http.ResponseWriter, r *http.Request) {
+	h.resolve(w, r, h.overrides.RejectOverride)
+}
+
+func (h *OverrideHandler) resolve(w http.ResponseWriter, r *http.Request, fn func(context.Context, string, string) (*repo.Override, error)) {
+	analyst, ok := caller(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "analyst identity required")
+		return
+	}
+	ov, err := fn(r.Context(), r.PathValue("id"), analyst)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ov)
+}
+
+func (h *OverrideHandler) fail(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrInvalidOverride):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, app.ErrSelfApproval):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, repo.ErrPaymentNotFound), errors.Is(err, repo.ErrOverrideNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, app.ErrTransitionRejected),
+		errors.Is(err, repo.ErrOverrideInProgress),
+		errors.Is(err, repo.ErrOverrideResolved),
+		errors.Is(err, repo.ErrStaleStatus):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.log.Error("override failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "override failed")
+	}
+}
+
+func caller(r *http.Request) (string, bool) {
+	p, ok := auth.FromContext(r.Context())
+	if !ok || p.Subject == "" {
+		return "", false
+	}
+	return p.Subject, true
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+type PaymentReader interface {
+	Get(ctx context.Context, id string) (*repo.Payment, error)
+	GetByCorrelationID(ctx context.Context, correlationID string) (*repo.Payment, error)
+	List(ctx context.Context, f repo.PaymentFilter, cursor string, limit int) ([]repo.Payment, string, error)
+}
+
+type PaymentHandler struct {
+	log      *zap.Logger
+	payments PaymentReader
+}
+
+func NewPaymentHandler(log *zap.Logger, payments PaymentReader) *PaymentHandler {
+	return &PaymentHandler{log: log, payments: payments}
+}
+
+func (h *PaymentHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /payments", h.list)
+	mux.HandleFunc("GET /payments/{id}", h.get)
+	mux.HandleFunc("GET /correlations/{correlationID}/payment", h.getByCorrelation)
+}
+
+func (h *PaymentHandler) get(w http.ResponseWriter, r *http.Request) {
+	p, err := h.payments.Get(r.Context(), r.PathValue("id"))
+	h.writePayment(w, p, err)
+}
+
+func (h *PaymentHandler) getByCorrelation(w http.ResponseWriter, r *http.Request) {
+	p, err := h.payments.GetByCorrelationID(r.Context(), r.PathValue("correlationID"))
+	h.writePayment(w, p, err)
+}
+
+func (h *PaymentHandler) writePayment(w http.ResponseWriter, p *repo.Payment, err error) {
+	if errors.Is(err, repo.ErrPaymentNotFound) {
+		writeError(w, http.StatusNotFound, "payment not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("payment lookup failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "lookup failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (h *PaymentHandler) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := repo.PaymentFilter{
+		MerchantID: q.Get("merchant_id"),
+		UserID:     q.Get("user_id"),
+		Status:     repo.PaymentStatus(q.Get("status")),
+	}
+
+	var err error
+	if filter.From, err = parseTime(q.Get("from")); err != nil {
+		writeError(w, http.StatusBadRequest, "from must be RFC3339")
+		return
+	}
+	if filter.To, err = parseTime(q.Get("to")); err != nil {
+		writeError(w, http.StatusBadRequest, "to must be RFC3339")
+		return
+	}
+
+	limit := defaultPageSize
+	if raw := q.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxPageSize {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxPageSize))
+			return
+		}
+	}
+
+	payments, next, err := h.payments.List(r.Context(), filter, q.Get("cursor"), limit)
+	if errors.Is(err, repo.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, "invalid cursor")
+		return
+	}
+	if err != nil {
+		h.log.Error("payment list failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "list failed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"payments":    payments,
+		"next_cursor": next,
+	})
+}
+
+func parseTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
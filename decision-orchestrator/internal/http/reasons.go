@@ -0,0 +1,33 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/reasons"
+)
+
+type ReasonCodeHandler struct {
+	registry *reasons.Registry
+}
+
+func NewReasonCodeHandler(registry *reasons.Registry) *ReasonCodeHandler {
+	return &ReasonCodeHandler{registry: registry}
+}
+
+func (h *ReasonCodeHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /reason-codes", h.list)
+	mux.HandleFunc("GET /reason-codes/{code}", h.get)
+}
+
+func (h *ReasonCodeHandler) list(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"codes": h.registry.All()})
+}
+
+func (h *ReasonCodeHandler) get(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.registry.Lookup(r.PathValue("code"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "reason code not registered")
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/app"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/audit"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+type RefundService interface {
+	RecordRefund(ctx context.Context, paymentID string, actor audit.Actor, actorID string, req app.RefundRequest) (*repo.Payment, bool, error)
+}
+
+type RefundHandler struct {
+	log     *zap.Logger
+	refunds RefundService
+}
+
+func NewRefundHandler(log *zap.Logger, refunds RefundService) *RefundHandler {
+	return &RefundHandler{log: log, refunds: refunds}
+}
+
+func (h *RefundHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /payments/{id}/refunds", h.create)
+}
+
+func (h *RefundHandler) create(w http.ResponseWriter, r *http.Request) {
+	analyst, ok := caller(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "analyst identity required")
+		return
+	}
+
+	var req app.RefundRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	p, added, err := h.refunds.RecordRefund(r.Context(), r.PathValue("id"), audit.ActorAnalyst, analyst, req)
+	switch {
+	case errors.Is(err, repo.ErrPaymentNotFound):
+		writeError(w, http.StatusNotFound, "payment not found")
+		return
+	case errors.Is(err, repo.ErrInvalidRefund):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, repo.ErrRefundNotAllowed), errors.Is(err, repo.ErrRefundConflict):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		h.log.Error("refund failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "refund failed")
+		return
+	}
+	status := http.StatusOK
+	if added {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, p)
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/feedback"
+	"go.uber.org/zap"
+)
+
+type QualityReporter interface {
+	Quality(ctx context.Context, groupBy string, from, to time.Time) (feedback.QualityReport, error)
+}
+
+type ReportHandler struct {
+	log     *zap.Logger
+	reports QualityReporter
+}
+
+func NewReportHandler(log *zap.Logger, reports QualityReporter) *ReportHandler {
+	return &ReportHandler{log: log, reports: reports}
+}
+
+func (h *ReportHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /reports/decision-quality", h.quality)
+}
+
+func (h *ReportHandler) quality(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, err := parseTime(q.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "from must be RFC3339")
+		return
+	}
+	to, err := parseTime(q.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "to must be RFC3339")
+		return
+	}
+	groupBy := q.Get("group_by")
+	if groupBy == "" {
+		groupBy = feedback.GroupByScoreBucket
+	}
+
+	report, err := h.reports.Quality(r.Context(), groupBy, from, to)
+	if errors.Is(err, feedback.ErrInvalidGrouping) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		h.log.Error("decision quality report failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "report failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
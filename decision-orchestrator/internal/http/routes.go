@@ -0,0 +1,49 @@
+package http
+
+import "github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/auth"
+
+// RoutePolicy is the least role needed for each route. Routes missing here
+// are admin only.
+var RoutePolicy = auth.Policy{
+	"/healthz": auth.RolePublic,
+	"/readyz":  auth.RolePublic,
+
+	"GET /payments":      auth.RoleViewer,
+	"GET /payments/{id}": auth.RoleViewer,
+	"GET /correlations/{correlationID}/payment": auth.RoleViewer,
+	"GET /payments/{id}/decisions":              auth.RoleViewer,
+	"GET /audit/roots":                          auth.RoleViewer,
+	"GET /merchants/{id}/policy":                auth.RoleViewer,
+	"GET /merchants/{id}/policy/versions":       auth.RoleViewer,
+	"GET /lists":                                auth.RoleViewer,
+	"GET /lists/{id}":                           auth.RoleViewer,
+	"GET /rules":                                auth.RoleViewer,
+	"GET /users/{id}/velocity":                  auth.RoleViewer,
+	"GET /merchants/{id}/velocity":              auth.RoleViewer,
+	"GET /shadow/stats":                         auth.RoleViewer,
+	"GET /shadow/divergences":                   auth.RoleViewer,
+	"GET /payments/{id}/shadow":                 auth.RoleViewer,
+	"GET /experiments":                          auth.RoleViewer,
+	"GET /experiments/{id}":                     auth.RoleViewer,
+	"GET /experiments/{id}/results":             auth.RoleViewer,
+	"GET /reason-codes":                         auth.RoleViewer,
+	"GET /reason-codes/{code}":                  auth.RoleViewer,
+	"GET /reports/decision-quality":             auth.RoleViewer,
+	"GET /model":                                auth.RoleViewer,
+	"GET /audit/public-key":                     auth.RolePublic,
+
+	"POST /payments":               auth.RoleAnalyst,
+	"POST /payments/{id}/override": auth.RoleAnalyst,
+	"POST /payments/{id}/refunds":  auth.RoleAnalyst,
+	"POST /overrides/{id}/approve": auth.RoleAnalyst,
+	"POST /overrides/{id}/reject":  auth.RoleAnalyst,
+	"POST /rules/dry-run":          auth.RoleAnalyst,
+	"POST /model/score":            auth.RoleAnalyst,
+	"POST /lists":                  auth.RoleAnalyst,
+	"PUT /lists/{id}":              auth.RoleAnalyst,
+	"DELETE /lists/{id}":           auth.RoleAnalyst,
+	"PUT /rules":                   auth.RoleAdmin,
+	"PUT /merchants/{id}/policy":   auth.RoleAdmin,
+	"POST /experiments":            auth.RoleAdmin,
+	"POST /experiments/{id}/stop":  auth.RoleAdmin,
+}
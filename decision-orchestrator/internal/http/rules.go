@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/rules"
+	"go.uber.org/zap"
+)
+
+type RuleSet interface {
+	Current() (rules.File, string)
+	Hits() map[string]int64
+	DryRun(ctx context.Context, p repo.Payment, proposed *rules.File) (rules.Decision, *rules.Decision, error)
+	Save(ctx context.Context, f rules.File, by string) error
+}
+
+type RulesHandler struct {
+	log   *zap.Logger
+	rules RuleSet
+}
+
+func NewRulesHandler(log *zap.Logger, rules RuleSet) *RulesHandler {
+	return &RulesHandler{log: log, rules: rules}
+}
+
+func (h *RulesHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /rules", h.get)
+	mux.HandleFunc("PUT /rules", h.put)
+	mux.HandleFunc("POST /rules/dry-run", h.dryRun)
+}
+
+func (h *RulesHandler) get(w http.ResponseWriter, r *http.Request) {
+	f, version := h.rules.Current()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"version": version,
+		"rules":   f,
+		"hits":    h.rules.Hits(),
+	})
+}
+
+func (h *RulesHandler) put(w http.ResponseWriter, r *http.Request) {
+	var f rules.File
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	by, _ := caller(r)
+	err := h.rules.Save(r.Context(), f, by)
+	switch {
+	case errors.Is(err, rules.ErrInvalidRules):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, rules.ErrReadOnlySource):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		h.log.Error("rule save failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "rule save failed")
+		return
+	}
+	h.get(w, r)
+}
+
+type dryRunRequest struct {
+	Payment  repo.Payment `json:"payment"`
+	Proposed *rules.File  `json:"proposed,omitempty"`
+}
+
+func (h *RulesHandler) dryRun(w http.ResponseWriter, r *http.Request) {
+	var req dryRunRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Payment.CreatedAt.IsZero() {
+		req.Payment.CreatedAt = time.Now().UTC()
+	}
+
+	current, proposed, err := h.rules.DryRun(r.Context(), req.Payment, req.Proposed)
+	if errors.Is(err, rules.ErrNoRuleSet) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if errors.Is(err, rules.ErrInvalidRules) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		h.log.Error("rule dry run failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "dry run failed")
+		return
+	}
+
+	resp := map[string]any{"current": current}
+	if proposed != nil {
+		resp["proposed"] = proposed
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
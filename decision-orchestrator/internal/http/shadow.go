@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+)
+
+type ShadowReader interface {
+	Get(ctx context.Context, paymentID string) (*repo.ShadowComparison, error)
+	Stats(ctx context.Context, merchantID string, from, to time.Time) (repo.ShadowStats, error)
+	Divergences(ctx context.Context, merchantID, cursor string, limit int) ([]repo.ShadowComparison, string, error)
+}
+
+type ShadowHandler struct {
+	log    *zap.Logger
+	shadow ShadowReader
+}
+
+func NewShadowHandler(log *zap.Logger, shadow ShadowReader) *ShadowHandler {
+	return &ShadowHandler{log: log, shadow: shadow}
+}
+
+func (h *ShadowHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /shadow/stats", h.stats)
+	mux.HandleFunc("GET /shadow/divergences", h.divergences)
+	mux.HandleFunc("GET /payments/{id}/shadow", h.get)
+}
+
+func (h *ShadowHandler) get(w http.ResponseWriter, r *http.Request) {
+	c, err := h.shadow.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		writeError(w, http.StatusNotFound, "no shadow comparison for payment")
+		return
+	}
+	if err != nil {
+		h.log.Error("shadow comparison lookup failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "lookup failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (h *ShadowHandler) stats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, err := parseTime(q.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "from must be RFC3339")
+		return
+	}
+	to, err := parseTime(q.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "to must be RFC3339")
+		return
+	}
+
+	stats, err := h.shadow.Stats(r.Context(), q.Get("merchant_id"), from, to)
+	if err != nil {
+		h.log.Error("shadow stats failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "stats failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (h *ShadowHandler) divergences(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := defaultPageSize
+	if raw := q.Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxPageSize {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxPageSize))
+			return
+		}
+	}
+
+	items, next, err := h.shadow.Divergences(r.Context(), q.Get("merchant_id"), q.Get("cursor"), limit)
+	if errors.Is(err, repo.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, "invalid cursor")
+		return
+	}
+	if err != nil {
+		h.log.Error("shadow divergence list failed", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "list failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"divergences": items,
+		"next_cursor": next,
+	})
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/velocity"
+	"go.uber.org/zap"
+)
+
+type VelocityReader interface {
+	Totals(ctx context.Context, kind velocity.Kind, id string, window time.Duration, at time.Time) (map[string]velocity.Totals, error)
+}
+
+type VelocityHandler struct {
+	log      *zap.Logger
+	counters VelocityReader
+}
+
+func NewVelocityHandler(log *zap.Logger, counters VelocityReader) *VelocityHandler {
+	return &VelocityHandler{log: log, counters: counters}
+}
+
+func (h *VelocityHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /users/{id}/velocity", h.get(velocity.KindUser))
+	mux.HandleFunc("GET /merchants/{id}/velocity", h.get(velocity.KindMerchant))
+}
+
+func (h *VelocityHandler) get(kind velocity.Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		now := time.Now().UTC()
+
+		windows := map[string]map[string]velocity.Totals{}
+		for _, window := range velocity.Windows {
+			totals, err := h.counters.Totals(r.Context(), kind, id, window, now)
+			if err != nil {
+				h.log.Error("velocity lookup failed", zap.Error(err), zap.String("kind", string(kind)), zap.String("id", id))
+				writeError(w, http.StatusInternalServerError, "velocity lookup failed")
+				return
+			}
+			windows[formatWindow(window)] = totals
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"kind":    kind,
+			"id":      id,
+			"as_of":   now,
+			"windows": windows,
+		})
+	}
+}
+
+// formatWindow renders windows the way they are usually written: 1m, 1h, 24h.
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	}
+	return d.String()
+}
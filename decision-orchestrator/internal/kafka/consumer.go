@@ -4,7 +4,9 @@ import (
 	"context"
 	"time"
 
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/codec"
 	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
 )
 
 type Handler func(ctx context.Context, msg kafka.Message) error
@@ -12,6 +14,8 @@ type Handler func(ctx context.Context, msg kafka.Message) error
 type Consumer struct {
 	r       *kafka.Reader
 	handler Handler
+	codecs  *codec.Topics
+	log     *zap.Logger
 }
 
 func NewConsumer(brokers []string, groupID, topic string, handler Handler) *Consumer {
@@ -24,7 +28,20 @@ func NewConsumer(brokers []string, groupID, topic string, handler Handler) *Cons
 		StartOffset:    kafka.LastOffset,
 		CommitInterval: time.Second,
 	})
-	return &Consumer{r: r, handler: handler}
+	return &Consumer{r: r, handler: handler, log: zap.NewNop()}
+}
+
+// WithCodecs decodes message values into JSON before they reach the handler.
+func (c *Consumer) WithCodecs(codecs *codec.Topics) *Consumer {
+	c.codecs = codecs
+	return c
+}
+
+// WithLogger reports messages that cannot be decoded. They are committed
+// past so one bad message does not stall the partition.
+func (c *Consumer) WithLogger(log *zap.Logger) *Consumer {
+	c.log = log
+	return c
 }
 
 func (c *Consumer) Run(ctx context.Context) error {
@@ -34,6 +51,19 @@ func (c *Consumer) Run(ctx context.Context) error {
 			return err
 		}
 
+		if c.codecs != nil {
+			decoded, err := c.decode(msg)
+			if err != nil {
+				c.log.Error("undecodable message skipped", zap.Error(err),
+					zap.String("topic", msg.Topic), zap.Int("partition", msg.Partition), zap.Int64("offset", msg.Offset))
+				if err := c.r.CommitMessages(ctx, msg); err != nil {
+					return err
+				}
+				continue
+			}
+			msg = decoded
+		}
+
 		if err := c.handler(ctx, msg); err != nil {
 			continue
 		}
@@ -47,3 +77,21 @@ func (c *Consumer) Run(ctx context.Context) error {
 func (c *Consumer) Close() error {
 	return c.r.Close()
 }
+
+func (c *Consumer) decode(msg kafka.Message) (kafka.Message, error) {
+	value, err := c.codecs.Decode(msg.Topic, msg.Headers, msg.Value)
+	if err != nil {
+		return msg, err
+	}
+
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for _, h := range msg.Headers {
+		if h.Key == codec.SchemaIDHeader || h.Key == "content-type" {
+			continue
+		}
+		headers = append(headers, h)
+	}
+	msg.Value = value
+	msg.Headers = append(headers, kafka.Header{Key: "content-type", Value: []byte("application/json")})
+	return msg, nil
+}
@@ -0,0 +1,125 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// Elector runs a function only while this replica holds the named lease. The
+// function's context is cancelled as soon as a renewal fails.
+type Elector struct {
+	log   *zap.Logger
+	repo  *LeaseRepo
+	name  string
+	retry time.Duration
+}
+
+func NewElector(l *zap.Logger, repo *LeaseRepo, name string) *Elector {
+	return &Elector{log: l, repo: repo, name: name, retry: repo.ttl / 2}
+}
+
+func (e *Elector) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	for {
+		l, err := e.repo.Acquire(ctx, e.name)
+		switch {
+		case err == nil:
+			e.log.Info("lease acquired", zap.String("lease", e.name), zap.String("holder", e.repo.holder), zap.Int64("token", l.Token))
+			e.lead(ctx, l, fn)
+		case errors.Is(err, ErrNotAcquired):
+		default:
+			e.log.Warn("lease acquire failed", zap.String("lease", e.name), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.retry):
+		}
+	}
+}
+
+func (e *Elector) lead(ctx context.Context, l Lease, fn func(ctx context.Context) error) {
+	leaderCtx, cancel := context.WithCancel(WithLease(ctx, e.repo, l))
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(leaderCtx) }()
+
+	ticker := time.NewTicker(e.repo.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				e.log.Error("leader job failed", zap.String("lease", e.name), zap.Error(err))
+			}
+			if relErr := e.repo.Release(context.Background(), l); relErr != nil {
+				e.log.Warn("lease release failed", zap.String("lease", e.name), zap.Error(relErr))
+			}
+			return
+		case <-ticker.C:
+			renewed, err := e.repo.Renew(ctx, l)
+			if err != nil {
+				e.log.Warn("lease lost", zap.String("lease", e.name), zap.Error(err))
+				cancel()
+				<-done
+				return
+			}
+			l = renewed
+		}
+	}
+}
+
+type leaseKey struct{}
+
+type held struct {
+	repo  *LeaseRepo
+	lease Lease
+}
+
+func WithLease(ctx context.Context, repo *LeaseRepo, l Lease) context.Context {
+	return context.WithValue(ctx, leaseKey{}, held{repo: repo, lease: l})
+}
+
+// Token returns the fencing token of the lease carried by ctx, if any.
+func Token(ctx context.Context) (int64, bool) {
+	h, ok := ctx.Value(leaseKey{}).(held)
+	return h.lease.Token, ok
+}
+
+// Check fails when the lease in ctx has been lost or taken over by a newer
+// term. It lets leader-only jobs stop early; a leader can still pause after
+// Check, so the writes themselves are guarded with Fence. Work not running
+// under a lease is always allowed.
+func Check(ctx context.Context) error {
+	h, ok := ctx.Value(leaseKey{}).(held)
+	if !ok {
+		return nil
+	}
+	return h.repo.Validate(ctx, h.lease)
+}
+
+// Fence guards an update of a single document made under the lease in ctx.
+// The document remembers the highest token that wrote it per lease, and the
+// update only matches while ctx's token is not lower, so a leader that was
+// paused past its term cannot overwrite what a newer term wrote. Without a
+// lease in ctx the update is left alone.
+func Fence(ctx context.Context, filter, update bson.M) {
+	h, ok := ctx.Value(leaseKey{}).(held)
+	if !ok {
+		return
+	}
+	field := "fencing." + h.lease.Name
+	filter[field] = bson.M{"$not": bson.M{"$gt": h.lease.Token}}
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set[field] = h.lease.Token
+}
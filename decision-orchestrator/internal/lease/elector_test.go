@@ -0,0 +1,48 @@
+package lease
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestFence(t *testing.T) {
+	ctx := WithLease(context.Background(), nil, Lease{Name: "timeout-sweeper", Token: 7})
+
+	filter := bson.M{"_id": "p-1", "status": "PENDING"}
+	update := bson.M{"$set": bson.M{"status": "DECLINED"}, "$inc": bson.M{"version": 1}}
+	Fence(ctx, filter, update)
+
+	wantFilter := bson.M{
+		"_id":                     "p-1",
+		"status":                  "PENDING",
+		"fencing.timeout-sweeper": bson.M{"$not": bson.M{"$gt": int64(7)}},
+	}
+	if !reflect.DeepEqual(filter, wantFilter) {
+		t.Errorf("filter = %v, want %v", filter, wantFilter)
+	}
+	wantSet := bson.M{"status": "DECLINED", "fencing.timeout-sweeper": int64(7)}
+	if !reflect.DeepEqual(update["$set"], wantSet) {
+		t.Errorf("$set = %v, want %v", update["$set"], wantSet)
+	}
+}
+
+func TestFenceAddsSetWhenMissing(t *testing.T) {
+	ctx := WithLease(context.Background(), nil, Lease{Name: "relay", Token: 2})
+	update := bson.M{"$inc": bson.M{"version": 1}}
+	Fence(ctx, bson.M{}, update)
+	if got := update["$set"]; !reflect.DeepEqual(got, bson.M{"fencing.relay": int64(2)}) {
+		t.Errorf("$set = %v", got)
+	}
+}
+
+func TestFenceWithoutLease(t *testing.T) {
+	filter := bson.M{"_id": "p-1"}
+	update := bson.M{"$set": bson.M{"status": "DECLINED"}}
+	Fence(context.Background(), filter, update)
+	if len(filter) != 1 || len(update["$set"].(bson.M)) != 1 {
+		t.Errorf("fence changed a write made without a lease: %v %v", filter, update)
+	}
+}
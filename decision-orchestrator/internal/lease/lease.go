@@ -0,0 +1,117 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrNotAcquired = errors.New("lease held by another holder")
+	ErrLost        = errors.New("lease lost")
+)
+
+type Lease struct {
+	Name       string    `bson:"_id"`
+	Holder     string    `bson:"holder"`
+	Token      int64     `bson:"token"`
+	ExpiresAt  time.Time `bson:"expires_at"`
+	AcquiredAt time.Time `bson:"acquired_at"`
+}
+
+type LeaseRepo struct {
+	col    *mongo.Collection
+	holder string
+	ttl    time.Duration
+}
+
+func NewLeaseRepo(db *mongo.Database, holder string, ttl time.Duration) *LeaseRepo {
+	return &LeaseRepo{col: db.Collection("leases"), holder: holder, ttl: ttl}
+}
+
+func (r *LeaseRepo) Holder() string {
+	return r.holder
+}
+
+// Acquire takes the named lease if it is free or expired. Every successful
+// acquisition starts a new term with a higher fencing token; re-acquiring a
+// lease this holder already owns keeps its token.
+func (r *LeaseRepo) Acquire(ctx context.Context, name string) (Lease, error) {
+	now := time.Now()
+
+	if l, err := r.extend(ctx, bson.M{"_id": name, "holder": r.holder, "expires_at": bson.M{"$gt": now}}); err == nil {
+		return l, nil
+	} else if !errors.Is(err, ErrLost) {
+		return Lease{}, err
+	}
+
+	filter := bson.M{"_id": name, "expires_at": bson.M{"$lte": now}}
+	update := bson.M{
+		"$set": bson.M{
+			"holder":      r.holder,
+			"expires_at":  now.Add(r.ttl),
+			"acquired_at": now,
+		},
+		"$inc": bson.M{"token": 1},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var l Lease
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&l)
+	if mongo.IsDuplicateKeyError(err) {
+		// the lease exists and has not expired
+		return Lease{}, ErrNotAcquired
+	}
+	if err != nil {
+		return Lease{}, err
+	}
+	return l, nil
+}
+
+// Renew pushes the expiry of a lease this holder still owns in the same term.
+func (r *LeaseRepo) Renew(ctx context.Context, l Lease) (Lease, error) {
+	return r.extend(ctx, bson.M{"_id": l.Name, "holder": r.holder, "token": l.Token})
+}
+
+func (r *LeaseRepo) Release(ctx context.Context, l Lease) error {
+	filter := bson.M{"_id": l.Name, "holder": r.holder, "token": l.Token}
+	_, err := r.col.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"expires_at": time.Now()}})
+	return err
+}
+
+// Validate confirms the lease is still held in the same term and unexpired.
+func (r *LeaseRepo) Validate(ctx context.Context, l Lease) error {
+	filter := bson.M{
+		"_id":        l.Name,
+		"holder":     r.holder,
+		"token":      l.Token,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	n, err := r.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLost
+	}
+	return nil
+}
+
+func (r *LeaseRepo) extend(ctx context.Context, filter bson.M) (Lease, error) {
+	update := bson.M{"$set": bson.M{"expires_at": time.Now().Add(r.ttl)}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var l Lease
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&l)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Lease{}, ErrLost
+	}
+	if err != nil {
+		return Lease{}, err
+	}
+	return l, nil
+}
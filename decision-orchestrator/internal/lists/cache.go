@@ -0,0 +1,172 @@
+package lists
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+// entrySource is where the cache reads entries from: the list collection in
+// production.
+type entrySource interface {
+	List(ctx context.Context, kind Kind, action Action) ([]Entry, error)
+	watch(ctx context.Context) (changeStream, error)
+}
+
+// changeStream is the part of *mongo.ChangeStream the cache uses.
+type changeStream interface {
+	Next(ctx context.Context) bool
+	Decode(v any) error
+	Err() error
+	Close(ctx context.Context) error
+}
+
+type key struct {
+	kind  Kind
+	value string
+}
+
+// Cache holds every list entry in memory so lookups on the decision path do
+// not touch Mongo. It follows the collection through a change stream and
+// falls back to periodic full reloads where change streams are unavailable
+// (standalone servers).
+type Cache struct {
+	log      *zap.Logger
+	source   entrySource
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	entries map[key]Entry
+	byID    map[string]key
+}
+
+func NewCache(l *zap.Logger, repo *ListRepo, reloadInterval time.Duration) *Cache {
+	return newCache(l, repo, reloadInterval)
+}
+
+func newCache(l *zap.Logger, source entrySource, reloadInterval time.Duration) *Cache {
+	return &Cache{log: l, source: source, interval: reloadInterval, now: time.Now, entries: map[key]Entry{}, byID: map[string]key{}}
+}
+
+func (c *Cache) Load(ctx context.Context) error {
+	all, err := c.source.List(ctx, "", "")
+	if err != nil {
+		return err
+	}
+	entries := make(map[key]Entry, len(all))
+	byID := make(map[string]key, len(all))
+	for _, e := range all {
+		k := key{e.Kind, e.Value}
+		entries[k] = e
+		byID[e.ID] = k
+	}
+
+	c.mu.Lock()
+	c.entries, c.byID = entries, byID
+	c.mu.Unlock()
+	return nil
+}
+
+// Run keeps the cache current until ctx is done.
+func (c *Cache) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := c.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		c.log.Warn("list change stream unavailable, polling instead", zap.Error(err), zap.Duration("interval", c.interval))
+		c.poll(ctx)
+	}
+}
+
+type changeEvent struct {
+	OperationType string `bson:"operationType"`
+	FullDocument  *Entry `bson:"fullDocument"`
+	DocumentKey   struct {
+		ID string `bson:"_id"`
+	} `bson:"documentKey"`
+}
+
+func (c *Cache) watch(ctx context.Context) error {
+	stream, err := c.source.watch(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	// reload after the stream is open so nothing written in between is missed
+	if err := c.Load(ctx); err != nil {
+		return err
+	}
+	for stream.Next(ctx) {
+		var ev changeEvent
+		if err := stream.Decode(&ev); err != nil {
+			return err
+		}
+		c.apply(ev)
+	}
+	return stream.Err()
+}
+
+func (c *Cache) apply(ev changeEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.byID[ev.DocumentKey.ID]; ok {
+		delete(c.entries, old)
+		delete(c.byID, ev.DocumentKey.ID)
+	}
+	if ev.OperationType != "delete" && ev.FullDocument != nil {
+		k := key{ev.FullDocument.Kind, ev.FullDocument.Value}
+		c.entries[k] = *ev.FullDocument
+		c.byID[ev.FullDocument.ID] = k
+	}
+}
+
+// poll reloads on an interval for one stream retry period, then returns so
+// Run can try the change stream again.
+func (c *Cache) poll(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for i := 0; i < 10; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Load(ctx); err != nil {
+				c.log.Error("list reload failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Match returns the entry that decides p, if any. Deny entries win over
+// allow entries so a trusted merchant cannot shield a known fraudster.
+func (c *Cache) Match(p repo.Payment) (*Entry, bool) {
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var allow *Entry
+	for _, k := range []key{{KindUser, p.UserID}, {KindMerchant, p.MerchantID}, {KindInstrument, p.InstrumentFingerprint}} {
+		if k.value == "" {
+			continue
+		}
+		e, ok := c.entries[k]
+		if !ok || e.expired(now) {
+			continue
+		}
+		if e.Action == ActionDeny {
+			return &e, true
+		}
+		if allow == nil {
+			allow = &e
+		}
+	}
+	return allow, allow != nil
+}
@@ -0,0 +1,211 @@
+package lists
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+type fakeSource struct {
+	mu       sync.Mutex
+	entries  []Entry
+	watchErr error
+	stream   *fakeStream
+	watches  int
+}
+
+func (s *fakeSource) List(context.Context, Kind, Action) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry{}, s.entries...), nil
+}
+
+func (s *fakeSource) watch(context.Context) (changeStream, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watches++
+	if s.watchErr != nil {
+		return nil, s.watchErr
+	}
+	return s.stream, nil
+}
+
+func (s *fakeSource) set(entries ...Entry) {
+	s.mu.Lock()
+	s.entries = entries
+	s.mu.Unlock()
+}
+
+type fakeStream struct {
+	events chan changeEvent
+	cur    changeEvent
+}
+
+func (s *fakeStream) Next(ctx context.Context) bool {
+	select {
+	case ev := <-s.events:
+		s.cur = ev
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *fakeStream) Decode(v any) error {
+	*v.(*changeEvent) = s.cur
+	return nil
+}
+
+func (s *fakeStream) Err() error                  { return nil }
+func (s *fakeStream) Close(context.Context) error { return nil }
+
+func change(op string, e Entry) changeEvent {
+	ev := changeEvent{OperationType: op}
+	ev.DocumentKey.ID = e.ID
+	if op != "delete" {
+		ev.FullDocument = &e
+	}
+	return ev
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func matchedID(c *Cache, p repo.Payment) string {
+	e, ok := c.Match(p)
+	if !ok {
+		return ""
+	}
+	return e.ID
+}
+
+func TestCacheMatch(t *testing.T) {
+	now := time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	src := &fakeSource{entries: []Entry{
+		{ID: "allow-user", Kind: KindUser, Value: "u-trusted", Action: ActionAllow},
+		{ID: "deny-user", Kind: KindUser, Value: "u-fraud", Action: ActionDeny},
+		{ID: "allow-merchant", Kind: KindMerchant, Value: "m-partner", Action: ActionAllow},
+		{ID: "deny-merchant", Kind: KindMerchant, Value: "m-bad", Action: ActionDeny},
+		{ID: "deny-card", Kind: KindInstrument, Value: "fp-stolen", Action: ActionDeny},
+		{ID: "expired-deny", Kind: KindInstrument, Value: "fp-recovered", Action: ActionDeny, ExpiresAt: &past},
+		{ID: "expires-now", Kind: KindUser, Value: "u-paroled", Action: ActionDeny, ExpiresAt: &now},
+		{ID: "still-denied", Kind: KindUser, Value: "u-suspended", Action: ActionDeny, ExpiresAt: &future},
+		// a value nobody sends; must not match payments without an instrument
+		{ID: "empty-card", Kind: KindInstrument, Value: "", Action: ActionDeny},
+	}}
+	c := newCache(zap.NewNop(), src, time.Minute)
+	c.now = func() time.Time { return now }
+	if err := c.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		payment repo.Payment
+		want    string
+	}{
+		{"nothing listed", repo.Payment{UserID: "u-1", MerchantID: "m-1", InstrumentFingerprint: "fp-1"}, ""},
+		{"allowed user", repo.Payment{UserID: "u-trusted", MerchantID: "m-1"}, "allow-user"},
+		{"allowed merchant", repo.Payment{UserID: "u-1", MerchantID: "m-partner"}, "allow-merchant"},
+		{"first allow wins among allows", repo.Payment{UserID: "u-trusted", MerchantID: "m-partner"}, "allow-user"},
+		{"deny user beats allowed merchant", repo.Payment{UserID: "u-fraud", MerchantID: "m-partner"}, "deny-user"},
+		{"deny merchant beats allowed user", repo.Payment{UserID: "u-trusted", MerchantID: "m-bad"}, "deny-merchant"},
+		{"deny instrument beats allowed user and merchant", repo.Payment{UserID: "u-trusted", MerchantID: "m-partner", InstrumentFingerprint: "fp-stolen"}, "deny-card"},
+		{"expired deny no longer shields", repo.Payment{UserID: "u-trusted", InstrumentFingerprint: "fp-recovered"}, "allow-user"},
+		{"expired deny alone", repo.Payment{UserID: "u-1", InstrumentFingerprint: "fp-recovered"}, ""},
+		{"expiry is exclusive", repo.Payment{UserID: "u-paroled"}, ""},
+		{"not yet expired", repo.Payment{UserID: "u-suspended", MerchantID: "m-partner"}, "still-denied"},
+		{"empty values are skipped", repo.Payment{UserID: "u-1", MerchantID: "m-1"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchedID(c, tt.payment); got != tt.want {
+				t.Errorf("Match = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheApply(t *testing.T) {
+	c := newCache(zap.NewNop(), &fakeSource{}, time.Minute)
+	p := repo.Payment{UserID: "u-1", MerchantID: "m-1"}
+
+	c.apply(change("insert", Entry{ID: "e1", Kind: KindUser, Value: "u-1", Action: ActionAllow}))
+	if got := matchedID(c, p); got != "e1" {
+		t.Fatalf("after insert Match = %q, want e1", got)
+	}
+	// an update may move the entry to another value; the old one must go
+	c.apply(change("update", Entry{ID: "e1", Kind: KindMerchant, Value: "m-2", Action: ActionDeny}))
+	if got := matchedID(c, p); got != "" {
+		t.Fatalf("after moving the entry Match = %q, want nothing", got)
+	}
+	if got := matchedID(c, repo.Payment{MerchantID: "m-2"}); got != "e1" {
+		t.Fatalf("Match on the new value = %q, want e1", got)
+	}
+	c.apply(change("delete", Entry{ID: "e1"}))
+	if got := matchedID(c, repo.Payment{MerchantID: "m-2"}); got != "" {
+		t.Errorf("after delete Match = %q, want nothing", got)
+	}
+}
+
+func TestCacheRunFollowsChangeStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src := &fakeSource{
+		entries: []Entry{{ID: "e1", Kind: KindUser, Value: "u-1", Action: ActionAllow}},
+		stream:  &fakeStream{events: make(chan changeEvent)},
+	}
+	c := newCache(zap.NewNop(), src, time.Hour)
+	go c.Run(ctx)
+
+	// the stream reloads once it is open
+	waitFor(t, "the initial load", func() bool { return matchedID(c, repo.Payment{UserID: "u-1"}) == "e1" })
+	src.stream.events <- change("insert", Entry{ID: "e2", Kind: KindUser, Value: "u-1", Action: ActionDeny})
+	waitFor(t, "the streamed deny", func() bool { return matchedID(c, repo.Payment{UserID: "u-1"}) == "e2" })
+}
+
+func TestCacheRunPollsWithoutChangeStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src := &fakeSource{watchErr: errors.New("The $changeStream stage is only supported on replica sets")}
+	c := newCache(zap.NewNop(), src, time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+
+	src.set(Entry{ID: "e1", Kind: KindMerchant, Value: "m-1", Action: ActionDeny})
+	waitFor(t, "a polled reload", func() bool { return matchedID(c, repo.Payment{MerchantID: "m-1"}) == "e1" })
+	src.set()
+	waitFor(t, "a polled removal", func() bool { return matchedID(c, repo.Payment{MerchantID: "m-1"}) == "" })
+
+	// after a round of polling the change stream is tried again
+	waitFor(t, "a change stream retry", func() bool {
+		src.mu.Lock()
+		defer src.mu.Unlock()
+		return src.watches > 1
+	})
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
@@ -0,0 +1,190 @@
+package lists
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type Kind string
+
+const (
+	KindUser       Kind = "user"
+	KindMerchant   Kind = "merchant"
+	KindInstrument Kind = "instrument"
+)
+
+type Action string
+
+const (
+	ActionAllow Action = "allow"
+	ActionDeny  Action = "deny"
+)
+
+var (
+	ErrEntryNotFound  = errors.New("list entry not found")
+	ErrDuplicateEntry = errors.New("value is already listed")
+	ErrInvalidEntry   = errors.New("invalid list entry")
+)
+
+// Entry forces the decision for every payment whose user, merchant or
+// instrument fingerprint equals Value.
+type Entry struct {
+	ID        string     `bson:"_id" json:"id"`
+	Kind      Kind       `bson:"kind" json:"kind"`
+	Value     string     `bson:"value" json:"value"`
+	Action    Action     `bson:"action" json:"action"`
+	Reason    string     `bson:"reason" json:"reason"`
+	CreatedBy string     `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `bson:"updated_at" json:"updated_at"`
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+func (e Entry) Validate() error {
+	switch {
+	case e.Kind != KindUser && e.Kind != KindMerchant && e.Kind != KindInstrument:
+		return fmt.Errorf("%w: kind must be user, merchant or instrument", ErrInvalidEntry)
+	case e.Action != ActionAllow && e.Action != ActionDeny:
+		return fmt.Errorf("%w: action must be allow or deny", ErrInvalidEntry)
+	case e.Value == "":
+		return fmt.Errorf("%w: value is required", ErrInvalidEntry)
+	case e.Reason == "":
+		return fmt.Errorf("%w: reason is required", ErrInvalidEntry)
+	}
+	return nil
+}
+
+func (e Entry) expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+type ListRepo struct {
+	col *mongo.Collection
+}
+
+func NewListRepo(db *mongo.Database) *ListRepo {
+	return &ListRepo{col: db.Collection("risk_lists")}
+}
+
+// EnsureIndexes keeps one entry per listed value and lets Mongo delete
+// entries once they expire.
+func (r *ListRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "kind", Value: 1}, {Key: "value", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+func (r *ListRepo) Create(ctx context.Context, e Entry) (*Entry, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	e.ID = uuid.NewString()
+	e.CreatedAt = time.Now().UTC()
+	e.UpdatedAt = e.CreatedAt
+	if _, err := r.col.InsertOne(ctx, e); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateEntry
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *ListRepo) Get(ctx context.Context, id string) (*Entry, error) {
+	var e Entry
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&e)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// List returns entries, optionally narrowed to one kind and action.
+func (r *ListRepo) List(ctx context.Context, kind Kind, action Action) ([]Entry, error) {
+	filter := bson.M{}
+	if kind != "" {
+		filter["kind"] = kind
+	}
+	if action != "" {
+		filter["action"] = action
+	}
+	cur, err := r.col.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "kind", Value: 1}, {Key: "value", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	entries := []Entry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// watch follows every change to the collection with the full document.
+func (r *ListRepo) watch(ctx context.Context) (changeStream, error) {
+	stream, err := r.col.Watch(ctx, mongo.Pipeline{}, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Update changes an entry's action, reason and expiry. Kind and value are
+// fixed; list the new value instead.
+func (r *ListRepo) Update(ctx context.Context, id string, action Action, reason string, expiresAt *time.Time) (*Entry, error) {
+	e, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	e.Action, e.Reason, e.ExpiresAt = action, reason, expiresAt
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	e.UpdatedAt = time.Now().UTC()
+
+	set := bson.M{"action": e.Action, "reason": e.Reason, "updated_at": e.UpdatedAt}
+	update := bson.M{"$set": set}
+	if expiresAt == nil {
+		update["$unset"] = bson.M{"expires_at": ""}
+	} else {
+		set["expires_at"] = expiresAt
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, ErrEntryNotFound
+	}
+	return e, nil
+}
+
+func (r *ListRepo) Delete(ctx context.Context, id string) error {
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrEntryNotFound
+	}
+	return nil
+}
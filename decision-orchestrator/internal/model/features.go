@@ -0,0 +1,108 @@
+package model
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/velocity"
+)
+
+// WindowTotals answers sliding window aggregates per user or merchant.
+type WindowTotals interface {
+	Totals(ctx context.Context, kind velocity.Kind, id string, window time.Duration, at time.Time) (map[string]velocity.Totals, error)
+}
+
+// paymentFeatures are read from the payment alone. Times are UTC.
+var paymentFeatures = map[string]func(repo.Payment) float64{
+	"amount":      func(p repo.Payment) float64 { return float64(p.Amount) },
+	"log_amount":  func(p repo.Payment) float64 { return math.Log1p(float64(p.Amount)) },
+	"hour_of_day": func(p repo.Payment) float64 { return float64(p.CreatedAt.UTC().Hour()) },
+	"day_of_week": func(p repo.Payment) float64 { return float64(p.CreatedAt.UTC().Weekday()) },
+	"has_instrument": func(p repo.Payment) float64 {
+		if p.InstrumentFingerprint == "" {
+			return 0
+		}
+		return 1
+	},
+}
+
+type windowFeature struct {
+	kind   velocity.Kind
+	window time.Duration
+	sum    bool
+}
+
+// windowFeatures read the velocity counters as of the payment's creation,
+// which include the payment itself, so a payment scores the same however
+// late it is scored. Counts cover all currencies; sums only the payment's
+// own currency.
+var windowFeatures = map[string]windowFeature{
+	"user_count_1m":      {velocity.KindUser, time.Minute, false},
+	"user_count_1h":      {velocity.KindUser, time.Hour, false},
+	"user_count_24h":     {velocity.KindUser, 24 * time.Hour, false},
+	"user_sum_1m":        {velocity.KindUser, time.Minute, true},
+	"user_sum_1h":        {velocity.KindUser, time.Hour, true},
+	"user_sum_24h":       {velocity.KindUser, 24 * time.Hour, true},
+	"merchant_count_1m":  {velocity.KindMerchant, time.Minute, false},
+	"merchant_count_1h":  {velocity.KindMerchant, time.Hour, false},
+	"merchant_count_24h": {velocity.KindMerchant, 24 * time.Hour, false},
+	"merchant_sum_1m":    {velocity.KindMerchant, time.Minute, true},
+	"merchant_sum_1h":    {velocity.KindMerchant, time.Hour, true},
+	"merchant_sum_24h":   {velocity.KindMerchant, 24 * time.Hour, true},
+}
+
+func knownFeature(name string) bool {
+	_, ok := paymentFeatures[name]
+	if !ok {
+		_, ok = windowFeatures[name]
+	}
+	return ok
+}
+
+// Extract computes the named features of p. Each velocity window is queried
+// once however many features read it.
+func Extract(ctx context.Context, p repo.Payment, counters WindowTotals, names []string) (map[string]float64, error) {
+	out := make(map[string]float64, len(names))
+	type key struct {
+		kind   velocity.Kind
+		window time.Duration
+	}
+	fetched := map[key]map[string]velocity.Totals{}
+
+	for _, name := range names {
+		if f, ok := paymentFeatures[name]; ok {
+			out[name] = f(p)
+			continue
+		}
+		wf := windowFeatures[name]
+		k := key{wf.kind, wf.window}
+		totals, ok := fetched[k]
+		if !ok {
+			if counters == nil {
+				return nil, ErrNoVelocity
+			}
+			id := p.UserID
+			if wf.kind == velocity.KindMerchant {
+				id = p.MerchantID
+			}
+			var err error
+			totals, err = counters.Totals(ctx, wf.kind, id, wf.window, p.CreatedAt)
+			if err != nil {
+				return nil, err
+			}
+			fetched[k] = totals
+		}
+		if wf.sum {
+			out[name] = float64(totals[p.Currency].Sum)
+			continue
+		}
+		var n int64
+		for _, t := range totals {
+			n += t.Count
+		}
+		out[name] = float64(n)
+	}
+	return out, nil
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+type Type string
+
+const (
+	TypeLogistic Type = "logistic_regression"
+	TypeGBT      Type = "gradient_boosted_trees"
+)
+
+var (
+	ErrInvalidModel = errors.New("invalid model")
+	ErrNoVelocity   = errors.New("model uses velocity features but no velocity store is configured")
+)
+
+// File is a model exported as JSON by the training pipeline. Both types
+// produce a log-odds margin that is squashed into a [0, 1] score.
+type File struct {
+	Version string `json:"version"`
+	Type    Type   `json:"type"`
+
+	// logistic regression: margin = intercept + sum(weight * feature)
+	Intercept float64            `json:"intercept,omitempty"`
+	Weights   map[string]float64 `json:"weights,omitempty"`
+
+	// gradient boosted trees: margin = base_score + sum of the leaves reached
+	BaseScore float64 `json:"base_score,omitempty"`
+	Trees     []Tree  `json:"trees,omitempty"`
+}
+
+// Tree is a flat list of nodes rooted at index 0. A split sends values
+// below Threshold to Left and the rest to Right; children must come after
+// their parent, which rules out cycles.
+type Tree struct {
+	Nodes []Node `json:"nodes"`
+}
+
+type Node struct {
+	Feature   string   `json:"feature,omitempty"`
+	Threshold float64  `json:"threshold,omitempty"`
+	Left      int      `json:"left,omitempty"`
+	Right     int      `json:"right,omitempty"`
+	Leaf      *float64 `json:"leaf,omitempty"`
+}
+
+// Model is a validated File ready to score.
+type Model struct {
+	Version  string
+	Type     Type
+	Features []string
+	margin   func(map[string]float64) float64
+}
+
+func (f File) Compile() (*Model, error) {
+	if f.Version == "" {
+		return nil, fmt.Errorf("%w: version is required", ErrInvalidModel)
+	}
+	m := &Model{Version: f.Version, Type: f.Type}
+	switch f.Type {
+	case TypeLogistic:
+		if len(f.Weights) == 0 {
+			return nil, fmt.Errorf("%w: logistic regression needs weights", ErrInvalidModel)
+		}
+		for name := range f.Weights {
+			if err := m.use(name); err != nil {
+				return nil, err
+			}
+		}
+		m.margin = func(x map[string]float64) float64 {
+			z := f.Intercept
+			for name, w := range f.Weights {
+				z += w * x[name]
+			}
+			return z
+		}
+	case TypeGBT:
+		if len(f.Trees) == 0 {
+			return nil, fmt.Errorf("%w: gradient boosted trees needs trees", ErrInvalidModel)
+		}
+		for i, t := range f.Trees {
+			if err := m.checkTree(t); err != nil {
+				return nil, fmt.Errorf("%w: tree %d: %v", ErrInvalidModel, i, err)
+			}
+		}
+		m.margin = func(x map[string]float64) float64 {
+			z := f.BaseScore
+			for _, t := range f.Trees {
+				z += t.leaf(x)
+			}
+			return z
+		}
+	default:
+		return nil, fmt.Errorf("%w: type must be %s or %s", ErrInvalidModel, TypeLogistic, TypeGBT)
+	}
+	slices.Sort(m.Features)
+	return m, nil
+}
+
+func (m *Model) use(feature string) error {
+	if !knownFeature(feature) {
+		return fmt.Errorf("%w: unknown feature %s", ErrInvalidModel, feature)
+	}
+	if !slices.Contains(m.Features, feature) {
+		m.Features = append(m.Features, feature)
+	}
+	return nil
+}
+
+func (m *Model) checkTree(t Tree) error {
+	if len(t.Nodes) == 0 {
+		return errors.New("no nodes")
+	}
+	for i, n := range t.Nodes {
+		if n.Leaf != nil {
+			continue
+		}
+		if !knownFeature(n.Feature) {
+			return fmt.Errorf("node %d: unknown feature %s", i, n.Feature)
+		}
+		for _, child := range []int{n.Left, n.Right} {
+			if child <= i || child >= len(t.Nodes) {
+				return fmt.Errorf("node %d: child %d out of range", i, child)
+			}
+		}
+		m.use(n.Feature)
+	}
+	return nil
+}
+
+func (t Tree) leaf(x map[string]float64) float64 {
+	i := 0
+	for {
+		n := t.Nodes[i]
+		if n.Leaf != nil {
+			return *n.Leaf
+		}
+		if x[n.Feature] < n.Threshold {
+			i = n.Left
+		} else {
+			i = n.Right
+		}
+	}
+}
+
+// Score maps features to a fraud probability. Features missing from x
+// count as zero.
+func (m *Model) Score(x map[string]float64) float64 {
+	return 1 / (1 + math.Exp(-m.margin(x)))
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/velocity"
+)
+
+func leaf(v float64) *float64 { return &v }
+
+// amountTree adds 1 to the margin for amounts of 1000 or more and -1 below.
+var amountTree = Tree{Nodes: []Node{
+	{Feature: "amount", Threshold: 1000, Left: 1, Right: 2},
+	{Leaf: leaf(-1)},
+	{Leaf: leaf(1)},
+}}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file File
+	}{
+		{"no version", File{Type: TypeLogistic, Weights: map[string]float64{"amount": 1}}},
+		{"unknown type", File{Version: "v1", Type: "svm"}},
+		{"logistic without weights", File{Version: "v1", Type: TypeLogistic}},
+		{"logistic with unknown feature", File{Version: "v1", Type: TypeLogistic, Weights: map[string]float64{"email_age": 1}}},
+		{"trees without trees", File{Version: "v1", Type: TypeGBT}},
+		{"tree without nodes", File{Version: "v1", Type: TypeGBT, Trees: []Tree{{}}}},
+		{"tree with unknown feature", File{Version: "v1", Type: TypeGBT, Trees: []Tree{{Nodes: []Node{
+			{Feature: "email_age", Left: 1, Right: 2}, {Leaf: leaf(0)}, {Leaf: leaf(0)},
+		}}}}},
+		{"tree with a cycle", File{Version: "v1", Type: TypeGBT, Trees: []Tree{{Nodes: []Node{
+			{Feature: "amount", Left: 0, Right: 1}, {Leaf: leaf(0)},
+		}}}}},
+		{"tree with a missing child", File{Version: "v1", Type: TypeGBT, Trees: []Tree{{Nodes: []Node{
+			{Feature: "amount", Left: 1, Right: 5}, {Leaf: leaf(0)},
+		}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.file.Compile(); !errors.Is(err, ErrInvalidModel) {
+				t.Errorf("Compile = %v, want ErrInvalidModel", err)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     File
+		x        map[string]float64
+		want     float64
+		features []string
+	}{
+		{
+			name:     "logistic at zero margin",
+			file:     File{Version: "v1", Type: TypeLogistic, Weights: map[string]float64{"amount": 0.001, "hour_of_day": -0.5}},
+			x:        map[string]float64{"amount": 1000, "hour_of_day": 2},
+			want:     0.5,
+			features: []string{"amount", "hour_of_day"},
+		},
+		{
+			name:     "logistic intercept only for missing features",
+			file:     File{Version: "v1", Type: TypeLogistic, Intercept: math.Log(3), Weights: map[string]float64{"user_count_1h": 1}},
+			x:        map[string]float64{},
+			want:     0.75,
+			features: []string{"user_count_1h"},
+		},
+		{
+			name:     "trees below threshold",
+			file:     File{Version: "v1", Type: TypeGBT, BaseScore: 1, Trees: []Tree{amountTree}},
+			x:        map[string]float64{"amount": 999},
+			want:     0.5,
+			features: []string{"amount"},
+		},
+		{
+			name:     "trees at threshold go right",
+			file:     File{Version: "v1", Type: TypeGBT, BaseScore: -2, Trees: []Tree{amountTree, amountTree}},
+			x:        map[string]float64{"amount": 1000},
+			want:     0.5,
+			features: []string{"amount"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := tt.file.Compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Score(tt.x); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(m.Features, tt.features) {
+				t.Errorf("Features = %v, want %v", m.Features, tt.features)
+			}
+		})
+	}
+}
+
+// countingTotals is a velocity store that counts its queries.
+type countingTotals struct {
+	*velocity.MemoryStore
+	queries int
+}
+
+func (c *countingTotals) Totals(ctx context.Context, kind velocity.Kind, id string, window time.Duration, at time.Time) (map[string]velocity.Totals, error) {
+	c.queries++
+	return c.MemoryStore.Totals(ctx, kind, id, window, at)
+}
+
+func TestExtract(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 7, 13, 0, 0, 0, time.UTC) // a Sunday
+	store := &countingTotals{MemoryStore: velocity.NewMemoryStore()}
+	p := repo.Payment{UserID: "u-1", MerchantID: "m-1", Amount: 500, Currency: "USD", CreatedAt: now}
+	for _, q := range []repo.Payment{
+		{UserID: "u-1", MerchantID: "m-2", Amount: 700, Currency: "EUR", CreatedAt: now.Add(-10 * time.Minute)},
+		p,
+	} {
+		if err := store.Record(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Extract(ctx, p, store, []string{"amount", "hour_of_day", "day_of_week", "has_instrument", "user_count_1h", "user_sum_1h", "merchant_count_1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{
+		"amount":            500,
+		"hour_of_day":       13,
+		"day_of_week":       0,
+		"has_instrument":    0,
+		"user_count_1h":     2,
+		"user_sum_1h":       500,
+		"merchant_count_1h": 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract = %v, want %v", got, want)
+	}
+	if store.queries != 2 {
+		t.Errorf("queried the store %d times, want once per subject and window", store.queries)
+	}
+
+	if _, err := Extract(ctx, p, nil, []string{"user_count_1h"}); !errors.Is(err, ErrNoVelocity) {
+		t.Errorf("Extract without a store = %v, want ErrNoVelocity", err)
+	}
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+type loadedModel struct {
+	model *Model
+	file  File
+	stamp string
+}
+
+// Scorer scores payments with the latest model file that compiled. It polls
+// the file and swaps in new versions; a file that fails to load is logged
+// and the previous model keeps scoring.
+type Scorer struct {
+	log      *zap.Logger
+	path     string
+	counters WindowTotals
+	interval time.Duration
+	current  atomic.Pointer[loadedModel]
+}
+
+func NewScorer(ctx context.Context, l *zap.Logger, path string, counters WindowTotals, interval time.Duration) (*Scorer, error) {
+	s := &Scorer{log: l, path: path, counters: counters, interval: interval}
+	if _, err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Scorer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := s.Reload(ctx)
+			if err != nil {
+				s.log.Error("model reload failed, keeping current model", zap.Error(err), zap.String("version", s.Version()))
+				continue
+			}
+			if changed {
+				s.log.Info("model reloaded", zap.String("version", s.Version()))
+			}
+		}
+	}
+}
+
+// Reload reads the model file if it changed on disk and swaps it in if it
+// compiles.
+func (s *Scorer) Reload(_ context.Context) (bool, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, err
+	}
+	stamp := info.ModTime().UTC().Format(time.RFC3339Nano) + "/" + strconv.FormatInt(info.Size(), 10)
+	if cur := s.current.Load(); cur != nil && cur.stamp == stamp {
+		return false, nil
+	}
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, err
+	}
+	var f File
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidModel, err)
+	}
+	m, err := f.Compile()
+	if err != nil {
+		return false, err
+	}
+	if s.counters == nil && slices.ContainsFunc(m.Features, func(name string) bool { _, ok := windowFeatures[name]; return ok }) {
+		return false, ErrNoVelocity
+	}
+	s.current.Store(&loadedModel{model: m, file: f, stamp: stamp})
+	return true, nil
+}
+
+func (s *Scorer) Score(ctx context.Context, p repo.Payment) (repo.ModelScore, error) {
+	m := s.current.Load().model
+	x, err := Extract(ctx, p, s.counters, m.Features)
+	if err != nil {
+		return repo.ModelScore{}, err
+	}
+	return repo.ModelScore{
+		Version:  m.Version,
+		Score:    m.Score(x),
+		Features: x,
+		ScoredAt: time.Now().UTC().Truncate(time.Millisecond),
+	}, nil
+}
+
+func (s *Scorer) Current() (File, []string) {
+	cur := s.current.Load()
+	return cur.file, cur.model.Features
+}
+
+func (s *Scorer) Version() string {
+	return s.current.Load().model.Version
+}
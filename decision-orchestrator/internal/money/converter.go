@@ -0,0 +1,103 @@
+package money
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+type loadedRates struct {
+	rates *Rates
+	stamp string
+}
+
+// Converter converts with the latest rates file that compiled. It polls the
+// file like the model scorer does; a file that fails to load is logged and
+// the previous rates stay in use.
+type Converter struct {
+	log       *zap.Logger
+	path      string
+	reporting string
+	interval  time.Duration
+	current   atomic.Pointer[loadedRates]
+}
+
+// NewConverter loads the rates file at path, whose base must be the
+// reporting currency.
+func NewConverter(ctx context.Context, l *zap.Logger, path, reporting string, interval time.Duration) (*Converter, error) {
+	c, err := Lookup(reporting)
+	if err != nil {
+		return nil, err
+	}
+	cv := &Converter{log: l, path: path, reporting: c.Code, interval: interval}
+	if _, err := cv.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return cv, nil
+}
+
+func (c *Converter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := c.Reload(ctx)
+			if err != nil {
+				c.log.Error("fx rates reload failed, keeping current rates", zap.Error(err))
+				continue
+			}
+			if changed {
+				c.log.Info("fx rates reloaded", zap.Strings("currencies", c.Currencies()))
+			}
+		}
+	}
+}
+
+// Reload reads the rates file if it changed on disk and swaps it in if it
+// compiles.
+func (c *Converter) Reload(_ context.Context) (bool, error) {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return false, err
+	}
+	stamp := info.ModTime().UTC().Format(time.RFC3339Nano) + "/" + strconv.FormatInt(info.Size(), 10)
+	if cur := c.current.Load(); cur != nil && cur.stamp == stamp {
+		return false, nil
+	}
+	raw, err := os.ReadFile(c.path)
+	if err != nil {
+		return false, err
+	}
+	var f RatesFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidRates, err)
+	}
+	r, err := f.Compile()
+	if err != nil {
+		return false, err
+	}
+	if r.Base() != c.reporting {
+		return false, fmt.Errorf("%w: base %s is not the reporting currency %s", ErrInvalidRates, r.Base(), c.reporting)
+	}
+	c.current.Store(&loadedRates{rates: r, stamp: stamp})
+	return true, nil
+}
+
+func (c *Converter) Convert(amount int64, currency string, at time.Time) (repo.ReportingAmount, error) {
+	return c.current.Load().rates.Convert(amount, currency, at)
+}
+
+func (c *Converter) Currencies() []string {
+	return c.current.Load().rates.Currencies()
+}
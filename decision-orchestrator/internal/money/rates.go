@@ -0,0 +1,158 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+var (
+	ErrInvalidRates = errors.New("invalid fx rates")
+	ErrNoRate       = errors.New("no fx rate")
+)
+
+// RatesFile is the on-disk FX table. Each rate is the price of one major
+// unit of Currency in the base currency, effective from EffectiveFrom (a
+// date or an RFC 3339 time) until the next rate for the same currency.
+type RatesFile struct {
+	Base  string      `json:"base"`
+	Rates []RateEntry `json:"rates"`
+}
+
+type RateEntry struct {
+	Currency      string      `json:"currency"`
+	Rate          json.Number `json:"rate"`
+	EffectiveFrom string      `json:"effective_from"`
+}
+
+type rate struct {
+	from  time.Time
+	text  string
+	value *big.Rat
+}
+
+// Rates is a compiled RatesFile.
+type Rates struct {
+	base  Currency
+	rates map[string][]rate
+}
+
+func (f RatesFile) Compile() (*Rates, error) {
+	base, err := Lookup(f.Base)
+	if err != nil {
+		return nil, fmt.Errorf("%w: base: %v", ErrInvalidRates, err)
+	}
+	r := &Rates{base: base, rates: map[string][]rate{}}
+	for i, e := range f.Rates {
+		c, err := Lookup(e.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rate %d: %v", ErrInvalidRates, i, err)
+		}
+		if c == base {
+			return nil, fmt.Errorf("%w: rate %d: %s is the base currency", ErrInvalidRates, i, c.Code)
+		}
+		v, ok := new(big.Rat).SetString(e.Rate.String())
+		if !ok || v.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: rate %d: rate %q must be a positive decimal", ErrInvalidRates, i, e.Rate)
+		}
+		from, err := parseEffective(e.EffectiveFrom)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rate %d: %v", ErrInvalidRates, i, err)
+		}
+		r.rates[c.Code] = append(r.rates[c.Code], rate{from: from, text: e.Rate.String(), value: v})
+	}
+	for code, rs := range r.rates {
+		slices.SortFunc(rs, func(a, b rate) int { return a.from.Compare(b.from) })
+		for i := 1; i < len(rs); i++ {
+			if rs[i].from.Equal(rs[i-1].from) {
+				return nil, fmt.Errorf("%w: two %s rates effective from %s", ErrInvalidRates, code, rs[i].from.Format(time.RFC3339))
+			}
+		}
+	}
+	return r, nil
+}
+
+func parseEffective(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("effective_from %q is neither a date nor an RFC 3339 time", s)
+	}
+	return t.UTC(), nil
+}
+
+func (r *Rates) Base() string { return r.base.Code }
+
+// Convert converts amount minor units of currency into the base currency
+// at the rate in effect at, rounding half away from zero to the base
+// currency's minor unit.
+func (r *Rates) Convert(amount int64, currency string, at time.Time) (repo.ReportingAmount, error) {
+	c, err := Lookup(currency)
+	if err != nil {
+		return repo.ReportingAmount{}, err
+	}
+	if c == r.base {
+		return repo.ReportingAmount{Currency: c.Code, Amount: amount, Rate: "1"}, nil
+	}
+	rs := r.rates[c.Code]
+	i, _ := slices.BinarySearchFunc(rs, at, func(x rate, t time.Time) int {
+		if x.from.After(t) {
+			return 1
+		}
+		return -1
+	})
+	if i == 0 {
+		return repo.ReportingAmount{}, fmt.Errorf("%w for %s to %s at %s", ErrNoRate, c.Code, r.base.Code, at.UTC().Format(time.RFC3339))
+	}
+	rt := rs[i-1]
+
+	x := new(big.Rat).SetInt64(amount)
+	x.Mul(x, rt.value)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(r.base.Exponent-c.Exponent))), nil))
+	if r.base.Exponent >= c.Exponent {
+		x.Mul(x, scale)
+	} else {
+		x.Quo(x, scale)
+	}
+	n := roundHalfAway(x)
+	if !n.IsInt64() {
+		return repo.ReportingAmount{}, fmt.Errorf("%d %s overflows when converted to %s", amount, c.Code, r.base.Code)
+	}
+	return repo.ReportingAmount{Currency: r.base.Code, Amount: n.Int64(), Rate: rt.text, RateFrom: rt.from}, nil
+}
+
+// Currencies lists the currencies with rates, in code order.
+func (r *Rates) Currencies() []string {
+	out := make([]string, 0, len(r.rates))
+	for code := range r.rates {
+		out = append(out, code)
+	}
+	slices.Sort(out)
+	return out
+}
+
+func roundHalfAway(x *big.Rat) *big.Int {
+	num := new(big.Int).Abs(x.Num())
+	den := x.Denom()
+	// (2|num| + den) / 2den is |x| rounded half up
+	num.Lsh(num, 1).Add(num, den)
+	n := num.Quo(num, new(big.Int).Lsh(den, 1))
+	if x.Sign() < 0 {
+		n.Neg(n)
+	}
+	return n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,157 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func testRates(t *testing.T) *Rates {
+	t.Helper()
+	r, err := RatesFile{Base: "USD", Rates: []RateEntry{
+		{Currency: "EUR", Rate: "1.20", EffectiveFrom: "2026-02-01"},
+		{Currency: "EUR", Rate: "1.10", EffectiveFrom: "2026-01-01"},
+		{Currency: "JPY", Rate: "0.0067", EffectiveFrom: "2026-01-01"},
+		{Currency: "KWD", Rate: "3.25", EffectiveFrom: "2026-01-01"},
+		{Currency: "CHF", Rate: "0.5", EffectiveFrom: "2026-01-01"},
+		{Currency: "KRW", Rate: "1000", EffectiveFrom: "2026-01-01"},
+		{Currency: "GBP", Rate: "1.25", EffectiveFrom: "2026-03-01T00:00:00+01:00"},
+	}}.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestConvert(t *testing.T) {
+	r := testRates(t)
+	tests := []struct {
+		name     string
+		amount   int64
+		currency string
+		at       time.Time
+		want     int64
+		rate     string
+		rateFrom time.Time
+	}{
+		{"base currency", 1234, "USD", day("2025-01-01"), 1234, "1", time.Time{}},
+		{"first rate", 1000, "EUR", day("2026-01-15"), 1100, "1.10", day("2026-01-01")},
+		{"rate takes effect at its start", 1000, "eur", day("2026-02-01"), 1200, "1.20", day("2026-02-01")},
+		{"just before the next rate", 1000, "EUR", day("2026-02-01").Add(-time.Nanosecond), 1100, "1.10", day("2026-01-01")},
+		{"from fewer minor units", 1500, "JPY", day("2026-01-15"), 1005, "0.0067", day("2026-01-01")},
+		{"from fewer minor units, rounded", 75, "JPY", day("2026-01-15"), 50, "0.0067", day("2026-01-01")},
+		{"from more minor units", 2000, "KWD", day("2026-01-15"), 650, "3.25", day("2026-01-01")},
+		{"from more minor units, rounded down", 1, "KWD", day("2026-01-15"), 0, "3.25", day("2026-01-01")},
+		{"from more minor units, rounded up", 2, "KWD", day("2026-01-15"), 1, "3.25", day("2026-01-01")},
+		{"half rounds away from zero", 1, "CHF", day("2026-01-15"), 1, "0.5", day("2026-01-01")},
+		{"half rounds away from zero again", 3, "CHF", day("2026-01-15"), 2, "0.5", day("2026-01-01")},
+		{"negative half rounds away from zero", -3, "CHF", day("2026-01-15"), -2, "0.5", day("2026-01-01")},
+		{"offset effective time in UTC", 100, "GBP", day("2026-02-28").Add(23 * time.Hour), 125, "1.25", day("2026-02-28").Add(23 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Convert(tt.amount, tt.currency, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Currency != "USD" || got.Amount != tt.want || got.Rate != tt.rate || !got.RateFrom.Equal(tt.rateFrom) {
+				t.Errorf("Convert = %+v, want %d USD at %s from %s", got, tt.want, tt.rate, tt.rateFrom)
+			}
+		})
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	r := testRates(t)
+	tests := []struct {
+		name     string
+		amount   int64
+		currency string
+		at       time.Time
+		wantErr  error
+	}{
+		{"unknown currency", 100, "XYZ", day("2026-01-15"), ErrUnknownCurrency},
+		{"before the first rate", 100, "EUR", day("2025-12-31"), ErrNoRate},
+		{"GBP before its offset start", 100, "GBP", day("2026-02-28").Add(22 * time.Hour), ErrNoRate},
+		{"no rates at all", 100, "SEK", day("2026-01-15"), ErrNoRate},
+		{"overflow", math.MaxInt64, "KRW", day("2026-01-15"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.Convert(tt.amount, tt.currency, tt.at)
+			if err == nil {
+				t.Fatal("Convert succeeded, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Convert = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompileRates(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  RatesFile
+		valid bool
+	}{
+		{"empty table", RatesFile{Base: "EUR"}, true},
+		{"lower case codes", RatesFile{Base: "eur", Rates: []RateEntry{{Currency: "usd", Rate: "0.9", EffectiveFrom: "2026-01-01"}}}, true},
+		{"unknown base", RatesFile{Base: "EURO"}, false},
+		{"unknown currency", RatesFile{Base: "EUR", Rates: []RateEntry{{Currency: "XYZ", Rate: "1", EffectiveFrom: "2026-01-01"}}}, false},
+		{"rate for the base", RatesFile{Base: "EUR", Rates: []RateEntry{{Currency: "EUR", Rate: "1", EffectiveFrom: "2026-01-01"}}}, false},
+		{"zero rate", RatesFile{Base: "EUR", Rates: []RateEntry{{Currency: "USD", Rate: "0", EffectiveFrom: "2026-01-01"}}}, false},
+		{"negative rate", RatesFile{Base: "EUR", Rates: []RateEntry{{Currency: "USD", Rate: "-0.9", EffectiveFrom: "2026-01-01"}}}, false},
+		{"rate is not a number", RatesFile{Base: "EUR", Rates: []RateEntry{{Currency: "USD", Rate: "abc", EffectiveFrom: "2026-01-01"}}}, false},
+		{"bad effective date", RatesFile{Base: "EUR", Rates: []RateEntry{{Currency: "USD", Rate: "0.9", EffectiveFrom: "01/02/2026"}}}, false},
+		{"two rates from the same time", RatesFile{Base: "EUR", Rates: []RateEntry{
+			{Currency: "USD", Rate: "0.9", EffectiveFrom: "2026-01-01"},
+			{Currency: "USD", Rate: "0.8", EffectiveFrom: "2026-01-01T00:00:00Z"},
+		}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.file.Compile()
+			if tt.valid && err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidRates) {
+				t.Errorf("Compile = %v, want ErrInvalidRates", err)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		code     string
+		want     string
+		exponent int
+	}{
+		{"USD", "USD", 2},
+		{"usd", "USD", 2},
+		{"JPY", "JPY", 0},
+		{"KWD", "KWD", 3},
+		{"CLF", "CLF", 4},
+	}
+	for _, tt := range tests {
+		c, err := Lookup(tt.code)
+		if err != nil || c.Code != tt.want || c.Exponent != tt.exponent {
+			t.Errorf("Lookup(%s) = %+v, %v; want %s with exponent %d", tt.code, c, err, tt.want, tt.exponent)
+		}
+	}
+	for _, code := range []string{"", "XAU", "US", "EURO"} {
+		if _, err := Lookup(code); !errors.Is(err, ErrUnknownCurrency) {
+			t.Errorf("Lookup(%q) = %v, want ErrUnknownCurrency", code, err)
+		}
+	}
+}
@@ -2,12 +2,16 @@ package outbox
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+var ErrDuplicateEvent = errors.New("outbox event already exists")
+
 type OutboxRepo struct {
 	col *mongo.Collection
 }
@@ -15,7 +19,9 @@ type OutboxRepo struct {
 type OutboxEvent struct {
 	ID            string    `bson:"_id"`
 	AggregateID   string    `bson:"aggregate_id"`
+	Topic         string    `bson:"topic,omitempty"`
 	Type          string    `bson:"type"`
+	SchemaVersion int       `bson:"schema_version,omitempty"`
 	Payload       []byte    `bson:"payload"`
 	Headers       bson.M    `bson:"headers"`
 	CreatedAt     time.Time `bson:"created_at"`
@@ -28,16 +34,78 @@ func NewOutboxRepo(db *mongo.Database) *OutboxRepo {
 	return &OutboxRepo{col: db.Collection("outbox")}
 }
 
+func (r *OutboxRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"published": false}),
+	})
+	return err
+}
+
 func (r *OutboxRepo) Insert(ctx context.Context, event OutboxEvent) error {
 	_, err := r.col.InsertOne(ctx, event)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateEvent
+	}
 	return err
 }
 
+func (r *OutboxRepo) Get(ctx context.Context, id string) (*OutboxEvent, error) {
+	var event OutboxEvent
+	if err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Scan walks events created in [from, to) in creation order. Records written
+// before schema versioning have SchemaVersion 0.
+func (r *OutboxRepo) Scan(ctx context.Context, from, to time.Time, unpublishedOnly bool, fn func(OutboxEvent) error) error {
+	filter := bson.M{"created_at": bson.M{"$gte": from, "$lt": to}}
+	if unpublishedOnly {
+		filter["published"] = false
+	}
+
+	cur, err := r.col.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var event OutboxEvent
+		if err := cur.Decode(&event); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// FindUnpublishedBefore returns up to limit events created before the cutoff
+// that were never marked published, oldest first.
+func (r *OutboxRepo) FindUnpublishedBefore(ctx context.Context, before time.Time, limit int) ([]OutboxEvent, error) {
+	cur, err := r.col.Find(ctx,
+		bson.M{"published": false, "created_at": bson.M{"$lt": before}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var out []OutboxEvent
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (r *OutboxRepo) MarkPublished(ctx context.Context, id string) error {
 	update := bson.M{
 		"$set": bson.M{
-			"published":     true,
-			"$published_at": time.Now(),
+			"published":    true,
+			"published_at": time.Now(),
 		},
 	}
 	_, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, update)
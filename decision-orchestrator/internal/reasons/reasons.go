@@ -0,0 +1,129 @@
+package reasons
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+type Severity string
+
+const (
+	SeverityInfo   Severity = "info"
+	SeverityLow    Severity = "low"
+	SeverityMedium Severity = "medium"
+	SeverityHigh   Severity = "high"
+)
+
+// Code describes one machine readable decision reason. Description is meant
+// for merchants; Sensitive codes reveal how fraud is detected and may be
+// generalized before they leave the platform.
+type Code struct {
+	Code        string   `json:"code"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+	Sensitive   bool     `json:"sensitive,omitempty"`
+}
+
+// Codes produced by the orchestrator itself rather than by a risk engine.
+const (
+	DecisionTimeout = "decision_timeout"
+	AllowList       = "allow_list"
+	DenyList        = "deny_list"
+
+	ChallengePassed    = "challenge_passed"
+	ChallengeFailed    = "challenge_failed"
+	ChallengeAbandoned = "challenge_abandoned"
+	ChallengeExpired   = "challenge_expired"
+)
+
+var builtin = []Code{
+	{Code: DecisionTimeout, Severity: SeverityInfo, Description: "No risk decision was made in time; the fallback policy applied."},
+	{Code: AllowList, Severity: SeverityInfo, Description: "The payment matched an allow list."},
+	{Code: DenyList, Severity: SeverityHigh, Description: "The payment matched a deny list.", Sensitive: true},
+	{Code: ChallengePassed, Severity: SeverityInfo, Description: "The customer passed step-up authentication."},
+	{Code: ChallengeFailed, Severity: SeverityMedium, Description: "The customer failed step-up authentication."},
+	{Code: ChallengeAbandoned, Severity: SeverityLow, Description: "The customer abandoned step-up authentication."},
+	{Code: ChallengeExpired, Severity: SeverityLow, Description: "No step-up authentication result arrived in time."},
+	{Code: "amount_over_limit", Severity: SeverityMedium, Description: "The amount exceeds the limit for its currency."},
+	{Code: "merchant_blocked", Severity: SeverityHigh, Description: "Payments to this merchant are blocked."},
+	{Code: "user_velocity", Severity: SeverityMedium, Description: "The customer made unusually many payments in a short time.", Sensitive: true},
+}
+
+var (
+	ErrInvalidRegistry = errors.New("invalid reason code registry")
+
+	validSeverities = []Severity{SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh}
+)
+
+type Registry struct {
+	codes map[string]Code
+}
+
+// Default holds only the built in codes.
+func Default() *Registry {
+	r := &Registry{codes: map[string]Code{}}
+	for _, c := range builtin {
+		r.codes[c.Code] = c
+	}
+	return r
+}
+
+// Load reads {"codes": [...]} from path on top of the built in codes; file
+// entries replace built in ones with the same code. An empty path yields
+// Default.
+func Load(path string) (*Registry, error) {
+	r := Default()
+	if path == "" {
+		return r, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		Codes []Code `json:"codes"`
+	}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRegistry, err)
+	}
+	for _, c := range f.Codes {
+		if c.Code == "" || c.Description == "" {
+			return nil, fmt.Errorf("%w: every code needs code and description", ErrInvalidRegistry)
+		}
+		if !slices.Contains(validSeverities, c.Severity) {
+			return nil, fmt.Errorf("%w: code %s has unknown severity %q", ErrInvalidRegistry, c.Code, c.Severity)
+		}
+		r.codes[c.Code] = c
+	}
+	return r, nil
+}
+
+func (r *Registry) Lookup(code string) (Code, bool) {
+	c, ok := r.codes[code]
+	return c, ok
+}
+
+// Unknown returns the codes that are not registered.
+func (r *Registry) Unknown(codes []string) []string {
+	var out []string
+	for _, c := range codes {
+		if _, ok := r.codes[c]; !ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// All returns every registered code sorted by code.
+func (r *Registry) All() []Code {
+	out := make([]Code, 0, len(r.codes))
+	for _, c := range r.codes {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
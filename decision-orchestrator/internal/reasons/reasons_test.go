@@ -0,0 +1,70 @@
+package reasons
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		lookup  string
+		want    Code
+		wantErr bool
+	}{
+		{
+			name:   "adds a code",
+			file:   `{"codes": [{"code": "ip_mismatch", "severity": "high", "description": "IP and card country differ.", "sensitive": true}]}`,
+			lookup: "ip_mismatch",
+			want:   Code{Code: "ip_mismatch", Severity: SeverityHigh, Description: "IP and card country differ.", Sensitive: true},
+		},
+		{
+			name:   "replaces a built in code",
+			file:   `{"codes": [{"code": "deny_list", "severity": "medium", "description": "Blocked."}]}`,
+			lookup: DenyList,
+			want:   Code{Code: DenyList, Severity: SeverityMedium, Description: "Blocked."},
+		},
+		{
+			name:   "keeps built in codes",
+			file:   `{"codes": []}`,
+			lookup: DecisionTimeout,
+			want:   Code{Code: DecisionTimeout, Severity: SeverityInfo, Description: "No risk decision was made in time; the fallback policy applied."},
+		},
+		{name: "missing description", file: `{"codes": [{"code": "x", "severity": "low"}]}`, wantErr: true},
+		{name: "unknown severity", file: `{"codes": [{"code": "x", "severity": "urgent", "description": "X."}]}`, wantErr: true},
+		{name: "invalid json", file: `{"codes":`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "reasons.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			r, err := Load(path)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRegistry) {
+					t.Fatalf("Load = %v, want ErrInvalidRegistry", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := r.Lookup(tt.lookup)
+			if !ok || got != tt.want {
+				t.Errorf("Lookup(%s) = %+v, %v; want %+v", tt.lookup, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	got := Default().Unknown([]string{AllowList, "made_up", ChallengeExpired, "also_made_up"})
+	if want := []string{"made_up", "also_made_up"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unknown = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/lease"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ChallengeResult string
+
+const (
+	ChallengePassed    ChallengeResult = "passed"
+	ChallengeFailed    ChallengeResult = "failed"
+	ChallengeAbandoned ChallengeResult = "abandoned"
+	// ChallengeExpired is set by the sweeper when no result arrived in time.
+	ChallengeExpired ChallengeResult = "expired"
+)
+
+// Challenge tracks step-up authentication the risk engine asked for. Result
+// is empty while the payment is CHALLENGE_REQUIRED.
+type Challenge struct {
+	RequestedAt time.Time       `bson:"requested_at" json:"requested_at"`
+	ExpiresAt   time.Time       `bson:"expires_at" json:"expires_at"`
+	Result      ChallengeResult `bson:"result,omitempty" json:"result,omitempty"`
+	ResultAt    *time.Time      `bson:"result_at,omitempty" json:"result_at,omitempty"`
+}
+
+// RequireChallenge moves a PENDING payment to CHALLENGE_REQUIRED, storing
+// the risk decision that asked for the challenge.
+func (r *PaymentRepo) RequireChallenge(ctx context.Context, id string, score float64, reason string, exp Explanation, ch Challenge) error {
+	set := bson.M{
+		"status":      StatusChallengeRequired,
+		"risk_score":  score,
+		"risk_reason": reason,
+		"challenge":   ch,
+	}
+	if len(exp.ReasonCodes) > 0 {
+		set["reason_codes"] = exp.ReasonCodes
+	}
+	if len(exp.Contributions) > 0 {
+		set["contributions"] = exp.Contributions
+	}
+	if exp.Model != nil {
+		set["model"] = exp.Model
+	}
+	return r.transition(ctx, id, StatusPending, set)
+}
+
+// ResolveChallenge finalizes a CHALLENGE_REQUIRED payment with the result
+// of its challenge and adds code to its reason codes. ErrStaleStatus means
+// the challenge was already resolved.
+func (r *PaymentRepo) ResolveChallenge(ctx context.Context, id string, to PaymentStatus, result ChallengeResult, code string, at time.Time) error {
+	filter := bson.M{"_id": id, "status": StatusChallengeRequired}
+	update := bson.M{
+		"$set": bson.M{
+			"status":              to,
+			"challenge.result":    result,
+			"challenge.result_at": at,
+		},
+		"$push": bson.M{"reason_codes": code},
+		"$inc":  bson.M{"version": 1},
+	}
+	lease.Fence(ctx, filter, update)
+	res, err := r.col.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrStaleStatus
+	}
+	return nil
+}
+
+func (r *PaymentRepo) FindChallengesExpiredBefore(ctx context.Context, cutoff time.Time, limit int) ([]Payment, error) {
+	filter := bson.M{"status": StatusChallengeRequired, "challenge.expires_at": bson.M{"$lt": cutoff}}
+	opts := options.Find().SetSort(bson.D{{Key: "challenge.expires_at", Value: 1}}).SetLimit(int64(limit))
+
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var payments []Payment
+	if err := cur.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
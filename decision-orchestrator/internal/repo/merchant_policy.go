@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrPolicyNotFound = errors.New("merchant policy not found")
+	ErrInvalidPolicy  = errors.New("invalid merchant policy")
+)
+
+// MerchantPolicy maps a risk score to a status for one merchant: scores
+// below ApproveBelow are approved, scores at or above DeclineFrom are
+// declined and anything in between goes to review. Every change is stored
+// as a new version; older versions are kept so decisions can be traced to
+// the exact thresholds applied.
+type MerchantPolicy struct {
+	ID           string    `bson:"_id" json:"-"`
+	MerchantID   string    `bson:"merchant_id" json:"merchant_id"`
+	Version      int64     `bson:"version" json:"version"`
+	ApproveBelow float64   `bson:"approve_below" json:"approve_below"`
+	DeclineFrom  float64   `bson:"decline_from" json:"decline_from"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+	CreatedBy    string    `bson:"created_by,omitempty" json:"created_by,omitempty"`
+}
+
+func (p MerchantPolicy) Validate() error {
+	if p.ApproveBelow < 0 || p.DeclineFrom > 1 || p.ApproveBelow > p.DeclineFrom {
+		return fmt.Errorf("%w: need 0 <= approve_below <= decline_from <= 1", ErrInvalidPolicy)
+	}
+	return nil
+}
+
+func (p MerchantPolicy) Decide(score float64) PaymentStatus {
+	switch {
+	case score >= p.DeclineFrom:
+		return StatusDeclined
+	case score < p.ApproveBelow:
+		return StatusApproved
+	default:
+		return StatusReview
+	}
+}
+
+type MerchantPolicyRepo struct {
+	col *mongo.Collection
+}
+
+func NewMerchantPolicyRepo(db *mongo.Database) *MerchantPolicyRepo {
+	return &MerchantPolicyRepo{col: db.Collection("merchant_policies")}
+}
+
+func (r *MerchantPolicyRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "merchant_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *MerchantPolicyRepo) Latest(ctx context.Context, merchantID string) (*MerchantPolicy, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	var p MerchantPolicy
+	err := r.col.FindOne(ctx, bson.M{"merchant_id": merchantID}, opts).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPolicyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *MerchantPolicyRepo) Versions(ctx context.Context, merchantID string) ([]MerchantPolicy, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	cur, err := r.col.Find(ctx, bson.M{"merchant_id": merchantID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	policies := []MerchantPolicy{}
+	if err := cur.All(ctx, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// Put stores p as the merchant's next version and returns it.
+func (r *MerchantPolicyRepo) Put(ctx context.Context, p MerchantPolicy) (*MerchantPolicy, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt < 5; attempt++ {
+		latest, err := r.Latest(ctx, p.MerchantID)
+		switch {
+		case errors.Is(err, ErrPolicyNotFound):
+			p.Version = 1
+		case err != nil:
+			return nil, err
+		default:
+			p.Version = latest.Version + 1
+		}
+		p.ID = fmt.Sprintf("%s:%d", p.MerchantID, p.Version)
+		p.CreatedAt = time.Now().UTC()
+
+		_, err = r.col.InsertOne(ctx, p)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &p, nil
+	}
+	return nil, errors.New("merchant policy update contended, giving up")
+}
@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type OverrideState string
+
+const (
+	OverridePendingApproval OverrideState = "PENDING_APPROVAL"
+	OverrideApplied         OverrideState = "APPLIED"
+	OverrideRejected        OverrideState = "REJECTED"
+	// OverrideStale marks an approved override whose payment changed status
+	// between request and approval, so it was never applied.
+	OverrideStale OverrideState = "STALE"
+)
+
+var (
+	ErrOverrideNotFound   = errors.New("override not found")
+	ErrOverrideInProgress = errors.New("payment already has an override awaiting approval")
+	ErrOverrideResolved   = errors.New("override is no longer awaiting approval")
+)
+
+// Override is an analyst's request to change a payment's decision. Requests
+// above the dual control threshold wait in PENDING_APPROVAL for a second
+// analyst.
+type Override struct {
+	ID          string        `bson:"_id" json:"id"`
+	PaymentID   string        `bson:"payment_id" json:"payment_id"`
+	From        PaymentStatus `bson:"from" json:"from"`
+	To          PaymentStatus `bson:"to" json:"to"`
+	ReasonCode  string        `bson:"reason_code" json:"reason_code"`
+	Note        string        `bson:"note,omitempty" json:"note,omitempty"`
+	Amount      int64         `bson:"amount" json:"amount"`
+	RequestedBy string        `bson:"requested_by" json:"requested_by"`
+	RequestedAt time.Time     `bson:"requested_at" json:"requested_at"`
+	State       OverrideState `bson:"state" json:"state"`
+	ResolvedBy  string        `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	ResolvedAt  *time.Time    `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+}
+
+type OverrideRepo struct {
+	col *mongo.Collection
+}
+
+func NewOverrideRepo(db *mongo.Database) *OverrideRepo {
+	return &OverrideRepo{col: db.Collection("payment_overrides")}
+}
+
+// EnsureIndexes allows at most one override per payment to await approval.
+func (r *OverrideRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "payment_id", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"state": OverridePendingApproval}),
+	})
+	return err
+}
+
+func (r *OverrideRepo) Insert(ctx context.Context, ov Override) error {
+	_, err := r.col.InsertOne(ctx, ov)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrOverrideInProgress
+	}
+	return err
+}
+
+func (r *OverrideRepo) Get(ctx context.Context, id string) (*Override, error) {
+	var ov Override
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&ov)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOverrideNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ov, nil
+}
+
+// Resolve moves an override out of PENDING_APPROVAL. Only the first caller
+// succeeds; later ones get ErrOverrideResolved.
+func (r *OverrideRepo) Resolve(ctx context.Context, id string, state OverrideState, by string) (*Override, error) {
+	now := time.Now().UTC()
+	filter := bson.M{"_id": id, "state": OverridePendingApproval}
+	update := bson.M{"$set": bson.M{"state": state, "resolved_by": by, "resolved_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var ov Override
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ov)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, getErr := r.Get(ctx, id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrOverrideResolved
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ov, nil
+}
+
+// Reopen puts an override claimed for approval back into PENDING_APPROVAL.
+// It is used when applying the override failed before the payment changed,
+// so the approval can be retried.
+func (r *OverrideRepo) Reopen(ctx context.Context, id string) error {
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": id, "state": OverrideApplied},
+		bson.M{
+			"$set":   bson.M{"state": OverridePendingApproval},
+			"$unset": bson.M{"resolved_by": "", "resolved_at": ""},
+		},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrOverrideInProgress
+	}
+	return err
+}
+
+func (r *OverrideRepo) MarkStale(ctx context.Context, id string) error {
+	_, err := r.col.UpdateOne(ctx, bson.M{"_id": id, "state": OverrideApplied}, bson.M{"$set": bson.M{"state": OverrideStale}})
+	return err
+}
@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type PaymentFilter struct {
+	MerchantID string
+	UserID     string
+	Status     PaymentStatus
+	From       time.Time
+	To         time.Time
+}
+
+// List returns payments newest first. The cursor is opaque to callers and
+// encodes the (created_at, _id) of the last payment on the previous page.
+func (r *PaymentRepo) List(ctx context.Context, f PaymentFilter, cursor string, limit int) ([]Payment, string, error) {
+	filter, err := listFilter(f, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cur.Close(ctx)
+
+	payments := make([]Payment, 0, limit)
+	if err := cur.All(ctx, &payments); err != nil {
+		return nil, "", err
+	}
+	payments, next := page(payments, limit)
+	return payments, next, nil
+}
+
+// listFilter builds the query for one page. Payments sharing the cursor's
+// created_at are ordered by _id, so a page boundary between equal
+// timestamps neither repeats nor skips a payment.
+func listFilter(f PaymentFilter, cursor string) (bson.M, error) {
+	filter := bson.M{}
+	if f.MerchantID != "" {
+		filter["merchant_id"] = f.MerchantID
+	}
+	if f.UserID != "" {
+		filter["user_id"] = f.UserID
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+
+	createdAt := bson.M{}
+	if !f.From.IsZero() {
+		createdAt["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		createdAt["$lt"] = f.To
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	if cursor != "" {
+		ts, id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": ts}},
+			bson.M{"created_at": ts, "_id": bson.M{"$lt": id}},
+		}
+	}
+	return filter, nil
+}
+
+// page trims a result fetched with limit+1 to limit and returns the cursor
+// for the next page, which is empty on the last one.
+func page(payments []Payment, limit int) ([]Payment, string) {
+	if len(payments) <= limit {
+		return payments, ""
+	}
+	payments = payments[:limit]
+	last := payments[len(payments)-1]
+	return payments, encodeCursor(last.CreatedAt, last.ID)
+}
+
+func (r *PaymentRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "merchant_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "correlation_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "timeout_event", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"timeout_event": bson.M{"$exists": true}}),
+		},
+		{
+			Keys:    bson.D{{Key: "challenge.expires_at", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"status": StatusChallengeRequired}),
+		},
+		{
+			Keys:    bson.D{{Key: "experiment.experiment_id", Value: 1}, {Key: "experiment.arm", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"experiment": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "merchant_id", Value: 1}, {Key: "idempotency_key", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"idempotency_key": bson.M{"$exists": true}}),
+		},
+	})
+	return err
+}
+
+func encodeCursor(ts time.Time, id string) string {
+	raw := strconv.FormatInt(ts.UnixMilli(), 10) + ":" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	ms, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return time.UnixMilli(n).UTC(), id, nil
+}
+
+// CountByUserSince counts the user's payments created at or after since.
+func (r *PaymentRepo) CountByUserSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	n, err := r.col.CountDocuments(ctx, bson.M{"user_id": userID, "created_at": bson.M{"$gte": since}})
+	return int(n), err
+}
@@ -0,0 +1,197 @@
+package repo
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		ts   time.Time
+		id   string
+		want time.Time
+	}{
+		{"milliseconds", time.Date(2026, 3, 4, 5, 6, 7, 8000000, time.UTC), "p-1", time.Date(2026, 3, 4, 5, 6, 7, 8000000, time.UTC)},
+		{"truncated to milliseconds like mongo", time.Date(2026, 3, 4, 5, 6, 7, 8999999, time.UTC), "p-1", time.Date(2026, 3, 4, 5, 6, 7, 8000000, time.UTC)},
+		{"non utc", time.Date(2026, 3, 4, 7, 6, 7, 0, time.FixedZone("CEST", 2*3600)), "p-1", time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{"id with colons", time.UnixMilli(1).UTC(), "merchant:p:1", time.UnixMilli(1).UTC()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, id, err := decodeCursor(encodeCursor(tt.ts, tt.id))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ts.Equal(tt.want) || ts.Location() != time.UTC || id != tt.id {
+				t.Errorf("decodeCursor = %v, %q; want %v, %q", ts, id, tt.want, tt.id)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	valid := encodeCursor(time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC), "p-1")
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"padded", valid + "="},
+		{"standard alphabet", base64.StdEncoding.EncodeToString([]byte("1772600767000:p-1"))},
+		{"no separator", raw("1772600767000")},
+		{"no id", raw("1772600767000:")},
+		{"timestamp is not a number", raw("yesterday:p-1")},
+		{"timestamp overflows", raw("99999999999999999999:p-1")},
+		{"empty timestamp", raw(":p-1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor(%q) = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+			if _, err := listFilter(PaymentFilter{}, tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("listFilter(%q) = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	ts := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	got, err := listFilter(PaymentFilter{MerchantID: "m-1", Status: StatusApproved, From: from, To: to}, encodeCursor(ts, "p-5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bson.M{
+		"merchant_id": "m-1",
+		"status":      StatusApproved,
+		"created_at":  bson.M{"$gte": from, "$lt": to},
+		"$or": bson.A{
+			bson.M{"created_at": bson.M{"$lt": ts}},
+			bson.M{"created_at": ts, "_id": bson.M{"$lt": "p-5"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("listFilter = %v, want %v", got, want)
+	}
+
+	if got, err := listFilter(PaymentFilter{}, ""); err != nil || len(got) != 0 {
+		t.Errorf("listFilter without filters = %v, %v; want an empty filter", got, err)
+	}
+}
+
+// afterCursor applies the $or clause listFilter builds the way mongo would.
+func afterCursor(t *testing.T, p Payment, filter bson.M) bool {
+	t.Helper()
+	clauses, ok := filter["$or"].(bson.A)
+	if !ok {
+		return true
+	}
+	for _, c := range clauses {
+		match := true
+		for field, cond := range c.(bson.M) {
+			switch field {
+			case "created_at":
+				if lt, ok := cond.(bson.M); ok {
+					match = match && p.CreatedAt.Before(lt["$lt"].(time.Time))
+				} else {
+					match = match && p.CreatedAt.Equal(cond.(time.Time))
+				}
+			case "_id":
+				match = match && p.ID < cond.(bson.M)["$lt"].(string)
+			default:
+				t.Fatalf("unexpected field %s in cursor clause", field)
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func TestListPagesThroughEqualTimestamps(t *testing.T) {
+	t0 := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	all := []Payment{
+		{ID: "p-1", CreatedAt: t0},
+		{ID: "p-2", CreatedAt: t0.Add(time.Second)},
+		{ID: "p-3", CreatedAt: t0.Add(time.Second)},
+		{ID: "p-4", CreatedAt: t0.Add(time.Second)},
+		{ID: "p-5", CreatedAt: t0.Add(time.Second)},
+		{ID: "p-6", CreatedAt: t0.Add(2 * time.Second)},
+		{ID: "p-7", CreatedAt: t0.Add(2 * time.Second)},
+	}
+	// created_at desc, _id desc
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.After(all[j].CreatedAt)
+		}
+		return all[i].ID > all[j].ID
+	})
+
+	for _, limit := range []int{1, 2, 3, 7, 10} {
+		var seen []string
+		var cursor string
+		pages := 0
+		for {
+			filter, err := listFilter(PaymentFilter{}, cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fetched []Payment
+			for _, p := range all {
+				if afterCursor(t, p, filter) && len(fetched) < limit+1 {
+					fetched = append(fetched, p)
+				}
+			}
+			got, next := page(fetched, limit)
+			if len(got) > limit {
+				t.Fatalf("limit %d: page of %d payments", limit, len(got))
+			}
+			for _, p := range got {
+				seen = append(seen, p.ID)
+			}
+			pages++
+			if next == "" {
+				break
+			}
+			if pages > len(all) {
+				t.Fatalf("limit %d: paging did not terminate", limit)
+			}
+			cursor = next
+		}
+
+		want := []string{"p-7", "p-6", "p-5", "p-4", "p-3", "p-2", "p-1"}
+		if !reflect.DeepEqual(seen, want) {
+			t.Errorf("limit %d: paged %v, want %v", limit, seen, want)
+		}
+		if wantPages := (len(all) + limit - 1) / limit; pages != wantPages {
+			t.Errorf("limit %d: %d pages, want %d", limit, pages, wantPages)
+		}
+	}
+}
+
+func TestPageLastPage(t *testing.T) {
+	ps := []Payment{{ID: "p-2"}, {ID: "p-1"}}
+	if got, next := page(ps, 2); len(got) != 2 || next != "" {
+		t.Errorf("page of exactly limit = %d payments, cursor %q; want 2 and no cursor", len(got), next)
+	}
+	if got, next := page(nil, 2); len(got) != 0 || next != "" {
+		t.Errorf("empty page = %d payments, cursor %q; want none", len(got), next)
+	}
+	got, next := page(append(ps, Payment{ID: "p-0"}), 2)
+	if len(got) != 2 || next != encodeCursor(time.Time{}, "p-1") {
+		t.Errorf("page with one extra = %d payments, cursor %q; want 2 and a cursor at p-1", len(got), next)
+	}
+}
@@ -3,10 +3,13 @@ package repo
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/lease"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type PaymentStatus string
@@ -16,20 +19,135 @@ const (
 	StatusApproved PaymentStatus = "APPROVED"
 	StatusDeclined PaymentStatus = "DECLINED"
 	StatusFailed   PaymentStatus = "FAILED"
+	StatusReview   PaymentStatus = "REVIEW"
+
+	StatusChallengeRequired PaymentStatus = "CHALLENGE_REQUIRED"
+
+	StatusPartiallyRefunded PaymentStatus = "PARTIALLY_REFUNDED"
+	StatusRefunded          PaymentStatus = "REFUNDED"
+	StatusReversed          PaymentStatus = "REVERSED"
+)
+
+var (
+	ErrPaymentNotFound  = errors.New("payment not found")
+	ErrDuplicatePayment = errors.New("payment already exists")
+	ErrStaleStatus      = errors.New("payment is no longer in the expected status")
 )
 
 type Payment struct {
-	ID            string        `bson:"_id"`
-	UserID        string        `bson:"user_id"`
-	Amount        int64         `bson:"amount"`
-	Currency      string        `bson:"currency"`
-	MerchantID    string        `bson:"merchant_id"`
-	CreatedAt     time.Time     `bson:"created_at"`
-	Status        PaymentStatus `bson:"status"`
-	RiskScore     float64       `bson:"risk_score,omitempty"`
-	RiskReason    string        `bson:"risk_reason,omitempty"`
-	CorrelationID string        `bson:"correlation_id"`
-	Version       int64         `bson:"version"`
+	ID                    string                `bson:"_id" json:"id"`
+	UserID                string                `bson:"user_id" json:"user_id"`
+	Amount                int64                 `bson:"amount" json:"amount"`
+	Currency              string                `bson:"currency" json:"currency"`
+	MerchantID            string                `bson:"merchant_id" json:"merchant_id"`
+	CreatedAt             time.Time             `bson:"created_at" json:"created_at"`
+	Status                PaymentStatus         `bson:"status" json:"status"`
+	RiskScore             float64               `bson:"risk_score,omitempty" json:"risk_score"`
+	RiskReason            string                `bson:"risk_reason,omitempty" json:"risk_reason"`
+	CorrelationID         string                `bson:"correlation_id" json:"correlation_id"`
+	Version               int64                 `bson:"version" json:"version"`
+	IdempotencyKey        string                `bson:"idempotency_key,omitempty" json:"idempotency_key,omitempty"`
+	RequestHash           string                `bson:"request_hash,omitempty" json:"-"`
+	PolicyVersion         int64                 `bson:"policy_version,omitempty" json:"policy_version,omitempty"`
+	InstrumentFingerprint string                `bson:"instrument_fingerprint,omitempty" json:"instrument_fingerprint,omitempty"`
+	Experiment            *ExperimentAssignment `bson:"experiment,omitempty" json:"experiment,omitempty"`
+	ReasonCodes           []string              `bson:"reason_codes,omitempty" json:"reason_codes,omitempty"`
+	Contributions         []Contribution        `bson:"contributions,omitempty" json:"contributions,omitempty"`
+	Labels                []Label               `bson:"labels,omitempty" json:"labels,omitempty"`
+	Fraud                 *bool                 `bson:"fraud,omitempty" json:"fraud,omitempty"`
+	Chargebacked          bool                  `bson:"chargebacked,omitempty" json:"chargebacked,omitempty"`
+	Model                 *ModelScore           `bson:"model,omitempty" json:"model,omitempty"`
+	Challenge             *Challenge            `bson:"challenge,omitempty" json:"challenge,omitempty"`
+	Refunds               []Refund              `bson:"refunds,omitempty" json:"refunds,omitempty"`
+	RefundedAmount        int64                 `bson:"refunded_amount,omitempty" json:"refunded_amount,omitempty"`
+	Reporting             *ReportingAmount      `bson:"reporting,omitempty" json:"reporting,omitempty"`
+	// TimeoutEvent is the ID of the sweeper's decision event until it is
+	// in the outbox.
+	TimeoutEvent string `bson:"timeout_event,omitempty" json:"-"`
+}
+
+// Label is later feedback on whether a payment was fraudulent. ID is the
+// issuer's or importer's identifier and makes attaching a label idempotent.
+type Label struct {
+	ID         string    `bson:"id" json:"id"`
+	Source     string    `bson:"source" json:"source"`
+	Fraud      bool      `bson:"fraud" json:"fraud"`
+	ReasonCode string    `bson:"reason_code,omitempty" json:"reason_code,omitempty"`
+	Amount     int64     `bson:"amount,omitempty" json:"amount,omitempty"`
+	OccurredAt time.Time `bson:"occurred_at" json:"occurred_at"`
+	ReceivedAt time.Time `bson:"received_at" json:"received_at"`
+}
+
+// LabelSourceChargeback marks labels that came from a card network dispute.
+const LabelSourceChargeback = "chargeback"
+
+// Contribution is how much one feature moved the risk score.
+type Contribution struct {
+	Feature string  `bson:"feature" json:"feature"`
+	Value   float64 `bson:"value" json:"value"`
+}
+
+// ModelScore is the in-process model's score, kept next to the risk
+// engine's for comparison, with the features it was computed from.
+type ModelScore struct {
+	Version  string             `bson:"version" json:"version"`
+	Score    float64            `bson:"score" json:"score"`
+	Features map[string]float64 `bson:"features,omitempty" json:"features,omitempty"`
+	ScoredAt time.Time          `bson:"scored_at" json:"scored_at"`
+}
+
+// ReportingAmount is the payment amount converted into the reporting
+// currency at intake, with the rate used and the date it took effect.
+type ReportingAmount struct {
+	Currency string    `bson:"currency" json:"currency"`
+	Amount   int64     `bson:"amount" json:"amount"`
+	Rate     string    `bson:"rate" json:"rate"`
+	RateFrom time.Time `bson:"rate_from" json:"rate_from"`
+}
+
+// ReportingValue returns the payment's amount in the given reporting
+// currency: the amount converted at intake, or the payment's own amount if
+// it was made in that currency. A payment in another currency that was
+// never converted has no comparable amount.
+func (p Payment) ReportingValue(currency string) (int64, bool) {
+	if p.Reporting != nil && strings.EqualFold(p.Reporting.Currency, currency) {
+		return p.Reporting.Amount, true
+	}
+	if strings.EqualFold(p.Currency, currency) {
+		return p.Amount, true
+	}
+	return 0, false
+}
+
+// Explanation is the structured part of a decision: registered reason codes,
+// the feature contributions behind the score and the local model's score.
+type Explanation struct {
+	ReasonCodes   []string
+	Contributions []Contribution
+	Model         *ModelScore
+}
+
+// ExperimentAssignment is the experiment arm a payment was bucketed into at
+// intake. The arm's thresholds are copied so later edits to the experiment
+// cannot change how an in-flight payment is decided; control arms carry no
+// thresholds and follow the merchant's regular policy.
+type ExperimentAssignment struct {
+	ExperimentID string     `bson:"experiment_id" json:"experiment_id"`
+	Arm          string     `bson:"arm" json:"arm"`
+	Policy       *ArmPolicy `bson:"policy,omitempty" json:"policy,omitempty"`
+}
+
+type ArmPolicy struct {
+	ApproveBelow float64 `bson:"approve_below" json:"approve_below"`
+	DeclineFrom  float64 `bson:"decline_from" json:"decline_from"`
+}
+
+func (a ArmPolicy) Validate() error {
+	return MerchantPolicy{ApproveBelow: a.ApproveBelow, DeclineFrom: a.DeclineFrom}.Validate()
+}
+
+func (a ArmPolicy) Decide(score float64) PaymentStatus {
+	return MerchantPolicy{ApproveBelow: a.ApproveBelow, DeclineFrom: a.DeclineFrom}.Decide(score)
 }
 
 type PaymentRepo struct {
@@ -40,7 +158,21 @@ func NewPaymentRepo(db *mongo.Database) *PaymentRepo {
 	return &PaymentRepo{col: db.Collection("payments")}
 }
 
-func (r *PaymentRepo) UpdateDecision(ctx context.Context, id string, status PaymentStatus, score float64, reason string) error {
+func (r *PaymentRepo) Insert(ctx context.Context, p Payment) error {
+	_, err := r.col.InsertOne(ctx, p)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicatePayment
+	}
+	return err
+}
+
+func (r *PaymentRepo) GetByIdempotencyKey(ctx context.Context, merchantID, key string) (*Payment, error) {
+	return r.findOne(ctx, bson.M{"merchant_id": merchantID, "idempotency_key": key})
+}
+
+// UpdateDecision overwrites the decision unconditionally and returns the
+// status the payment had before.
+func (r *PaymentRepo) UpdateDecision(ctx context.Context, id string, status PaymentStatus, score float64, reason string) (PaymentStatus, error) {
 	filter := bson.M{"_id": id}
 	update := bson.M{
 		"$set": bson.M{
@@ -50,22 +182,170 @@ func (r *PaymentRepo) UpdateDecision(ctx context.Context, id string, status Paym
 		},
 		"$inc": bson.M{"version": 1},
 	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.Before).
+		SetProjection(bson.M{"status": 1})
+
+	var before Payment
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", ErrPaymentNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return before.Status, nil
+}
+
+func (r *PaymentRepo) Get(ctx context.Context, id string) (*Payment, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *PaymentRepo) GetByCorrelationID(ctx context.Context, correlationID string) (*Payment, error) {
+	return r.findOne(ctx, bson.M{"correlation_id": correlationID})
+}
+
+func (r *PaymentRepo) findOne(ctx context.Context, filter bson.M) (*Payment, error) {
+	var p Payment
+	err := r.col.FindOne(ctx, filter).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// TransitionDecision applies a decision only while the payment is still in
+// the from status, so concurrent writers cannot both win.
+func (r *PaymentRepo) TransitionDecision(ctx context.Context, id string, from, to PaymentStatus, score float64, reason string) error {
+	return r.transition(ctx, id, from, bson.M{
+		"status":      to,
+		"risk_score":  score,
+		"risk_reason": reason,
+	})
+}
+
+// TransitionWithPolicy is TransitionDecision for decisions derived from a
+// merchant policy, recording the policy version that was applied and the
+// decision's explanation.
+func (r *PaymentRepo) TransitionWithPolicy(ctx context.Context, id string, from, to PaymentStatus, score float64, reason string, policyVersion int64, exp Explanation) error {
+	set := bson.M{
+		"status":         to,
+		"risk_score":     score,
+		"risk_reason":    reason,
+		"policy_version": policyVersion,
+	}
+	if len(exp.ReasonCodes) > 0 {
+		set["reason_codes"] = exp.ReasonCodes
+	}
+	if len(exp.Contributions) > 0 {
+		set["contributions"] = exp.Contributions
+	}
+	if exp.Model != nil {
+		set["model"] = exp.Model
+	}
+	return r.transition(ctx, id, from, set)
+}
+
+// TimeOut is TransitionWithPolicy for the timeout sweeper. The payment keeps
+// eventID as its pending timeout event until TimeoutEventWritten clears it,
+// so an event lost to a crash right after the transition is written by a
+// later sweep.
+func (r *PaymentRepo) TimeOut(ctx context.Context, id string, to PaymentStatus, score float64, reason string, exp Explanation, eventID string) error {
+	set := bson.M{
+		"status":        to,
+		"risk_score":    score,
+		"risk_reason":   reason,
+		"timeout_event": eventID,
+	}
+	if len(exp.ReasonCodes) > 0 {
+		set["reason_codes"] = exp.ReasonCodes
+	}
+	if len(exp.Contributions) > 0 {
+		set["contributions"] = exp.Contributions
+	}
+	return r.transition(ctx, id, StatusPending, set)
+}
+
+// TimeoutEventWritten clears the pending timeout event once it is stored.
+func (r *PaymentRepo) TimeoutEventWritten(ctx context.Context, id string) error {
+	_, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"timeout_event": ""}})
+	return err
+}
+
+// FindPendingTimeoutEvents returns payments the sweeper finalized without
+// storing their event.
+func (r *PaymentRepo) FindPendingTimeoutEvents(ctx context.Context, limit int) ([]Payment, error) {
+	cur, err := r.col.Find(ctx, bson.M{"timeout_event": bson.M{"$exists": true}}, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var payments []Payment
+	if err := cur.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *PaymentRepo) transition(ctx context.Context, id string, from PaymentStatus, set bson.M) error {
+	filter := bson.M{"_id": id, "status": from}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"version": 1},
+	}
+	lease.Fence(ctx, filter, update)
 
 	res, err := r.col.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
 	if res.MatchedCount == 0 {
-		return errors.New("payment not found")
+		return ErrStaleStatus
 	}
 	return nil
 }
 
-func (r *PaymentRepo) Get(ctx context.Context, id string) (*Payment, error) {
-	var p Payment
-	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&p)
+// AddLabel appends l to the payment's labels unless a label with the same ID
+// is already attached, and makes it the payment's current fraud verdict. The
+// boolean reports whether the label was new.
+func (r *PaymentRepo) AddLabel(ctx context.Context, id string, l Label) (bool, error) {
+	set := bson.M{"fraud": l.Fraud}
+	if l.Source == LabelSourceChargeback {
+		set["chargebacked"] = true
+	}
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": id, "labels.id": bson.M{"$ne": l.ID}},
+		bson.M{"$push": bson.M{"labels": l}, "$set": set},
+	)
+	if err != nil {
+		return false, err
+	}
+	if res.MatchedCount == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *PaymentRepo) FindPendingBefore(ctx context.Context, cutoff time.Time, limit int) ([]Payment, error) {
+	filter := bson.M{"status": StatusPending, "created_at": bson.M{"$lt": cutoff}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(int64(limit))
+
+	cur, err := r.col.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
-	return &p, nil
+	defer cur.Close(ctx)
+
+	var payments []Payment
+	if err := cur.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
 }
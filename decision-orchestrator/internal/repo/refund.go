@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	ErrInvalidRefund    = errors.New("invalid refund")
+	ErrRefundNotAllowed = errors.New("refund not allowed")
+	ErrRefundConflict   = errors.New("refund id reused with a different refund")
+)
+
+type RefundKind string
+
+const (
+	// RefundKindRefund returns part or all of a settled payment.
+	RefundKindRefund RefundKind = "refund"
+	// RefundKindReversal cancels the whole payment, typically before
+	// settlement, and ends its lifecycle.
+	RefundKindReversal RefundKind = "reversal"
+)
+
+// Refund is money returned on an approved payment. ID comes from the caller
+// and makes recording a refund idempotent.
+type Refund struct {
+	ID          string     `bson:"id" json:"id"`
+	Kind        RefundKind `bson:"kind" json:"kind"`
+	Amount      int64      `bson:"amount" json:"amount"`
+	Reason      string     `bson:"reason,omitempty" json:"reason,omitempty"`
+	RequestedBy string     `bson:"requested_by,omitempty" json:"requested_by,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// FindRefund returns the refund with the given ID, if recorded.
+func (p Payment) FindRefund(id string) (Refund, bool) {
+	for _, r := range p.Refunds {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Refund{}, false
+}
+
+// ApplyRefund checks ref against the payment and returns the status the
+// payment moves to. Refunds may follow each other until the amount is used
+// up; a reversal must cover the whole amount of a payment nothing was
+// refunded on yet, which an APPROVED payment never has.
+func (p Payment) ApplyRefund(ref Refund) (PaymentStatus, error) {
+	if ref.Amount <= 0 {
+		return "", fmt.Errorf("%w: amount must be positive", ErrInvalidRefund)
+	}
+	switch ref.Kind {
+	case RefundKindRefund:
+		if p.Status != StatusApproved && p.Status != StatusPartiallyRefunded {
+			return "", fmt.Errorf("%w: payment is %s", ErrRefundNotAllowed, p.Status)
+		}
+		remaining := p.Amount - p.RefundedAmount
+		if ref.Amount > remaining {
+			return "", fmt.Errorf("%w: amount %d exceeds the %d left to refund", ErrRefundNotAllowed, ref.Amount, remaining)
+		}
+		if ref.Amount == remaining {
+			return StatusRefunded, nil
+		}
+		return StatusPartiallyRefunded, nil
+	case RefundKindReversal:
+		if p.Status != StatusApproved {
+			return "", fmt.Errorf("%w: payment is %s", ErrRefundNotAllowed, p.Status)
+		}
+		if ref.Amount != p.Amount {
+			return "", fmt.Errorf("%w: a reversal must cover the payment amount %d", ErrRefundNotAllowed, p.Amount)
+		}
+		return StatusReversed, nil
+	}
+	return "", fmt.Errorf("%w: kind must be %s or %s", ErrInvalidRefund, RefundKindRefund, RefundKindReversal)
+}
+
+// AfterRefund replays the stored refunds up to the one with the given ID and
+// returns the payment as it was right after that refund, with the status it
+// had before. Refunds start from APPROVED, so the history is enough to
+// rebuild the refund's event later.
+func (p Payment) AfterRefund(id string) (Payment, PaymentStatus, error) {
+	cur := p
+	cur.Status = StatusApproved
+	cur.Refunds = nil
+	cur.RefundedAmount = 0
+	for _, ref := range p.Refunds {
+		previous := cur.Status
+		status, err := cur.ApplyRefund(ref)
+		if err != nil {
+			return Payment{}, "", fmt.Errorf("refund %s: %w", ref.ID, err)
+		}
+		cur.Refunds = append(cur.Refunds, ref)
+		cur.RefundedAmount += ref.Amount
+		cur.Status = status
+		if ref.ID == id {
+			return cur, previous, nil
+		}
+	}
+	return Payment{}, "", fmt.Errorf("refund %s is not recorded on payment %s", id, p.ID)
+}
+
+// AddRefund appends ref and moves the payment to status, provided nothing
+// changed the payment since version was read. ErrStaleStatus means it did
+// and the refund must be checked again.
+func (r *PaymentRepo) AddRefund(ctx context.Context, id string, version int64, ref Refund, status PaymentStatus) error {
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": id, "version": version},
+		bson.M{
+			"$push": bson.M{"refunds": ref},
+			"$set":  bson.M{"status": status},
+			"$inc":  bson.M{"refunded_amount": ref.Amount, "version": 1},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrStaleStatus
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyRefund(t *testing.T) {
+	tests := []struct {
+		name    string
+		payment Payment
+		ref     Refund
+		want    PaymentStatus
+		wantErr error
+	}{
+		{
+			name:    "partial refund",
+			payment: Payment{Status: StatusApproved, Amount: 1000},
+			ref:     Refund{Kind: RefundKindRefund, Amount: 400},
+			want:    StatusPartiallyRefunded,
+		},
+		{
+			name:    "full refund",
+			payment: Payment{Status: StatusApproved, Amount: 1000},
+			ref:     Refund{Kind: RefundKindRefund, Amount: 1000},
+			want:    StatusRefunded,
+		},
+		{
+			name:    "refund of the remainder",
+			payment: Payment{Status: StatusPartiallyRefunded, Amount: 1000, RefundedAmount: 400},
+			ref:     Refund{Kind: RefundKindRefund, Amount: 600},
+			want:    StatusRefunded,
+		},
+		{
+			name:    "refund beyond the remainder",
+			payment: Payment{Status: StatusPartiallyRefunded, Amount: 1000, RefundedAmount: 400},
+			ref:     Refund{Kind: RefundKindRefund, Amount: 601},
+			wantErr: ErrRefundNotAllowed,
+		},
+		{
+			name:    "refund of a fully refunded payment",
+			payment: Payment{Status: StatusRefunded, Amount: 1000, RefundedAmount: 1000},
+			ref:     Refund{Kind: RefundKindRefund, Amount: 1},
+			wantErr: ErrRefundNotAllowed,
+		},
+		{
+			name:    "refund of a declined payment",
+			payment: Payment{Status: StatusDeclined, Amount: 1000},
+			ref:     Refund{Kind: RefundKindRefund, Amount: 100},
+			wantErr: ErrRefundNotAllowed,
+		},
+		{
+			name:    "reversal",
+			payment: Payment{Status: StatusApproved, Amount: 1000},
+			ref:     Refund{Kind: RefundKindReversal, Amount: 1000},
+			want:    StatusReversed,
+		},
+		{
+			name:    "partial reversal",
+			payment: Payment{Status: StatusApproved, Amount: 1000},
+			ref:     Refund{Kind: RefundKindReversal, Amount: 500},
+			wantErr: ErrRefundNotAllowed,
+		},
+		{
+			name:    "reversal after a refund",
+			payment: Payment{Status: StatusPartiallyRefunded, Amount: 1000, RefundedAmount: 400},
+			ref:     Refund{Kind: RefundKindReversal, Amount: 1000},
+			wantErr: ErrRefundNotAllowed,
+		},
+		{
+			name:    "zero amount",
+			payment: Payment{Status: StatusApproved, Amount: 1000},
+			ref:     Refund{Kind: RefundKindRefund},
+			wantErr: ErrInvalidRefund,
+		},
+		{
+			name:    "negative amount",
+			payment: Payment{Status: StatusApproved, Amount: 1000},
+			ref:     Refund{Kind: RefundKindRefund, Amount: -5},
+			wantErr: ErrInvalidRefund,
+		},
+		{
+			name:    "unknown kind",
+			payment: Payment{Status: StatusApproved, Amount: 1000},
+			ref:     Refund{Kind: "chargeback", Amount: 100},
+			wantErr: ErrInvalidRefund,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.payment.ApplyRefund(tt.ref)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ApplyRefund = %s, %v; want %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ApplyRefund = %s, %v; want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAfterRefund(t *testing.T) {
+	p := Payment{
+		ID:             "p-1",
+		Status:         StatusRefunded,
+		Amount:         1000,
+		RefundedAmount: 1000,
+		Refunds: []Refund{
+			{ID: "r-1", Kind: RefundKindRefund, Amount: 300},
+			{ID: "r-2", Kind: RefundKindRefund, Amount: 300},
+			{ID: "r-3", Kind: RefundKindRefund, Amount: 400},
+		},
+	}
+	tests := []struct {
+		id       string
+		previous PaymentStatus
+		status   PaymentStatus
+		refunded int64
+	}{
+		{"r-1", StatusApproved, StatusPartiallyRefunded, 300},
+		{"r-2", StatusPartiallyRefunded, StatusPartiallyRefunded, 600},
+		{"r-3", StatusPartiallyRefunded, StatusRefunded, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			after, previous, err := p.AfterRefund(tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if previous != tt.previous || after.Status != tt.status || after.RefundedAmount != tt.refunded {
+				t.Errorf("AfterRefund = %s -> %s with %d refunded, want %s -> %s with %d",
+					previous, after.Status, after.RefundedAmount, tt.previous, tt.status, tt.refunded)
+			}
+		})
+	}
+
+	reversed := Payment{ID: "p-2", Status: StatusReversed, Amount: 500, RefundedAmount: 500,
+		Refunds: []Refund{{ID: "v-1", Kind: RefundKindReversal, Amount: 500}}}
+	if after, previous, err := reversed.AfterRefund("v-1"); err != nil || previous != StatusApproved || after.Status != StatusReversed {
+		t.Errorf("AfterRefund(v-1) = %s -> %s, %v; want APPROVED -> REVERSED", previous, after.Status, err)
+	}
+
+	if _, _, err := p.AfterRefund("r-9"); err == nil {
+		t.Error("AfterRefund of an unknown refund succeeded")
+	}
+}
@@ -0,0 +1,207 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ShadowSide is one decision source's answer for a payment and the status
+// it led, or would have led, to.
+type ShadowSide struct {
+	Source        string        `bson:"source" json:"source"`
+	Decision      string        `bson:"decision" json:"decision"`
+	Status        PaymentStatus `bson:"status" json:"status"`
+	Score         float64       `bson:"score" json:"score"`
+	Reason        string        `bson:"reason" json:"reason"`
+	PolicyVersion int64         `bson:"policy_version,omitempty" json:"policy_version,omitempty"`
+	DecidedAt     time.Time     `bson:"decided_at" json:"decided_at"`
+}
+
+// ShadowComparison pairs the decision applied to a payment with the one a
+// candidate source made for it. Agree is unset until both sides arrived.
+type ShadowComparison struct {
+	PaymentID  string      `bson:"_id" json:"payment_id"`
+	MerchantID string      `bson:"merchant_id" json:"merchant_id"`
+	Primary    *ShadowSide `bson:"primary,omitempty" json:"primary,omitempty"`
+	Shadow     *ShadowSide `bson:"shadow,omitempty" json:"shadow,omitempty"`
+	Agree      *bool       `bson:"agree,omitempty" json:"agree,omitempty"`
+	UpdatedAt  time.Time   `bson:"updated_at" json:"updated_at"`
+}
+
+type ShadowStats struct {
+	Compared   int64            `json:"compared"`
+	Agreed     int64            `json:"agreed"`
+	Diverged   int64            `json:"diverged"`
+	Incomplete int64            `json:"incomplete"`
+	Agreement  float64          `json:"agreement_rate"`
+	Matrix     map[string]int64 `json:"matrix"`
+}
+
+type ShadowRepo struct {
+	col *mongo.Collection
+}
+
+func NewShadowRepo(db *mongo.Database) *ShadowRepo {
+	return &ShadowRepo{col: db.Collection("shadow_decisions")}
+}
+
+func (r *ShadowRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "updated_at", Value: -1}}},
+		{Keys: bson.D{{Key: "agree", Value: 1}, {Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}},
+	})
+	return err
+}
+
+func (r *ShadowRepo) RecordPrimary(ctx context.Context, p Payment, side ShadowSide) error {
+	return r.record(ctx, p, "primary", side)
+}
+
+func (r *ShadowRepo) RecordShadow(ctx context.Context, p Payment, side ShadowSide) error {
+	return r.record(ctx, p, "shadow", side)
+}
+
+// record sets one side and recomputes agreement in the same pipeline update,
+// so whichever side lands second sees the first regardless of ordering.
+func (r *ShadowRepo) record(ctx context.Context, p Payment, field string, side ShadowSide) error {
+	side.DecidedAt = side.DecidedAt.UTC().Truncate(time.Millisecond)
+	present := func(path string) bson.M {
+		return bson.M{"$ne": bson.A{bson.M{"$type": path}, "missing"}}
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			field:         bson.M{"$literal": side},
+			"merchant_id": p.MerchantID,
+			"updated_at":  time.Now().UTC().Truncate(time.Millisecond),
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"agree": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{present("$primary"), present("$shadow")}},
+				bson.M{"$eq": bson.A{"$primary.status", "$shadow.status"}},
+				"$$REMOVE",
+			}},
+		}}},
+	}
+	_, err := r.col.UpdateOne(ctx, bson.M{"_id": p.ID}, update, options.UpdateOne().SetUpsert(true))
+	return err
+}
+
+func (r *ShadowRepo) Get(ctx context.Context, paymentID string) (*ShadowComparison, error) {
+	var c ShadowComparison
+	if err := r.col.FindOne(ctx, bson.M{"_id": paymentID}).Decode(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Stats counts comparisons updated in [from, to); zero times leave the
+// range open. Matrix keys are "<primary status>-><shadow status>".
+func (r *ShadowRepo) Stats(ctx context.Context, merchantID string, from, to time.Time) (ShadowStats, error) {
+	match := bson.M{}
+	if merchantID != "" {
+		match["merchant_id"] = merchantID
+	}
+	updated := bson.M{}
+	if !from.IsZero() {
+		updated["$gte"] = from
+	}
+	if !to.IsZero() {
+		updated["$lt"] = to
+	}
+	if len(updated) > 0 {
+		match["updated_at"] = updated
+	}
+
+	cur, err := r.col.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"primary": "$primary.status", "shadow": "$shadow.status"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return ShadowStats{}, err
+	}
+	defer cur.Close(ctx)
+
+	var rows []shadowPair
+	if err := cur.All(ctx, &rows); err != nil {
+		return ShadowStats{}, err
+	}
+	return tally(rows), nil
+}
+
+// shadowPair counts comparisons with one combination of statuses. A side
+// that has not arrived yet has an empty status.
+type shadowPair struct {
+	ID struct {
+		Primary PaymentStatus `bson:"primary"`
+		Shadow  PaymentStatus `bson:"shadow"`
+	} `bson:"_id"`
+	Count int64 `bson:"count"`
+}
+
+func tally(rows []shadowPair) ShadowStats {
+	stats := ShadowStats{Matrix: map[string]int64{}}
+	for _, row := range rows {
+		if row.ID.Primary == "" || row.ID.Shadow == "" {
+			stats.Incomplete += row.Count
+			continue
+		}
+		stats.Compared += row.Count
+		if row.ID.Primary == row.ID.Shadow {
+			stats.Agreed += row.Count
+		} else {
+			stats.Diverged += row.Count
+		}
+		stats.Matrix[string(row.ID.Primary)+"->"+string(row.ID.Shadow)] += row.Count
+	}
+	if stats.Compared > 0 {
+		stats.Agreement = float64(stats.Agreed) / float64(stats.Compared)
+	}
+	return stats
+}
+
+// Divergences lists comparisons where the sources disagreed, most recently
+// updated first, paged with the same opaque cursor format as payments.
+func (r *ShadowRepo) Divergences(ctx context.Context, merchantID, cursor string, limit int) ([]ShadowComparison, string, error) {
+	filter := bson.M{"agree": false}
+	if merchantID != "" {
+		filter["merchant_id"] = merchantID
+	}
+	if cursor != "" {
+		ts, id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		filter["$or"] = bson.A{
+			bson.M{"updated_at": bson.M{"$lt": ts}},
+			bson.M{"updated_at": ts, "_id": bson.M{"$lt": id}},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cur.Close(ctx)
+
+	out := make([]ShadowComparison, 0, limit)
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, "", err
+	}
+	var next string
+	if len(out) > limit {
+		out = out[:limit]
+		last := out[len(out)-1]
+		next = encodeCursor(last.UpdatedAt, last.PaymentID)
+	}
+	return out, next, nil
+}
@@ -0,0 +1,47 @@
+package repo
+
+import "testing"
+
+func pair(primary, shadow PaymentStatus, count int64) shadowPair {
+	var p shadowPair
+	p.ID.Primary, p.ID.Shadow, p.Count = primary, shadow, count
+	return p
+}
+
+func TestTally(t *testing.T) {
+	stats := tally([]shadowPair{
+		pair(StatusApproved, StatusApproved, 60),
+		pair(StatusDeclined, StatusDeclined, 15),
+		pair(StatusApproved, StatusDeclined, 20),
+		pair(StatusReview, StatusApproved, 5),
+		// one side still missing
+		pair(StatusApproved, "", 7),
+		pair("", StatusDeclined, 3),
+	})
+
+	if stats.Compared != 100 || stats.Agreed != 75 || stats.Diverged != 25 || stats.Incomplete != 10 {
+		t.Errorf("stats = %+v, want 100 compared, 75 agreed, 25 diverged, 10 incomplete", stats)
+	}
+	if stats.Agreement != 0.75 {
+		t.Errorf("agreement = %v, want 0.75", stats.Agreement)
+	}
+	want := map[string]int64{
+		"APPROVED->APPROVED": 60,
+		"DECLINED->DECLINED": 15,
+		"APPROVED->DECLINED": 20,
+		"REVIEW->APPROVED":   5,
+	}
+	if len(stats.Matrix) != len(want) {
+		t.Errorf("matrix = %v, want %v", stats.Matrix, want)
+	}
+	for k, n := range want {
+		if stats.Matrix[k] != n {
+			t.Errorf("matrix[%s] = %d, want %d", k, stats.Matrix[k], n)
+		}
+	}
+
+	empty := tally(nil)
+	if empty.Compared != 0 || empty.Agreement != 0 || empty.Matrix == nil {
+		t.Errorf("tally(nil) = %+v, want zero counts and an empty matrix", empty)
+	}
+}
@@ -0,0 +1,26 @@
+package repo
+
+// transitions is the payment state machine. Automated decisions move a
+// payment out of PENDING; the remaining edges are analyst overrides.
+// Challenge results and refunds move payments on separately, see
+// ResolveChallenge and Payment.ApplyRefund, and cannot be overridden.
+var transitions = map[PaymentStatus][]PaymentStatus{
+	StatusPending:  {StatusApproved, StatusDeclined, StatusFailed, StatusReview, StatusChallengeRequired},
+	StatusReview:   {StatusApproved, StatusDeclined},
+	StatusDeclined: {StatusApproved},
+	StatusApproved: {StatusDeclined},
+	StatusFailed:   {StatusApproved, StatusDeclined},
+}
+
+func CanTransition(from, to PaymentStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovedStatuses are the statuses of payments that were approved,
+// including those refunded or reversed since.
+var ApprovedStatuses = []PaymentStatus{StatusApproved, StatusPartiallyRefunded, StatusRefunded, StatusReversed}
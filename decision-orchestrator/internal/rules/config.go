@@ -0,0 +1,139 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	TypeAmountThreshold   = "amount_threshold"
+	TypeMerchantBlocklist = "merchant_blocklist"
+	TypeUserVelocity      = "user_velocity"
+	TypeExpr              = "expr"
+)
+
+// File is the JSON rules file:
+//
+//	{
+//	  "decline_score": 0.8,
+//	  "review_score": 0.5,
+//	  "rules": [
+//	    {"name": "large-usd", "type": "amount_threshold", "score": 0.6, "limits": {"USD": 500000}},
+//	    {"name": "blocked", "type": "merchant_blocklist", "decline": true, "merchants": ["m-123"]},
+//	    {"name": "burst", "type": "user_velocity", "score": 0.4, "window": "10m", "max": 5},
+//	    {"name": "night-eur", "type": "expr", "score": 0.3, "reason_code": "night_high_value",
+//	     "when": "currency == \"EUR\" && amount > 100000 && hour < 6"}
+//	  ]
+//	}
+type File struct {
+	DeclineScore float64      `json:"decline_score" bson:"decline_score"`
+	ReviewScore  float64      `json:"review_score" bson:"review_score"`
+	Rules        []RuleConfig `json:"rules" bson:"rules"`
+}
+
+type RuleConfig struct {
+	Name       string           `json:"name" bson:"name"`
+	Type       string           `json:"type" bson:"type"`
+	Score      float64          `json:"score" bson:"score"`
+	ReasonCode string           `json:"reason_code,omitempty" bson:"reason_code,omitempty"`
+	Decline    bool             `json:"decline,omitempty" bson:"decline,omitempty"`
+	Limits     map[string]int64 `json:"limits,omitempty" bson:"limits,omitempty"`
+	Merchants  []string         `json:"merchants,omitempty" bson:"merchants,omitempty"`
+	Window     string           `json:"window,omitempty" bson:"window,omitempty"`
+	Max        int              `json:"max,omitempty" bson:"max,omitempty"`
+	When       string           `json:"when,omitempty" bson:"when,omitempty"`
+}
+
+var ErrInvalidRules = errors.New("invalid rule set")
+
+var defaultReasonCodes = map[string]string{
+	TypeAmountThreshold:   "amount_over_limit",
+	TypeMerchantBlocklist: "merchant_blocked",
+	TypeUserVelocity:      "user_velocity",
+}
+
+func (f File) Build(in Inputs) (*Engine, error) {
+	if f.DeclineScore <= 0 || f.DeclineScore > 1 {
+		return nil, errors.New("decline_score must be in (0, 1]")
+	}
+	if f.ReviewScore <= 0 || f.ReviewScore > f.DeclineScore {
+		return nil, errors.New("review_score must be in (0, decline_score]")
+	}
+
+	var built []Rule
+	names := map[string]bool{}
+	for i, rc := range f.Rules {
+		if rc.Name == "" {
+			return nil, fmt.Errorf("rule %d: name is required", i)
+		}
+		if names[rc.Name] {
+			return nil, fmt.Errorf("rule %s: duplicate name", rc.Name)
+		}
+		names[rc.Name] = true
+		r, err := rc.build(in)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rc.Name, err)
+		}
+		built = append(built, r)
+	}
+	return NewEngine(built, f.DeclineScore, f.ReviewScore), nil
+}
+
+func (rc RuleConfig) build(in Inputs) (Rule, error) {
+	if rc.Score < 0 {
+		return nil, errors.New("score must not be negative")
+	}
+	reason := rc.ReasonCode
+	if reason == "" {
+		reason = defaultReasonCodes[rc.Type]
+	}
+
+	switch rc.Type {
+	case TypeAmountThreshold:
+		if len(rc.Limits) == 0 {
+			return nil, errors.New("limits are required")
+		}
+		limits := map[string]int64{}
+		for cur, limit := range rc.Limits {
+			limits[strings.ToUpper(cur)] = limit
+		}
+		return AmountThreshold{RuleName: rc.Name, Limits: limits, Score: rc.Score, ReasonCode: reason, Decline: rc.Decline}, nil
+	case TypeMerchantBlocklist:
+		merchants := map[string]bool{}
+		for _, m := range rc.Merchants {
+			merchants[m] = true
+		}
+		return MerchantBlocklist{RuleName: rc.Name, Merchants: merchants, Score: rc.Score, ReasonCode: reason, Decline: rc.Decline}, nil
+	case TypeUserVelocity:
+		window, err := time.ParseDuration(rc.Window)
+		if err != nil || window <= 0 {
+			return nil, errors.New("window must be a positive duration")
+		}
+		if rc.Max <= 0 {
+			return nil, errors.New("max must be positive")
+		}
+		if in.Payments == nil {
+			return nil, errors.New("no payment counter available")
+		}
+		return UserVelocity{RuleName: rc.Name, Counter: in.Payments, Window: window, Max: rc.Max, Score: rc.Score, ReasonCode: reason, Decline: rc.Decline}, nil
+	case TypeExpr:
+		when, err := Compile(rc.When)
+		if err != nil {
+			return nil, fmt.Errorf("when: %w", err)
+		}
+		if rc.ReasonCode == "" {
+			return nil, errors.New("reason_code is required for expr rules")
+		}
+		if when.usesVelocity && in.Payments == nil {
+			return nil, errors.New("no payment counter available")
+		}
+		if when.usesTotals && in.Velocity == nil {
+			return nil, errors.New("no velocity store available")
+		}
+		return ExprRule{RuleName: rc.Name, When: when, Inputs: in, Score: rc.Score, ReasonCode: reason, Decline: rc.Decline}, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", rc.Type)
+	}
+}
@@ -0,0 +1,112 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/velocity"
+)
+
+func TestBuild(t *testing.T) {
+	f := File{
+		DeclineScore: 0.8,
+		ReviewScore:  0.5,
+		Rules: []RuleConfig{
+			{Name: "large-usd", Type: TypeAmountThreshold, Score: 0.6, Limits: map[string]int64{"usd": 500000}},
+			{Name: "blocked", Type: TypeMerchantBlocklist, Decline: true, Merchants: []string{"m-123"}},
+			{Name: "burst", Type: TypeUserVelocity, Score: 0.4, Window: "10m", Max: 5},
+			{Name: "night-eur", Type: TypeExpr, Score: 0.3, ReasonCode: "night_high_value", When: `currency == "EUR" && amount > 100000 && hour < 6`},
+		},
+	}
+	e, err := f.Build(Inputs{Payments: countFunc(func(string, time.Time) int { return 0 })})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.rules) != 4 || e.declineScore != 0.8 || e.reviewScore != 0.5 {
+		t.Fatalf("engine = %d rules, %v/%v; want 4 rules, 0.8/0.5", len(e.rules), e.declineScore, e.reviewScore)
+	}
+
+	tests := []struct {
+		name    string
+		payment repo.Payment
+		want    repo.PaymentStatus
+		reasons []string
+	}{
+		// limits are matched whatever the case of the configured currency
+		{"limit in lower case", repo.Payment{Amount: 600000, Currency: "USD"}, repo.StatusReview, []string{"amount_over_limit"}},
+		{"blocked merchant", repo.Payment{MerchantID: "m-123", Currency: "USD"}, repo.StatusDeclined, []string{"merchant_blocked"}},
+		{
+			name:    "expression with its reason code",
+			payment: repo.Payment{Amount: 200000, Currency: "EUR", CreatedAt: time.Date(2026, 5, 6, 3, 0, 0, 0, time.UTC)},
+			want:    repo.StatusApproved,
+			reasons: []string{"night_high_value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := e.Evaluate(context.Background(), tt.payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Decision != tt.want || len(d.ReasonCodes) != len(tt.reasons) || d.ReasonCodes[0] != tt.reasons[0] {
+				t.Errorf("Evaluate = %s %v, want %s %v", d.Decision, d.ReasonCodes, tt.want, tt.reasons)
+			}
+		})
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	counter := countFunc(func(string, time.Time) int { return 0 })
+	full := Inputs{Payments: counter, Velocity: velocity.NewMemoryStore()}
+	rule := func(rc RuleConfig) File {
+		return File{DeclineScore: 0.8, ReviewScore: 0.5, Rules: []RuleConfig{rc}}
+	}
+	tests := []struct {
+		name string
+		file File
+		in   Inputs
+	}{
+		{"no decline score", File{ReviewScore: 0.5}, full},
+		{"decline score over one", File{DeclineScore: 1.2, ReviewScore: 0.5}, full},
+		{"no review score", File{DeclineScore: 0.8}, full},
+		{"review above decline", File{DeclineScore: 0.5, ReviewScore: 0.8}, full},
+		{"rule without a name", rule(RuleConfig{Type: TypeMerchantBlocklist}), full},
+		{
+			name: "duplicate names",
+			file: File{DeclineScore: 0.8, ReviewScore: 0.5, Rules: []RuleConfig{
+				{Name: "blocked", Type: TypeMerchantBlocklist},
+				{Name: "blocked", Type: TypeMerchantBlocklist},
+			}},
+			in: full,
+		},
+		{"negative score", rule(RuleConfig{Name: "r", Type: TypeMerchantBlocklist, Score: -0.1}), full},
+		{"unknown type", rule(RuleConfig{Name: "r", Type: "geo_fence"}), full},
+		{"threshold without limits", rule(RuleConfig{Name: "r", Type: TypeAmountThreshold}), full},
+		{"velocity without a window", rule(RuleConfig{Name: "r", Type: TypeUserVelocity, Max: 5}), full},
+		{"velocity with a bad window", rule(RuleConfig{Name: "r", Type: TypeUserVelocity, Window: "-10m", Max: 5}), full},
+		{"velocity without max", rule(RuleConfig{Name: "r", Type: TypeUserVelocity, Window: "10m"}), full},
+		{"velocity without a counter", rule(RuleConfig{Name: "r", Type: TypeUserVelocity, Window: "10m", Max: 5}), Inputs{}},
+		{"expr that does not compile", rule(RuleConfig{Name: "r", Type: TypeExpr, ReasonCode: "x", When: `amount >`}), full},
+		{"expr without a reason code", rule(RuleConfig{Name: "r", Type: TypeExpr, When: `amount > 1`}), full},
+		{"expr velocity without a counter", rule(RuleConfig{Name: "r", Type: TypeExpr, ReasonCode: "x", When: `velocity("1h") > 5`}), Inputs{Velocity: full.Velocity}},
+		{"expr totals without a store", rule(RuleConfig{Name: "r", Type: TypeExpr, ReasonCode: "x", When: `user_sum("1h") > 5`}), Inputs{Payments: counter}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.file.Build(tt.in); err == nil {
+				t.Error("Build succeeded, want an error")
+			}
+		})
+	}
+
+	// a broken later rule names itself in the error
+	f := File{DeclineScore: 0.8, ReviewScore: 0.5, Rules: []RuleConfig{
+		{Name: "blocked", Type: TypeMerchantBlocklist},
+		{Name: "burst", Type: TypeUserVelocity, Window: "10m"},
+	}}
+	if _, err := f.Build(full); err == nil || err.Error() != "rule burst: max must be positive" {
+		t.Errorf("Build = %v, want the burst rule named", err)
+	}
+}
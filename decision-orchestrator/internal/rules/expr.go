@@ -0,0 +1,604 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/velocity"
+)
+
+// Expressions are small boolean formulas over a payment, for example
+//
+//	amount > 250000 && currency in ["USD", "EUR"]
+//	merchant_id == "m-42" || velocity("10m") > 5
+//
+// Fields: amount, currency, merchant_id, user_id, hour (UTC hour created),
+// reporting_amount (amount in the reporting currency; payments without one
+// fail the rule's evaluation).
+// Functions: velocity(window) counts the user's payments within window;
+// user_count, user_sum, merchant_count and merchant_sum(window) read the
+// velocity counters, where sums are in the payment's currency and windows
+// are at most 24h. user_reporting_sum and merchant_reporting_sum(window)
+// sum all currencies in the reporting currency.
+// Operators: || && ! == != < <= > >= in + - * / and parentheses.
+// Expressions are type checked when compiled and must evaluate to a bool.
+
+type kind int
+
+const (
+	kindNumber kind = iota + 1
+	kindString
+	kindBool
+	kindList
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindNumber:
+		return "number"
+	case kindString:
+		return "string"
+	case kindBool:
+		return "bool"
+	case kindList:
+		return "list"
+	}
+	return "unknown"
+}
+
+type evalEnv struct {
+	ctx context.Context
+	p   repo.Payment
+	in  Inputs
+}
+
+type node struct {
+	kind kind
+	elem kind
+	eval func(env *evalEnv) (any, error)
+}
+
+// Expr is a compiled, type checked expression.
+type Expr struct {
+	src          string
+	root         node
+	usesVelocity bool
+	usesTotals   bool
+}
+
+func (e *Expr) String() string { return e.src }
+
+func (e *Expr) Match(ctx context.Context, p repo.Payment, in Inputs) (bool, error) {
+	v, err := e.root.eval(&evalEnv{ctx: ctx, p: p, in: in})
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+func Compile(src string) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	ps := &parser{toks: toks}
+	root, err := ps.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := ps.peek(); t.typ != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	if root.kind != kindBool {
+		return nil, fmt.Errorf("expression is %s, want bool", root.kind)
+	}
+	return &Expr{src: src, root: root, usesVelocity: ps.usesVelocity, usesTotals: ps.usesTotals}, nil
+}
+
+type tokType int
+
+const (
+	tokEOF tokType = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	typ  tokType
+	text string
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_') {
+				j++
+			}
+			toks = append(toks, token{tokIdent, src[i:j], i})
+			i = j
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, src[i:j], i})
+			i = j
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			toks = append(toks, token{tokString, src[i : j+1], i})
+			i = j + 1
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ",", "+", "-", "*", "/"} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{tokEOF, "end of expression", len(src)}), nil
+}
+
+type parser struct {
+	toks         []token
+	pos          int
+	usesVelocity bool
+	usesTotals   bool
+}
+
+func (ps *parser) peek() token { return ps.toks[ps.pos] }
+
+func (ps *parser) next() token {
+	t := ps.toks[ps.pos]
+	if t.typ != tokEOF {
+		ps.pos++
+	}
+	return t
+}
+
+func (ps *parser) accept(op string) bool {
+	if t := ps.peek(); t.typ == tokOp && t.text == op || t.typ == tokIdent && t.text == op {
+		ps.pos++
+		return true
+	}
+	return false
+}
+
+func (ps *parser) expect(op string) error {
+	if !ps.accept(op) {
+		t := ps.peek()
+		return fmt.Errorf("expected %q at %d, got %q", op, t.pos, t.text)
+	}
+	return nil
+}
+
+func (ps *parser) parseOr() (node, error) {
+	left, err := ps.parseAnd()
+	if err != nil {
+		return node{}, err
+	}
+	for ps.accept("||") {
+		right, err := ps.parseAnd()
+		if err != nil {
+			return node{}, err
+		}
+		if left, err = logical("||", left, right); err != nil {
+			return node{}, err
+		}
+	}
+	return left, nil
+}
+
+func (ps *parser) parseAnd() (node, error) {
+	left, err := ps.parseNot()
+	if err != nil {
+		return node{}, err
+	}
+	for ps.accept("&&") {
+		right, err := ps.parseNot()
+		if err != nil {
+			return node{}, err
+		}
+		if left, err = logical("&&", left, right); err != nil {
+			return node{}, err
+		}
+	}
+	return left, nil
+}
+
+func logical(op string, left, right node) (node, error) {
+	if left.kind != kindBool || right.kind != kindBool {
+		return node{}, fmt.Errorf("%s needs bool operands, got %s and %s", op, left.kind, right.kind)
+	}
+	return node{kind: kindBool, eval: func(env *evalEnv) (any, error) {
+		l, err := left.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		// short circuit so velocity lookups only run when needed
+		if op == "&&" && !l.(bool) || op == "||" && l.(bool) {
+			return l, nil
+		}
+		return right.eval(env)
+	}}, nil
+}
+
+func (ps *parser) parseNot() (node, error) {
+	if ps.accept("!") {
+		inner, err := ps.parseNot()
+		if err != nil {
+			return node{}, err
+		}
+		if inner.kind != kindBool {
+			return node{}, fmt.Errorf("! needs a bool, got %s", inner.kind)
+		}
+		return node{kind: kindBool, eval: func(env *evalEnv) (any, error) {
+			v, err := inner.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			return !v.(bool), nil
+		}}, nil
+	}
+	return ps.parseComparison()
+}
+
+func (ps *parser) parseComparison() (node, error) {
+	left, err := ps.parseAdditive()
+	if err != nil {
+		return node{}, err
+	}
+	t := ps.peek()
+	if !comparisonOps[t.text] || t.typ != tokOp && t.text != "in" {
+		return left, nil
+	}
+	ps.next()
+	right, err := ps.parseAdditive()
+	if err != nil {
+		return node{}, err
+	}
+	return compare(t.text, left, right)
+}
+
+var comparisonOps = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "in": true}
+
+func compare(op string, left, right node) (node, error) {
+	if op == "in" {
+		if right.kind != kindList || right.elem != left.kind {
+			return node{}, fmt.Errorf("in needs a list of %s on the right", left.kind)
+		}
+		return node{kind: kindBool, eval: func(env *evalEnv) (any, error) {
+			l, err := left.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			r, err := right.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range r.([]any) {
+				if item == l {
+					return true, nil
+				}
+			}
+			return false, nil
+		}}, nil
+	}
+
+	if left.kind != right.kind || left.kind == kindList {
+		return node{}, fmt.Errorf("cannot compare %s %s %s", left.kind, op, right.kind)
+	}
+	if left.kind == kindBool && op != "==" && op != "!=" {
+		return node{}, fmt.Errorf("bools only support == and !=")
+	}
+	return node{kind: kindBool, eval: func(env *evalEnv) (any, error) {
+		l, err := left.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "==":
+			return l == r, nil
+		case "!=":
+			return l != r, nil
+		}
+		var c int
+		switch lv := l.(type) {
+		case float64:
+			c = cmpOrdered(lv, r.(float64))
+		case string:
+			c = cmpOrdered(lv, r.(string))
+		}
+		switch op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}}, nil
+}
+
+func cmpOrdered[T float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (ps *parser) parseAdditive() (node, error) {
+	left, err := ps.parseMultiplicative()
+	if err != nil {
+		return node{}, err
+	}
+	for {
+		op := ps.peek().text
+		if ps.peek().typ != tokOp || op != "+" && op != "-" {
+			return left, nil
+		}
+		ps.next()
+		right, err := ps.parseMultiplicative()
+		if err != nil {
+			return node{}, err
+		}
+		if left, err = arithmetic(op, left, right); err != nil {
+			return node{}, err
+		}
+	}
+}
+
+func (ps *parser) parseMultiplicative() (node, error) {
+	left, err := ps.parseUnary()
+	if err != nil {
+		return node{}, err
+	}
+	for {
+		op := ps.peek().text
+		if ps.peek().typ != tokOp || op != "*" && op != "/" {
+			return left, nil
+		}
+		ps.next()
+		right, err := ps.parseUnary()
+		if err != nil {
+			return node{}, err
+		}
+		if left, err = arithmetic(op, left, right); err != nil {
+			return node{}, err
+		}
+	}
+}
+
+func arithmetic(op string, left, right node) (node, error) {
+	if left.kind != kindNumber || right.kind != kindNumber {
+		return node{}, fmt.Errorf("%s needs numbers, got %s and %s", op, left.kind, right.kind)
+	}
+	return node{kind: kindNumber, eval: func(env *evalEnv) (any, error) {
+		l, err := left.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		a, b := l.(float64), r.(float64)
+		switch op {
+		case "+":
+			return a + b, nil
+		case "-":
+			return a - b, nil
+		case "*":
+			return a * b, nil
+		}
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	}}, nil
+}
+
+func (ps *parser) parseUnary() (node, error) {
+	if ps.accept("-") {
+		inner, err := ps.parseUnary()
+		if err != nil {
+			return node{}, err
+		}
+		return arithmetic("-", constant(kindNumber, 0.0), inner)
+	}
+	return ps.parsePrimary()
+}
+
+func constant(k kind, v any) node {
+	return node{kind: k, eval: func(*evalEnv) (any, error) { return v, nil }}
+}
+
+func (ps *parser) parsePrimary() (node, error) {
+	t := ps.next()
+	switch t.typ {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return node{}, fmt.Errorf("bad number %q at %d", t.text, t.pos)
+		}
+		return constant(kindNumber, f), nil
+	case tokString:
+		s, err := strconv.Unquote(t.text)
+		if err != nil {
+			return node{}, fmt.Errorf("bad string at %d", t.pos)
+		}
+		return constant(kindString, s), nil
+	case tokIdent:
+		if ps.peek().typ == tokOp && ps.peek().text == "(" {
+			return ps.parseCall(t)
+		}
+		return field(t)
+	case tokOp:
+		switch t.text {
+		case "(":
+			inner, err := ps.parseOr()
+			if err != nil {
+				return node{}, err
+			}
+			return inner, ps.expect(")")
+		case "[":
+			return ps.parseList(t)
+		}
+	}
+	return node{}, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+func (ps *parser) parseList(open token) (node, error) {
+	var items []any
+	var elem kind
+	for !ps.accept("]") {
+		if len(items) > 0 {
+			if err := ps.expect(","); err != nil {
+				return node{}, err
+			}
+		}
+		t := ps.peek()
+		item, err := ps.parsePrimary()
+		if err != nil {
+			return node{}, err
+		}
+		if t.typ != tokNumber && t.typ != tokString {
+			return node{}, fmt.Errorf("list items must be literals, got %q at %d", t.text, t.pos)
+		}
+		if elem != 0 && item.kind != elem {
+			return node{}, fmt.Errorf("list at %d mixes %s and %s", open.pos, elem, item.kind)
+		}
+		elem = item.kind
+		v, _ := item.eval(nil)
+		items = append(items, v)
+	}
+	if elem == 0 {
+		return node{}, fmt.Errorf("empty list at %d", open.pos)
+	}
+	return node{kind: kindList, elem: elem, eval: func(*evalEnv) (any, error) { return items, nil }}, nil
+}
+
+func field(t token) (node, error) {
+	switch t.text {
+	case "true", "false":
+		return constant(kindBool, t.text == "true"), nil
+	case "amount":
+		return node{kind: kindNumber, eval: func(env *evalEnv) (any, error) { return float64(env.p.Amount), nil }}, nil
+	case "reporting_amount":
+		return node{kind: kindNumber, eval: func(env *evalEnv) (any, error) {
+			if env.p.Reporting == nil {
+				return nil, fmt.Errorf("payment %s has no reporting amount", env.p.ID)
+			}
+			return float64(env.p.Reporting.Amount), nil
+		}}, nil
+	case "hour":
+		return node{kind: kindNumber, eval: func(env *evalEnv) (any, error) { return float64(env.p.CreatedAt.UTC().Hour()), nil }}, nil
+	case "currency":
+		return node{kind: kindString, eval: func(env *evalEnv) (any, error) { return strings.ToUpper(env.p.Currency), nil }}, nil
+	case "merchant_id":
+		return node{kind: kindString, eval: func(env *evalEnv) (any, error) { return env.p.MerchantID, nil }}, nil
+	case "user_id":
+		return node{kind: kindString, eval: func(env *evalEnv) (any, error) { return env.p.UserID, nil }}, nil
+	}
+	return node{}, fmt.Errorf("unknown field %q at %d", t.text, t.pos)
+}
+
+func (ps *parser) parseCall(name token) (node, error) {
+	ps.next() // (
+	var subject velocity.Kind
+	switch name.text {
+	case "velocity":
+	case "user_count", "user_sum", "user_reporting_sum":
+		subject = velocity.KindUser
+	case "merchant_count", "merchant_sum", "merchant_reporting_sum":
+		subject = velocity.KindMerchant
+	default:
+		return node{}, fmt.Errorf("unknown function %q at %d", name.text, name.pos)
+	}
+	arg := ps.next()
+	if arg.typ != tokString {
+		return node{}, fmt.Errorf("%s takes a duration string, got %q at %d", name.text, arg.text, arg.pos)
+	}
+	s, _ := strconv.Unquote(arg.text)
+	window, err := time.ParseDuration(s)
+	if err != nil || window <= 0 {
+		return node{}, fmt.Errorf("%s window %q is not a positive duration", name.text, s)
+	}
+	if err := ps.expect(")"); err != nil {
+		return node{}, err
+	}
+
+	if subject == "" {
+		ps.usesVelocity = true
+		return node{kind: kindNumber, eval: func(env *evalEnv) (any, error) {
+			n, err := env.in.Payments.CountByUserSince(env.ctx, env.p.UserID, env.p.CreatedAt.Add(-window))
+			return float64(n), err
+		}}, nil
+	}
+
+	if window > velocity.Retention {
+		return node{}, fmt.Errorf("%s window %q exceeds %s", name.text, s, velocity.Retention)
+	}
+	ps.usesTotals = true
+	reporting := strings.HasSuffix(name.text, "_reporting_sum")
+	sum := strings.HasSuffix(name.text, "_sum")
+	return node{kind: kindNumber, eval: func(env *evalEnv) (any, error) {
+		id := env.p.UserID
+		if subject == velocity.KindMerchant {
+			id = env.p.MerchantID
+		}
+		totals, err := env.in.Velocity.Totals(env.ctx, subject, id, window, env.p.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if reporting {
+			return float64(velocity.ReportingSum(totals)), nil
+		}
+		if sum {
+			return float64(totals[strings.ToUpper(env.p.Currency)].Sum), nil
+		}
+		var n int64
+		for _, t := range totals {
+			n += t.Count
+		}
+		return float64(n), nil
+	}}, nil
+}
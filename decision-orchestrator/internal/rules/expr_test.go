@@ -0,0 +1,241 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/velocity"
+)
+
+// countFunc is a PaymentCounter backed by a function.
+type countFunc func(userID string, since time.Time) int
+
+func (f countFunc) CountByUserSince(_ context.Context, userID string, since time.Time) (int, error) {
+	return f(userID, since), nil
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"empty", ``},
+		{"not bool", `amount`},
+		{"compare number with string", `amount == "x"`},
+		{"unknown field", `foo > 1`},
+		{"unknown function", `foo("1h") > 1`},
+		{"window is not a string", `velocity(10) > 1`},
+		{"window is not a duration", `velocity("soon") > 1`},
+		{"negative window", `velocity("-1h") > 1`},
+		{"sum window beyond retention", `user_sum("48h") > 1`},
+		{"mixed list", `currency in ["USD", 1]`},
+		{"empty list", `currency in []`},
+		{"list of fields", `currency in [merchant_id]`},
+		{"in with wrong element type", `amount in ["USD"]`},
+		{"logical on numbers", `amount && true`},
+		{"not on a number", `!amount`},
+		{"arithmetic on strings", `currency + 1 > 0`},
+		{"trailing tokens", `true true`},
+		{"unclosed paren", `(amount > 1`},
+		{"unterminated string", `currency == "USD`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.src); err == nil {
+				t.Errorf("Compile(%q) succeeded, want an error", tt.src)
+			}
+		})
+	}
+}
+
+func TestExprMatch(t *testing.T) {
+	p := repo.Payment{
+		ID:         "p-1",
+		UserID:     "u-1",
+		MerchantID: "m-42",
+		Amount:     250000,
+		Currency:   "usd",
+		CreatedAt:  time.Date(2026, 3, 4, 23, 30, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`amount > 100000 && currency in ["USD", "EUR"]`, true},
+		{`amount > 100000 && currency in ["EUR"]`, false},
+		{`currency == "USD"`, true},
+		{`merchant_id == "m-42" || amount > 1000000000`, true},
+		{`user_id != "u-1"`, false},
+		{`amount in [250000, 1]`, true},
+		{`!(amount < 1000)`, true},
+		{`hour >= 22`, true},
+		{`amount / 100 == 2500`, true},
+		{`amount * 2 - 1 > 499998`, true},
+		{`-amount < 0`, true},
+		{`1 + 2 * 3 == 7`, true},
+		{`(1 + 2) * 3 == 9`, true},
+		{`true || false && false`, true},
+		{`"a" < "b"`, true},
+		{`velocity("10m") > 2`, true},
+		{`velocity("10m") > 3`, false},
+	}
+	in := Inputs{Payments: countFunc(func(userID string, since time.Time) int {
+		if userID != "u-1" || !since.Equal(p.CreatedAt.Add(-10*time.Minute)) {
+			return 0
+		}
+		return 3
+	})}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			e, err := Compile(tt.src)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			got, err := e.Match(context.Background(), p, in)
+			if err != nil {
+				t.Fatalf("Match: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprEvalErrors(t *testing.T) {
+	e, err := Compile(`amount / (hour - 23) > 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := repo.Payment{Amount: 100, CreatedAt: time.Date(2026, 3, 4, 23, 0, 0, 0, time.UTC)}
+	if _, err := e.Match(context.Background(), p, Inputs{}); err == nil {
+		t.Error("Match divided by zero without an error")
+	}
+}
+
+func TestExprShortCircuits(t *testing.T) {
+	calls := 0
+	in := Inputs{Payments: countFunc(func(string, time.Time) int {
+		calls++
+		return 100
+	})}
+	e, err := Compile(`amount > 1000 && velocity("1h") > 5`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := e.Match(context.Background(), repo.Payment{Amount: 10}, in)
+	if err != nil || ok {
+		t.Fatalf("Match = %v, %v; want false", ok, err)
+	}
+	if calls != 0 {
+		t.Errorf("velocity looked up %d times for a payment the amount check already rejected", calls)
+	}
+}
+
+func TestExprVelocityTotals(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC)
+	store := velocity.NewMemoryStore()
+	for _, p := range []repo.Payment{
+		{UserID: "u-1", MerchantID: "m-1", Amount: 1000, Currency: "USD", CreatedAt: now.Add(-3 * time.Hour)},
+		{UserID: "u-1", MerchantID: "m-1", Amount: 2000, Currency: "EUR", CreatedAt: now.Add(-30 * time.Minute)},
+		{UserID: "u-2", MerchantID: "m-1", Amount: 4000, Currency: "USD", CreatedAt: now.Add(-10 * time.Minute)},
+	} {
+		if err := store.Record(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := repo.Payment{UserID: "u-1", MerchantID: "m-1", Amount: 500, Currency: "usd", CreatedAt: now}
+	if err := store.Record(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`user_count("1h") == 2`, true},
+		{`user_count("24h") == 3`, true},
+		// sums only count the payment's own currency
+		{`user_sum("24h") == 1500`, true},
+		{`user_sum("1h") == 500`, true},
+		{`merchant_count("1h") == 3`, true},
+		{`merchant_sum("1h") == 4500`, true},
+		{`merchant_sum("24h") > 5000 && user_count("1h") > 1`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			e, err := Compile(tt.src)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			got, err := e.Match(ctx, p, Inputs{Velocity: store})
+			if err != nil {
+				t.Fatalf("Match: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprReporting(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC)
+	store := velocity.NewMemoryStore()
+	for _, p := range []repo.Payment{
+		{UserID: "u-1", MerchantID: "m-1", Amount: 1000, Currency: "EUR", CreatedAt: now.Add(-time.Hour),
+			Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 1100}},
+		{UserID: "u-1", MerchantID: "m-2", Amount: 500, Currency: "USD", CreatedAt: now.Add(-time.Minute),
+			Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 500}},
+		// no rate at intake, left out of reporting sums
+		{UserID: "u-1", MerchantID: "m-1", Amount: 9000, Currency: "SEK", CreatedAt: now.Add(-time.Minute)},
+	} {
+		if err := store.Record(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := repo.Payment{ID: "p-1", UserID: "u-1", MerchantID: "m-1", Amount: 200, Currency: "GBP", CreatedAt: now,
+		Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 250}}
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`reporting_amount == 250`, true},
+		{`user_reporting_sum("24h") == 1600`, true},
+		{`user_reporting_sum("10m") == 500`, true},
+		{`merchant_reporting_sum("24h") == 1100`, true},
+		{`reporting_amount + user_reporting_sum("24h") > 1800`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			e, err := Compile(tt.src)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			got, err := e.Match(ctx, p, Inputs{Velocity: store})
+			if err != nil {
+				t.Fatalf("Match: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	e, err := Compile(`reporting_amount > 100`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Reporting = nil
+	if _, err := e.Match(ctx, p, Inputs{}); err == nil {
+		t.Error("reporting_amount of a payment without one matched, want an error")
+	}
+	if _, err := Compile(`merchant_reporting_sum("25h") > 1`); err == nil {
+		t.Error("reporting sum beyond retention compiled")
+	}
+}
@@ -0,0 +1,266 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoRuleSet      = errors.New("no rule set stored")
+	ErrReadOnlySource = errors.New("rule source is read only")
+)
+
+// Source provides the rule set and a version that changes whenever the rule
+// set does.
+type Source interface {
+	Load(ctx context.Context) (File, string, error)
+}
+
+type FileSource struct {
+	path string
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (s *FileSource) Load(_ context.Context) (File, string, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return File{}, "", err
+	}
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return File{}, "", err
+	}
+	var f File
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return File{}, "", err
+	}
+	return f, info.ModTime().UTC().Format(time.RFC3339Nano) + "/" + strconv.FormatInt(info.Size(), 10), nil
+}
+
+// MongoSource keeps the active rule set as a single document in risk_rules.
+type MongoSource struct {
+	col *mongo.Collection
+}
+
+func NewMongoSource(db *mongo.Database) *MongoSource {
+	return &MongoSource{col: db.Collection("risk_rules")}
+}
+
+type storedRuleSet struct {
+	File      `bson:",inline"`
+	Version   int64     `bson:"version"`
+	UpdatedAt time.Time `bson:"updated_at"`
+	UpdatedBy string    `bson:"updated_by"`
+}
+
+func (s *MongoSource) Load(ctx context.Context) (File, string, error) {
+	var doc storedRuleSet
+	err := s.col.FindOne(ctx, bson.M{"_id": "active"}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return File{}, "", ErrNoRuleSet
+	}
+	if err != nil {
+		return File{}, "", err
+	}
+	return doc.File, strconv.FormatInt(doc.Version, 10), nil
+}
+
+func (s *MongoSource) Save(ctx context.Context, f File, by string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"decline_score": f.DeclineScore,
+			"review_score":  f.ReviewScore,
+			"rules":         f.Rules,
+			"updated_at":    time.Now().UTC(),
+			"updated_by":    by,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	_, err := s.col.UpdateOne(ctx, bson.M{"_id": "active"}, update, options.UpdateOne().SetUpsert(true))
+	return err
+}
+
+type saver interface {
+	Save(ctx context.Context, f File, by string) error
+}
+
+type loadedRuleSet struct {
+	engine  *Engine
+	file    File
+	version string
+}
+
+// Reloader serves evaluations from the latest rule set that compiled. It
+// polls its source and swaps in new versions; a rule set that fails to
+// compile is logged and the previous one stays active. A Mongo source may
+// start out empty: evaluations then fail with ErrNoRuleSet until the first
+// rule set is saved.
+type Reloader struct {
+	log      *zap.Logger
+	source   Source
+	inputs   Inputs
+	interval time.Duration
+	current  atomic.Pointer[loadedRuleSet]
+	hits     sync.Map // rule name -> *atomic.Int64
+	hitCount metric.Int64Counter
+}
+
+func NewReloader(ctx context.Context, l *zap.Logger, source Source, in Inputs, interval time.Duration) (*Reloader, error) {
+	hitCount, err := otel.Meter("decision-orchestrator").Int64Counter(
+		"risk.rule_hits",
+		metric.WithDescription("Local risk rules that fired, by rule"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reloader{log: l, source: source, inputs: in, interval: interval, hitCount: hitCount}
+	if _, err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.Reload(ctx)
+			if err != nil {
+				r.log.Error("rule reload failed, keeping current rules", zap.Error(err), zap.String("version", r.Version()))
+				continue
+			}
+			if changed {
+				r.log.Info("rules reloaded", zap.String("version", r.Version()), zap.Int("rules", len(r.current.Load().file.Rules)))
+			}
+		}
+	}
+}
+
+// Reload loads the source and swaps in its rule set if the version changed
+// and it compiles.
+func (r *Reloader) Reload(ctx context.Context) (bool, error) {
+	f, version, err := r.source.Load(ctx)
+	if errors.Is(err, ErrNoRuleSet) {
+		switch cur := r.current.Load(); {
+		case cur == nil:
+			r.current.Store(&loadedRuleSet{})
+			return true, nil
+		case cur.engine == nil:
+			return false, nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	if cur := r.current.Load(); cur != nil && cur.version == version {
+		return false, nil
+	}
+	engine, err := f.Build(r.inputs)
+	if err != nil {
+		return false, fmt.Errorf("rule set %s: %w", version, err)
+	}
+	r.current.Store(&loadedRuleSet{engine: engine, file: f, version: version})
+	return true, nil
+}
+
+func (r *Reloader) Evaluate(ctx context.Context, p repo.Payment) (Decision, error) {
+	engine := r.current.Load().engine
+	if engine == nil {
+		return Decision{}, ErrNoRuleSet
+	}
+	d, err := engine.Evaluate(ctx, p)
+	if err != nil {
+		return d, err
+	}
+	for _, h := range d.Hits {
+		v, _ := r.hits.LoadOrStore(h.Rule, new(atomic.Int64))
+		v.(*atomic.Int64).Add(1)
+		r.hitCount.Add(ctx, 1, metric.WithAttributes(attribute.String("rule", h.Rule)))
+	}
+	return d, nil
+}
+
+func (r *Reloader) Current() (File, string) {
+	cur := r.current.Load()
+	return cur.file, cur.version
+}
+
+func (r *Reloader) Version() string {
+	return r.current.Load().version
+}
+
+// Hits returns how often each rule fired since the process started.
+func (r *Reloader) Hits() map[string]int64 {
+	out := map[string]int64{}
+	r.hits.Range(func(k, v any) bool {
+		out[k.(string)] = v.(*atomic.Int64).Load()
+		return true
+	})
+	return out
+}
+
+// DryRun evaluates p against the active rule set and, if given, a proposed
+// one, without counting hits.
+func (r *Reloader) DryRun(ctx context.Context, p repo.Payment, proposed *File) (Decision, *Decision, error) {
+	active := r.current.Load().engine
+	if active == nil {
+		return Decision{}, nil, ErrNoRuleSet
+	}
+	current, err := active.Evaluate(ctx, p)
+	if err != nil {
+		return Decision{}, nil, err
+	}
+	if proposed == nil {
+		return current, nil, nil
+	}
+	engine, err := proposed.Build(r.inputs)
+	if err != nil {
+		return Decision{}, nil, fmt.Errorf("%w: %v", ErrInvalidRules, err)
+	}
+	d, err := engine.Evaluate(ctx, p)
+	if err != nil {
+		return Decision{}, nil, err
+	}
+	return current, &d, nil
+}
+
+// Save validates f and stores it in the source, if the source is writable.
+// Other replicas pick the new rules up on their next poll.
+func (r *Reloader) Save(ctx context.Context, f File, by string) error {
+	s, ok := r.source.(saver)
+	if !ok {
+		return ErrReadOnlySource
+	}
+	if _, err := f.Build(r.inputs); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRules, err)
+	}
+	if err := s.Save(ctx, f, by); err != nil {
+		return err
+	}
+	_, err := r.Reload(ctx)
+	return err
+}
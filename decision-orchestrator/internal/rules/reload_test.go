@@ -0,0 +1,60 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.uber.org/zap"
+)
+
+type stubSource struct {
+	file    File
+	version string
+	err     error
+}
+
+func (s *stubSource) Load(context.Context) (File, string, error) {
+	return s.file, s.version, s.err
+}
+
+func TestReloaderStartsWithoutStoredRuleSet(t *testing.T) {
+	ctx := context.Background()
+	src := &stubSource{err: ErrNoRuleSet}
+	r, err := NewReloader(ctx, zap.NewNop(), src, Inputs{}, 0)
+	if err != nil {
+		t.Fatalf("NewReloader with an empty source: %v", err)
+	}
+	p := repo.Payment{Amount: 5000, Currency: "USD"}
+	if _, err := r.Evaluate(ctx, p); !errors.Is(err, ErrNoRuleSet) {
+		t.Errorf("Evaluate = %v, want ErrNoRuleSet", err)
+	}
+	if changed, err := r.Reload(ctx); changed || err != nil {
+		t.Errorf("Reload of a still empty source = %v, %v; want no change and no error", changed, err)
+	}
+
+	src.file = File{DeclineScore: 0.8, ReviewScore: 0.5, Rules: []RuleConfig{
+		{Name: "big", Type: TypeAmountThreshold, Score: 0.9, Limits: map[string]int64{"usd": 1000}},
+	}}
+	src.version, src.err = "1", nil
+	if changed, err := r.Reload(ctx); !changed || err != nil {
+		t.Fatalf("Reload after the first save = %v, %v; want the new rule set", changed, err)
+	}
+	d, err := r.Evaluate(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Decision != repo.StatusDeclined {
+		t.Errorf("decision = %s, want DECLINED", d.Decision)
+	}
+
+	// once rules are active, losing the stored set keeps them
+	src.err = ErrNoRuleSet
+	if _, err := r.Reload(ctx); !errors.Is(err, ErrNoRuleSet) {
+		t.Errorf("Reload = %v, want ErrNoRuleSet", err)
+	}
+	if _, version := r.Current(); version != "1" {
+		t.Errorf("version = %q, want the previous rule set kept", version)
+	}
+}
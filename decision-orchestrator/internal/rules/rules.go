@@ -0,0 +1,180 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/velocity"
+)
+
+// Hit is one rule firing for a payment.
+type Hit struct {
+	Rule       string  `json:"rule"`
+	ReasonCode string  `json:"reason_code"`
+	Score      float64 `json:"score"`
+	Decline    bool    `json:"decline,omitempty"`
+}
+
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, p repo.Payment) (*Hit, error)
+}
+
+// Decision is the engine's verdict, shaped like the external risk engine's
+// answer so either can drive a payment transition.
+type Decision struct {
+	Decision    repo.PaymentStatus `json:"decision"`
+	Score       float64            `json:"score"`
+	Reason      string             `json:"reason"`
+	ReasonCodes []string           `json:"reason_codes"`
+	Hits        []Hit              `json:"hits"`
+}
+
+// Engine scores a payment by summing the scores of every rule that fires,
+// capped at 1. A hit marked Decline declines outright.
+type Engine struct {
+	rules        []Rule
+	declineScore float64
+	reviewScore  float64
+}
+
+func NewEngine(rules []Rule, declineScore, reviewScore float64) *Engine {
+	return &Engine{rules: rules, declineScore: declineScore, reviewScore: reviewScore}
+}
+
+func (e *Engine) Evaluate(ctx context.Context, p repo.Payment) (Decision, error) {
+	d := Decision{ReasonCodes: []string{}, Hits: []Hit{}}
+	var decline bool
+	for _, r := range e.rules {
+		hit, err := r.Evaluate(ctx, p)
+		if err != nil {
+			return Decision{}, fmt.Errorf("rule %s: %w", r.Name(), err)
+		}
+		if hit == nil {
+			continue
+		}
+		d.Hits = append(d.Hits, *hit)
+		d.Score += hit.Score
+		decline = decline || hit.Decline
+		if !slices.Contains(d.ReasonCodes, hit.ReasonCode) {
+			d.ReasonCodes = append(d.ReasonCodes, hit.ReasonCode)
+		}
+	}
+	d.Score = min(d.Score, 1)
+
+	switch {
+	case decline || d.Score >= e.declineScore:
+		d.Decision = repo.StatusDeclined
+	case d.Score >= e.reviewScore:
+		d.Decision = repo.StatusReview
+	default:
+		d.Decision = repo.StatusApproved
+	}
+	d.Reason = "rules: no rule fired"
+	if len(d.ReasonCodes) > 0 {
+		d.Reason = "rules: " + strings.Join(d.ReasonCodes, ",")
+	}
+	return d, nil
+}
+
+// AmountThreshold fires when the amount exceeds the limit for its currency.
+// Currencies without a limit never fire.
+type AmountThreshold struct {
+	RuleName   string
+	Limits     map[string]int64
+	Score      float64
+	ReasonCode string
+	Decline    bool
+}
+
+func (r AmountThreshold) Name() string { return r.RuleName }
+
+func (r AmountThreshold) Evaluate(_ context.Context, p repo.Payment) (*Hit, error) {
+	limit, ok := r.Limits[strings.ToUpper(p.Currency)]
+	if !ok || p.Amount <= limit {
+		return nil, nil
+	}
+	return &Hit{Rule: r.RuleName, ReasonCode: r.ReasonCode, Score: r.Score, Decline: r.Decline}, nil
+}
+
+type MerchantBlocklist struct {
+	RuleName   string
+	Merchants  map[string]bool
+	Score      float64
+	ReasonCode string
+	Decline    bool
+}
+
+func (r MerchantBlocklist) Name() string { return r.RuleName }
+
+func (r MerchantBlocklist) Evaluate(_ context.Context, p repo.Payment) (*Hit, error) {
+	if !r.Merchants[p.MerchantID] {
+		return nil, nil
+	}
+	return &Hit{Rule: r.RuleName, ReasonCode: r.ReasonCode, Score: r.Score, Decline: r.Decline}, nil
+}
+
+type PaymentCounter interface {
+	CountByUserSince(ctx context.Context, userID string, since time.Time) (int, error)
+}
+
+// WindowTotals answers sliding window aggregates per user or merchant.
+type WindowTotals interface {
+	Totals(ctx context.Context, kind velocity.Kind, id string, window time.Duration, at time.Time) (map[string]velocity.Totals, error)
+}
+
+// Inputs are the data sources rules may query besides the payment itself.
+// Either may be nil, in which case rules that need it fail to build.
+type Inputs struct {
+	Payments PaymentCounter
+	Velocity WindowTotals
+}
+
+// UserVelocity fires when the user made more than Max payments within
+// Window before this one, counting the payment itself.
+type UserVelocity struct {
+	RuleName   string
+	Counter    PaymentCounter
+	Window     time.Duration
+	Max        int
+	Score      float64
+	ReasonCode string
+	Decline    bool
+}
+
+func (r UserVelocity) Name() string { return r.RuleName }
+
+func (r UserVelocity) Evaluate(ctx context.Context, p repo.Payment) (*Hit, error) {
+	n, err := r.Counter.CountByUserSince(ctx, p.UserID, p.CreatedAt.Add(-r.Window))
+	if err != nil {
+		return nil, err
+	}
+	if n <= r.Max {
+		return nil, nil
+	}
+	return &Hit{Rule: r.RuleName, ReasonCode: r.ReasonCode, Score: r.Score, Decline: r.Decline}, nil
+}
+
+// ExprRule fires when its compiled expression matches the payment.
+type ExprRule struct {
+	RuleName   string
+	When       *Expr
+	Inputs     Inputs
+	Score      float64
+	ReasonCode string
+	Decline    bool
+}
+
+func (r ExprRule) Name() string { return r.RuleName }
+
+func (r ExprRule) Evaluate(ctx context.Context, p repo.Payment) (*Hit, error) {
+	ok, err := r.When.Match(ctx, p, r.Inputs)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &Hit{Rule: r.RuleName, ReasonCode: r.ReasonCode, Score: r.Score, Decline: r.Decline}, nil
+}
@@ -0,0 +1,184 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+// fixedRule fires with its hit, or stays silent when hit is nil.
+type fixedRule struct {
+	name string
+	hit  *Hit
+	err  error
+}
+
+func (r fixedRule) Name() string { return r.name }
+
+func (r fixedRule) Evaluate(context.Context, repo.Payment) (*Hit, error) {
+	return r.hit, r.err
+}
+
+func fires(name, reason string, score float64) Rule {
+	return fixedRule{name: name, hit: &Hit{Rule: name, ReasonCode: reason, Score: score}}
+}
+
+func TestEngineEvaluate(t *testing.T) {
+	silent := fixedRule{name: "silent"}
+	tests := []struct {
+		name    string
+		rules   []Rule
+		want    repo.PaymentStatus
+		score   float64
+		reason  string
+		reasons []string
+		hits    int
+	}{
+		{
+			name:    "no rules",
+			want:    repo.StatusApproved,
+			reason:  "rules: no rule fired",
+			reasons: []string{},
+		},
+		{
+			name:    "nothing fires",
+			rules:   []Rule{silent, silent},
+			want:    repo.StatusApproved,
+			reason:  "rules: no rule fired",
+			reasons: []string{},
+		},
+		{
+			name:    "below review",
+			rules:   []Rule{fires("a", "amount_over_limit", 0.2), silent},
+			want:    repo.StatusApproved,
+			score:   0.2,
+			reason:  "rules: amount_over_limit",
+			reasons: []string{"amount_over_limit"},
+			hits:    1,
+		},
+		{
+			name:    "scores add up to review",
+			rules:   []Rule{fires("a", "amount_over_limit", 0.3), fires("b", "user_velocity", 0.25)},
+			want:    repo.StatusReview,
+			score:   0.55,
+			reason:  "rules: amount_over_limit,user_velocity",
+			reasons: []string{"amount_over_limit", "user_velocity"},
+			hits:    2,
+		},
+		{
+			name:    "review score is inclusive",
+			rules:   []Rule{fires("a", "amount_over_limit", 0.5)},
+			want:    repo.StatusReview,
+			score:   0.5,
+			reason:  "rules: amount_over_limit",
+			reasons: []string{"amount_over_limit"},
+			hits:    1,
+		},
+		{
+			name:    "decline score is inclusive",
+			rules:   []Rule{fires("a", "amount_over_limit", 0.5), fires("b", "user_velocity", 0.3)},
+			want:    repo.StatusDeclined,
+			score:   0.8,
+			reason:  "rules: amount_over_limit,user_velocity",
+			reasons: []string{"amount_over_limit", "user_velocity"},
+			hits:    2,
+		},
+		{
+			name:    "score capped at one",
+			rules:   []Rule{fires("a", "amount_over_limit", 0.9), fires("b", "user_velocity", 0.9)},
+			want:    repo.StatusDeclined,
+			score:   1,
+			reason:  "rules: amount_over_limit,user_velocity",
+			reasons: []string{"amount_over_limit", "user_velocity"},
+			hits:    2,
+		},
+		{
+			name:    "reason codes listed once",
+			rules:   []Rule{fires("a", "night_high_value", 0.1), fires("b", "night_high_value", 0.1)},
+			want:    repo.StatusApproved,
+			score:   0.2,
+			reason:  "rules: night_high_value",
+			reasons: []string{"night_high_value"},
+			hits:    2,
+		},
+		{
+			name:    "decline hit overrides a low score",
+			rules:   []Rule{fixedRule{name: "blocked", hit: &Hit{Rule: "blocked", ReasonCode: "merchant_blocked", Decline: true}}},
+			want:    repo.StatusDeclined,
+			reason:  "rules: merchant_blocked",
+			reasons: []string{"merchant_blocked"},
+			hits:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewEngine(tt.rules, 0.8, 0.5).Evaluate(context.Background(), repo.Payment{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Decision != tt.want || d.Reason != tt.reason || len(d.Hits) != tt.hits {
+				t.Errorf("Evaluate = %s %q with %d hits, want %s %q with %d", d.Decision, d.Reason, len(d.Hits), tt.want, tt.reason, tt.hits)
+			}
+			if d.Score < tt.score-1e-9 || d.Score > tt.score+1e-9 {
+				t.Errorf("score = %v, want %v", d.Score, tt.score)
+			}
+			if !slices.Equal(d.ReasonCodes, tt.reasons) {
+				t.Errorf("reason codes = %v, want %v", d.ReasonCodes, tt.reasons)
+			}
+		})
+	}
+}
+
+func TestEngineEvaluateRuleError(t *testing.T) {
+	boom := errors.New("counter unavailable")
+	e := NewEngine([]Rule{fires("a", "amount_over_limit", 0.9), fixedRule{name: "burst", err: boom}}, 0.8, 0.5)
+	if _, err := e.Evaluate(context.Background(), repo.Payment{}); !errors.Is(err, boom) {
+		t.Errorf("Evaluate = %v, want the rule's error", err)
+	}
+}
+
+func TestRules(t *testing.T) {
+	now := time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC)
+	counter := countFunc(func(userID string, since time.Time) int {
+		if userID != "u-1" || !since.Equal(now.Add(-10*time.Minute)) {
+			return 0
+		}
+		return 6
+	})
+	amount := AmountThreshold{RuleName: "large", Limits: map[string]int64{"USD": 500000}, Score: 0.6, ReasonCode: "amount_over_limit"}
+	blocked := MerchantBlocklist{RuleName: "blocked", Merchants: map[string]bool{"m-123": true}, ReasonCode: "merchant_blocked", Decline: true}
+	burst := UserVelocity{RuleName: "burst", Counter: counter, Window: 10 * time.Minute, Max: 5, Score: 0.4, ReasonCode: "user_velocity"}
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		payment repo.Payment
+		fires   bool
+	}{
+		{"amount over the limit", amount, repo.Payment{Amount: 500001, Currency: "usd"}, true},
+		{"amount at the limit", amount, repo.Payment{Amount: 500000, Currency: "USD"}, false},
+		{"currency without a limit", amount, repo.Payment{Amount: 1 << 40, Currency: "JPY"}, false},
+		{"blocked merchant", blocked, repo.Payment{MerchantID: "m-123"}, true},
+		{"other merchant", blocked, repo.Payment{MerchantID: "m-124"}, false},
+		{"too many payments", burst, repo.Payment{UserID: "u-1", CreatedAt: now}, true},
+		{"few payments", burst, repo.Payment{UserID: "u-2", CreatedAt: now}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, err := tt.rule.Evaluate(context.Background(), tt.payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (hit != nil) != tt.fires {
+				t.Fatalf("Evaluate = %+v, want fired %v", hit, tt.fires)
+			}
+			if hit != nil && hit.Rule != tt.rule.Name() {
+				t.Errorf("hit rule = %q, want %q", hit.Rule, tt.rule.Name())
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// CheckCompatibility parses every registered schema and verifies that each
+// version of a subject can read data written with the previous version.
+func CheckCompatibility(r Registry) error {
+	var errs []error
+	for _, subject := range r.Subjects() {
+		versions, err := r.Versions(subject)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for i, s := range versions {
+			if err := Validate(s); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if i == 0 {
+				continue
+			}
+			prev := versions[i-1]
+			if prev.Format != s.Format {
+				errs = append(errs, fmt.Errorf("%s v%d changes format from %s to %s", subject, s.Version, prev.Format, s.Format))
+				continue
+			}
+			if err := backwardCompatible(s, prev); err != nil {
+				errs = append(errs, fmt.Errorf("%s v%d is not backward compatible with v%d: %w", subject, s.Version, prev.Version, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func Validate(s Schema) error {
+	var err error
+	switch s.Format {
+	case FormatAvro:
+		_, err = AvroSchema(s)
+	case FormatProtobuf:
+		_, err = MessageDescriptor(s)
+	case FormatJSON:
+		if len(s.Definition) > 0 {
+			_, err = jsonSchema(s)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("schema %d (%s v%d): %w", s.ID, s.Subject, s.Version, err)
+	}
+	return nil
+}
+
+func AvroSchema(s Schema) (avro.Schema, error) {
+	return avro.ParseBytes(s.Definition)
+}
+
+// MessageDescriptor resolves the schema's message from its serialized
+// FileDescriptorSet, as produced by protoc --descriptor_set_out.
+func MessageDescriptor(s Schema) (protoreflect.MessageDescriptor, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(s.Definition, &set); err != nil {
+		return nil, err
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(s.Message))
+	if err != nil {
+		return nil, err
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", s.Message)
+	}
+	return md, nil
+}
+
+func backwardCompatible(reader, writer Schema) error {
+	switch reader.Format {
+	case FormatAvro:
+		r, err := AvroSchema(reader)
+		if err != nil {
+			return err
+		}
+		w, err := AvroSchema(writer)
+		if err != nil {
+			return err
+		}
+		return avro.NewSchemaCompatibility().Compatible(r, w)
+	case FormatProtobuf:
+		r, err := MessageDescriptor(reader)
+		if err != nil {
+			return err
+		}
+		w, err := MessageDescriptor(writer)
+		if err != nil {
+			return err
+		}
+		return protoCompatible(r, w)
+	case FormatJSON:
+		if len(reader.Definition) == 0 || len(writer.Definition) == 0 {
+			return nil
+		}
+		r, err := jsonSchema(reader)
+		if err != nil {
+			return err
+		}
+		w, err := jsonSchema(writer)
+		if err != nil {
+			return err
+		}
+		return jsonCompatible(r, w)
+	}
+	return nil
+}
+
+// protoCompatible requires every field number shared by both messages to keep
+// its kind and cardinality, so old bytes decode to the same values.
+func protoCompatible(reader, writer protoreflect.MessageDescriptor) error {
+	fields := writer.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		wf := fields.Get(i)
+		rf := reader.Fields().ByNumber(wf.Number())
+		if rf == nil {
+			continue
+		}
+		if rf.Kind() != wf.Kind() || rf.Cardinality() != wf.Cardinality() || rf.IsMap() != wf.IsMap() {
+			return fmt.Errorf("field %d changed from %s %s to %s %s", wf.Number(), wf.Cardinality(), wf.Kind(), rf.Cardinality(), rf.Kind())
+		}
+		if rf.Kind() == protoreflect.MessageKind && rf.Message().FullName() != wf.Message().FullName() {
+			if err := protoCompatible(rf.Message(), wf.Message()); err != nil {
+				return fmt.Errorf("field %d: %w", wf.Number(), err)
+			}
+		}
+	}
+	return nil
+}
+
+type jsonSchemaDoc struct {
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+func jsonSchema(s Schema) (jsonSchemaDoc, error) {
+	var doc jsonSchemaDoc
+	err := json.Unmarshal(s.Definition, &doc)
+	return doc, err
+}
+
+// jsonCompatible rejects new required properties that old documents never
+// carried and type changes on properties present in both versions.
+func jsonCompatible(reader, writer jsonSchemaDoc) error {
+	for _, name := range reader.Required {
+		if _, ok := writer.Properties[name]; !ok {
+			return fmt.Errorf("new required property %q", name)
+		}
+	}
+	for name, rp := range reader.Properties {
+		wp, ok := writer.Properties[name]
+		if !ok {
+			continue
+		}
+		var rt, wt struct {
+			Type any `json:"type"`
+		}
+		_ = json.Unmarshal(rp, &rt)
+		_ = json.Unmarshal(wp, &wt)
+		if fmt.Sprint(rt.Type) != fmt.Sprint(wt.Type) {
+			return fmt.Errorf("property %q changed type from %v to %v", name, wt.Type, rt.Type)
+		}
+	}
+	return nil
+}
+
+// RequiredProperties lists the top-level properties a JSON schema requires.
+func RequiredProperties(s Schema) ([]string, error) {
+	if len(s.Definition) == 0 {
+		return nil, nil
+	}
+	doc, err := jsonSchema(s)
+	return doc.Required, err
+}
@@ -0,0 +1,230 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// protoField is name, number and type of a field of the test message.
+type protoField struct {
+	name   string
+	number int32
+	typ    descriptorpb.FieldDescriptorProto_Type
+	label  descriptorpb.FieldDescriptorProto_Label
+}
+
+// protoDefinition serializes a FileDescriptorSet holding payments.Decision
+// with the given fields, as protoc --descriptor_set_out would.
+func protoDefinition(t *testing.T, fields ...protoField) []byte {
+	t.Helper()
+	msg := &descriptorpb.DescriptorProto{Name: proto.String("Decision")}
+	for _, f := range fields {
+		label := f.label
+		if label == 0 {
+			label = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		}
+		msg.Field = append(msg.Field, &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(f.name),
+			JsonName: proto.String(f.name),
+			Number:   proto.Int32(f.number),
+			Type:     f.typ.Enum(),
+			Label:    label.Enum(),
+		})
+	}
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:        proto.String("decision.proto"),
+		Package:     proto.String("payments"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{msg},
+	}}}
+	raw, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func registryOf(t *testing.T, schemas ...Schema) *FileRegistry {
+	t.Helper()
+	r := &FileRegistry{byID: map[int]Schema{}, subjects: map[string][]Schema{}}
+	for _, s := range schemas {
+		if err := r.add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+const avroV1 = `{"type":"record","name":"Decision","fields":[
+	{"name":"payment_id","type":"string"},
+	{"name":"amount","type":"long"}]}`
+
+func TestCheckCompatibility(t *testing.T) {
+	var (
+		str     = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		i64     = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		rep     = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		asProto = func(t *testing.T, fields ...protoField) Schema {
+			return Schema{Format: FormatProtobuf, Message: "payments.Decision", Definition: protoDefinition(t, fields...)}
+		}
+		asAvro = func(def string) Schema { return Schema{Format: FormatAvro, Definition: []byte(def)} }
+		asJSON = func(def string) Schema { return Schema{Format: FormatJSON, Definition: []byte(def)} }
+	)
+	tests := []struct {
+		name    string
+		v1, v2  func(t *testing.T) Schema
+		wantErr string
+	}{
+		{
+			name: "avro adds a field with a default",
+			v1:   func(*testing.T) Schema { return asAvro(avroV1) },
+			v2: func(*testing.T) Schema {
+				return asAvro(`{"type":"record","name":"Decision","fields":[
+					{"name":"payment_id","type":"string"},
+					{"name":"amount","type":"long"},
+					{"name":"reason","type":["null","string"],"default":null}]}`)
+			},
+		},
+		{
+			name: "avro adds a field without a default",
+			v1:   func(*testing.T) Schema { return asAvro(avroV1) },
+			v2: func(*testing.T) Schema {
+				return asAvro(`{"type":"record","name":"Decision","fields":[
+					{"name":"payment_id","type":"string"},
+					{"name":"amount","type":"long"},
+					{"name":"reason","type":"string"}]}`)
+			},
+			wantErr: "not backward compatible",
+		},
+		{
+			name: "avro changes a field type",
+			v1:   func(*testing.T) Schema { return asAvro(avroV1) },
+			v2: func(*testing.T) Schema {
+				return asAvro(`{"type":"record","name":"Decision","fields":[
+					{"name":"payment_id","type":"string"},
+					{"name":"amount","type":"string"}]}`)
+			},
+			wantErr: "not backward compatible",
+		},
+		{
+			name:    "avro definition does not parse",
+			v1:      func(*testing.T) Schema { return asAvro(avroV1) },
+			v2:      func(*testing.T) Schema { return asAvro(`{"type":"record"`) },
+			wantErr: "schema 2",
+		},
+		{
+			name: "protobuf adds and renames fields",
+			v1:   func(t *testing.T) Schema { return asProto(t, protoField{"payment_id", 1, str, 0}) },
+			v2: func(t *testing.T) Schema {
+				return asProto(t, protoField{"id", 1, str, 0}, protoField{"amount", 2, i64, 0})
+			},
+		},
+		{
+			name:    "protobuf changes a field kind",
+			v1:      func(t *testing.T) Schema { return asProto(t, protoField{"amount", 2, str, 0}) },
+			v2:      func(t *testing.T) Schema { return asProto(t, protoField{"amount", 2, i64, 0}) },
+			wantErr: "field 2 changed",
+		},
+		{
+			name:    "protobuf makes a field repeated",
+			v1:      func(t *testing.T) Schema { return asProto(t, protoField{"codes", 3, str, 0}) },
+			v2:      func(t *testing.T) Schema { return asProto(t, protoField{"codes", 3, str, rep}) },
+			wantErr: "field 3 changed",
+		},
+		{
+			name: "json adds an optional property",
+			v1: func(*testing.T) Schema {
+				return asJSON(`{"required":["payment_id"],"properties":{"payment_id":{"type":"string"}}}`)
+			},
+			v2: func(*testing.T) Schema {
+				return asJSON(`{"required":["payment_id"],"properties":{"payment_id":{"type":"string"},"reason":{"type":"string"}}}`)
+			},
+		},
+		{
+			name: "json requires a new property",
+			v1:   func(*testing.T) Schema { return asJSON(`{"properties":{"payment_id":{"type":"string"}}}`) },
+			v2: func(*testing.T) Schema {
+				return asJSON(`{"required":["status"],"properties":{"payment_id":{"type":"string"},"status":{"type":"string"}}}`)
+			},
+			wantErr: `new required property "status"`,
+		},
+		{
+			name:    "json changes a property type",
+			v1:      func(*testing.T) Schema { return asJSON(`{"properties":{"amount":{"type":"integer"}}}`) },
+			v2:      func(*testing.T) Schema { return asJSON(`{"properties":{"amount":{"type":"string"}}}`) },
+			wantErr: `property "amount" changed type`,
+		},
+		{
+			name:    "format changes",
+			v1:      func(*testing.T) Schema { return asJSON(`{"properties":{}}`) },
+			v2:      func(*testing.T) Schema { return asAvro(avroV1) },
+			wantErr: "changes format from json to avro",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v1, v2 := tt.v1(t), tt.v2(t)
+			v1.ID, v1.Subject, v1.Version = 1, "payments.decisions-value", 1
+			v2.ID, v2.Subject, v2.Version = 2, "payments.decisions-value", 2
+			err := CheckCompatibility(registryOf(t, v1, v2))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckCompatibility: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckCompatibility = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "decision.avsc"), []byte(avroV1), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	index := `{"schemas":[
+		{"id":2,"subject":"payments.decisions-value","version":2,"format":"json","schema":"{\"properties\":{}}"},
+		{"id":1,"subject":"payments.decisions-value","version":1,"format":"json"},
+		{"id":3,"subject":"payments.outbox-value","version":1,"format":"avro","schema_file":"decision.avsc"}
+	]}`
+	path := filepath.Join(dir, "registry.json")
+	if err := os.WriteFile(path, []byte(index), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest, err := r.Latest("payments.decisions-value"); err != nil || latest.ID != 2 {
+		t.Errorf("Latest = %+v, %v; want id 2", latest, err)
+	}
+	if s, err := r.ByID(3); err != nil || string(s.Definition) != avroV1 {
+		t.Errorf("ByID(3) = %+v, %v; want the schema file contents", s, err)
+	}
+	if _, err := r.ByID(9); err == nil {
+		t.Error("ByID(9) found a schema")
+	}
+
+	for name, bad := range map[string]string{
+		"duplicate id":      `{"schemas":[{"id":1,"subject":"a","version":1,"format":"json"},{"id":1,"subject":"b","version":1,"format":"json"}]}`,
+		"duplicate version": `{"schemas":[{"id":1,"subject":"a","version":1,"format":"json"},{"id":2,"subject":"a","version":1,"format":"json"}]}`,
+		"unknown format":    `{"schemas":[{"id":1,"subject":"a","version":1,"format":"xml"}]}`,
+		"proto no message":  `{"schemas":[{"id":1,"subject":"a","version":1,"format":"protobuf"}]}`,
+		"missing version":   `{"schemas":[{"id":1,"subject":"a","format":"json"}]}`,
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("%s: LoadFile succeeded", name)
+		}
+	}
+}
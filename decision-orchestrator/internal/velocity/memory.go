@@ -0,0 +1,72 @@
+package velocity
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+type memEvent struct {
+	at        time.Time
+	currency  string
+	amount    int64
+	reporting int64
+}
+
+// MemoryStore keeps exact per-payment history in process. It is meant for
+// tests and single-instance development setups.
+type MemoryStore struct {
+	mu     sync.Mutex
+	events map[string][]memEvent
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{events: map[string][]memEvent{}}
+}
+
+func (s *MemoryStore) Record(_ context.Context, p repo.Payment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := p.CreatedAt.Add(-Retention)
+	for _, sub := range subjects(p) {
+		k := string(sub.kind) + ":" + sub.id
+		evs := s.events[k]
+		// drop expired history from the front; events arrive roughly in order
+		i := 0
+		for i < len(evs) && evs[i].at.Before(cutoff) {
+			i++
+		}
+		s.events[k] = append(evs[i:], memEvent{
+			at:        p.CreatedAt,
+			currency:  strings.ToUpper(p.Currency),
+			amount:    p.Amount,
+			reporting: reportingAmount(p),
+		})
+	}
+	return nil
+}
+
+func (s *MemoryStore) Totals(_ context.Context, kind Kind, id string, window time.Duration, at time.Time) (map[string]Totals, error) {
+	if err := checkWindow(window); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := at.Add(-window)
+	out := map[string]Totals{}
+	for _, ev := range s.events[string(kind)+":"+id] {
+		if ev.at.After(from) && !ev.at.After(at) {
+			t := out[ev.currency]
+			t.Count++
+			t.Sum += ev.amount
+			t.ReportingSum += ev.reporting
+			out[ev.currency] = t
+		}
+	}
+	return out, nil
+}
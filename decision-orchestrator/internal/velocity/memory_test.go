@@ -0,0 +1,130 @@
+package velocity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+func TestMemoryStoreTotals(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	for _, p := range []repo.Payment{
+		{UserID: "u-1", MerchantID: "m-1", Amount: 100, Currency: "usd", CreatedAt: now.Add(-25 * time.Hour)},
+		{UserID: "u-1", MerchantID: "m-1", Amount: 200, Currency: "USD", CreatedAt: now.Add(-2 * time.Hour)},
+		{UserID: "u-1", MerchantID: "m-2", Amount: 300, Currency: "EUR", CreatedAt: now.Add(-30 * time.Minute),
+			Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 330}},
+		{UserID: "u-1", MerchantID: "m-1", Amount: 400, Currency: "USD", CreatedAt: now.Add(-time.Minute)},
+		{UserID: "u-2", MerchantID: "m-1", Amount: 500, Currency: "USD", CreatedAt: now,
+			Reporting: &repo.ReportingAmount{Currency: "USD", Amount: 500}},
+		{UserID: "u-1", MerchantID: "m-1", Amount: 600, Currency: "USD", CreatedAt: now.Add(time.Minute)},
+	} {
+		if err := s.Record(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		kind   Kind
+		id     string
+		window time.Duration
+		want   map[string]Totals
+	}{
+		{
+			// the start of the window is exclusive, the end inclusive
+			name:   "user last minute",
+			kind:   KindUser,
+			id:     "u-1",
+			window: time.Minute,
+			want:   map[string]Totals{},
+		},
+		{
+			name:   "user last hour",
+			kind:   KindUser,
+			id:     "u-1",
+			window: time.Hour,
+			want: map[string]Totals{
+				"USD": {Count: 1, Sum: 400},
+				"EUR": {Count: 1, Sum: 300, ReportingSum: 330},
+			},
+		},
+		{
+			name:   "user last day",
+			kind:   KindUser,
+			id:     "u-1",
+			window: 24 * time.Hour,
+			want: map[string]Totals{
+				"USD": {Count: 2, Sum: 600},
+				"EUR": {Count: 1, Sum: 300, ReportingSum: 330},
+			},
+		},
+		{
+			name:   "merchant last day",
+			kind:   KindMerchant,
+			id:     "m-1",
+			window: 24 * time.Hour,
+			want:   map[string]Totals{"USD": {Count: 3, Sum: 1100, ReportingSum: 500}},
+		},
+		{
+			name:   "unknown subject",
+			kind:   KindMerchant,
+			id:     "m-9",
+			window: time.Hour,
+			want:   map[string]Totals{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Totals(ctx, tt.kind, tt.id, tt.window, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Totals = %v, want %v", got, tt.want)
+			}
+			for cur, want := range tt.want {
+				if got[cur] != want {
+					t.Errorf("Totals[%s] = %+v, want %+v", cur, got[cur], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreDropsExpiredHistory(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 5, 6, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	for i := 0; i < 3; i++ {
+		p := repo.Payment{UserID: "u-1", MerchantID: "m-1", Amount: 1, Currency: "USD", CreatedAt: start.Add(time.Duration(i) * 20 * time.Hour)}
+		if err := s.Record(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(s.events["user:u-1"]); n != 2 {
+		t.Errorf("kept %d events, want 2 after the first fell out of retention", n)
+	}
+}
+
+func TestMemoryStoreRejectsWindows(t *testing.T) {
+	s := NewMemoryStore()
+	for _, window := range []time.Duration{0, -time.Minute, Retention + time.Second} {
+		if _, err := s.Totals(context.Background(), KindUser, "u-1", window, time.Now()); err == nil {
+			t.Errorf("Totals with window %s succeeded, want an error", window)
+		}
+	}
+}
+
+func TestReportingSum(t *testing.T) {
+	got := ReportingSum(map[string]Totals{
+		"USD": {Count: 2, Sum: 600, ReportingSum: 600},
+		"EUR": {Count: 1, Sum: 300, ReportingSum: 330},
+	})
+	if got != 930 {
+		t.Errorf("ReportingSum = %d, want 930", got)
+	}
+}
@@ -0,0 +1,94 @@
+package velocity
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// BucketSize is the resolution of the Mongo store: windows are counted in
+// whole buckets, so a window can include up to one bucket of extra history.
+const BucketSize = 10 * time.Second
+
+// MongoStore aggregates payments into small time buckets per subject and
+// currency, so recording is a single upsert and a 24h query sums at most a
+// few thousand documents. Buckets expire after Retention.
+type MongoStore struct {
+	col *mongo.Collection
+}
+
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{col: db.Collection("velocity_buckets")}
+}
+
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "kind", Value: 1}, {Key: "subject", Value: 1}, {Key: "bucket", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "bucket", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32((Retention + BucketSize) / time.Second)),
+		},
+	})
+	return err
+}
+
+func (s *MongoStore) Record(ctx context.Context, p repo.Payment) error {
+	bucket := p.CreatedAt.UTC().Truncate(BucketSize)
+	currency := strings.ToUpper(p.Currency)
+	for _, sub := range subjects(p) {
+		id := fmt.Sprintf("%s:%s:%s:%d", sub.kind, sub.id, currency, bucket.Unix())
+		update := bson.M{
+			"$setOnInsert": bson.M{"kind": sub.kind, "subject": sub.id, "currency": currency, "bucket": bucket},
+			"$inc":         bson.M{"count": 1, "sum": p.Amount, "reporting_sum": reportingAmount(p)},
+		}
+		if _, err := s.col.UpdateOne(ctx, bson.M{"_id": id}, update, options.UpdateOne().SetUpsert(true)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MongoStore) Totals(ctx context.Context, kind Kind, id string, window time.Duration, at time.Time) (map[string]Totals, error) {
+	if err := checkWindow(window); err != nil {
+		return nil, err
+	}
+	from := at.UTC().Add(-window).Truncate(BucketSize)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"kind":    kind,
+			"subject": id,
+			"bucket":  bson.M{"$gte": from, "$lte": at.UTC()},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$currency",
+			"count": bson.M{"$sum": "$count"},
+			"sum":   bson.M{"$sum": "$sum"},
+			// $sum skips buckets written before reporting sums existed
+			"reporting_sum": bson.M{"$sum": "$reporting_sum"},
+		}}},
+	}
+	cur, err := s.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := map[string]Totals{}
+	for cur.Next(ctx) {
+		var row struct {
+			Currency string `bson:"_id"`
+			Totals   `bson:",inline"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return nil, err
+		}
+		out[row.Currency] = row.Totals
+	}
+	return out, cur.Err()
+}
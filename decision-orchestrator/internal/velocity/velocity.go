@@ -0,0 +1,70 @@
+package velocity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/decision-orchestrator/internal/repo"
+)
+
+type Kind string
+
+const (
+	KindUser     Kind = "user"
+	KindMerchant Kind = "merchant"
+)
+
+// Windows are the sliding windows reported by the API. Stores keep enough
+// history to answer any window up to Retention.
+var Windows = []time.Duration{time.Minute, time.Hour, 24 * time.Hour}
+
+const Retention = 24 * time.Hour
+
+// Totals is the number and summed amount (in minor units) of payments in
+// one currency. ReportingSum is the same payments in the reporting currency,
+// leaving out any that could not be converted.
+type Totals struct {
+	Count        int64 `json:"count" bson:"count"`
+	Sum          int64 `json:"sum" bson:"sum"`
+	ReportingSum int64 `json:"reporting_sum" bson:"reporting_sum"`
+}
+
+type Store interface {
+	// Record adds p to the counters of its user and merchant.
+	Record(ctx context.Context, p repo.Payment) error
+	// Totals returns per-currency totals for the window ending at.
+	Totals(ctx context.Context, kind Kind, id string, window time.Duration, at time.Time) (map[string]Totals, error)
+}
+
+// ReportingSum adds up the reporting currency sums of all currencies.
+func ReportingSum(totals map[string]Totals) int64 {
+	var sum int64
+	for _, t := range totals {
+		sum += t.ReportingSum
+	}
+	return sum
+}
+
+func reportingAmount(p repo.Payment) int64 {
+	if p.Reporting == nil {
+		return 0
+	}
+	return p.Reporting.Amount
+}
+
+func checkWindow(window time.Duration) error {
+	if window <= 0 || window > Retention {
+		return fmt.Errorf("window %s outside (0, %s]", window, Retention)
+	}
+	return nil
+}
+
+type subject struct {
+	kind Kind
+	id   string
+}
+
+func subjects(p repo.Payment) []subject {
+	return []subject{{KindUser, p.UserID}, {KindMerchant, p.MerchantID}}
+}
@@ -8,12 +8,15 @@ import (
 	"syscall"
 
 	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/app"
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/auth"
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/codec"
 	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/config"
 	httpHandler "github.com/dmehra2102/payments-risk-decisioning/notification/internal/http"
 	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/kafka"
 	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/log"
 	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/notify"
 	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/observability"
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/reasons"
 	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/store"
 	"go.uber.org/zap"
 )
@@ -37,17 +40,58 @@ func main() {
 	}
 	defer shutdown(ctx)
 
+	codecs, err := codec.Load(cfg.SchemaRegistry, cfg.TopicCodecs)
+	if err != nil {
+		logger.Fatal("codec init failed", zap.Error(err))
+	}
+
 	sender := notify.New(cfg.NotifyWebhook, cfg.Timeout)
 	state := store.New()
 	producer := kafka.NewProducer(cfg.KafkaBrokers)
 	defer producer.Close()
 
-	app := app.New(logger, sender, state, producer, cfg.DLQTopic)
+	reasonCodes, err := reasons.Load(cfg.ReasonCodesFile)
+	if err != nil {
+		logger.Fatal("reason code registry load failed", zap.Error(err))
+	}
+	redaction, err := reasons.LoadPolicy(cfg.RedactionFile, reasons.Mode(cfg.RedactionMode))
+	if err != nil {
+		logger.Fatal("redaction policy load failed", zap.Error(err))
+	}
+
+	appOpts := []app.Option{app.WithSource(cfg.AppName), app.WithExplanations(reasonCodes, redaction)}
+	if cfg.WebhookFormat == "cloudevents" {
+		appOpts = append(appOpts, app.WithCloudEvents(cfg.CloudEventsMode))
+	}
+
+	app := app.New(logger, sender, state, producer, cfg.DLQTopic, appOpts...)
+
+	consumer := kafka.NewConsumer(cfg.KafkaBrokers, cfg.GroupID, cfg.OutboxTopic, app.Handle).WithCodecs(codecs).WithDeadLetter(logger, producer, cfg.DLQTopic)
+	mux := http.NewServeMux()
+	hh := httpHandler.HealthHandler()
+	mux.Handle("/healthz", hh)
+	mux.Handle("/readyz", hh)
+
+	authn, err := auth.New(auth.Settings{
+		APIKeysFile: cfg.AuthAPIKeysFile,
+		HMACSecret:  cfg.AuthHMACSecret,
+		JWKSFile:    cfg.AuthJWKSFile,
+		JWTIssuer:   cfg.AuthJWTIssuer,
+		JWTAudience: cfg.AuthJWTAudience,
+		RoleClaim:   cfg.AuthRoleClaim,
+	})
+	if err != nil {
+		logger.Fatal("auth init failed", zap.Error(err))
+	}
+	authz := auth.NewMiddleware(logger, authn, httpHandler.RoutePolicy)
+	if cfg.AuthDisabled {
+		logger.Warn("authentication disabled, every caller is treated as admin")
+		authz.Disable()
+	}
 
-	consumer := kafka.NewConsumer(cfg.KafkaBrokers, cfg.GroupID, cfg.OutboxTopic, app.Handle)
 	srv := &http.Server{
 		Addr:    cfg.HTTPAddr,
-		Handler: httpHandler.HealthHandler(),
+		Handler: authz.Wrap(mux),
 	}
 
 	go func() {
@@ -2,11 +2,18 @@ module github.com/dmehra2102/payments-risk-decisioning/notification
 
 go 1.24.4
 
-require github.com/spf13/viper v1.21.0
+require (
+	github.com/hamba/avro/v2 v2.31.0
+	github.com/spf13/viper v1.21.0
+	google.golang.org/protobuf v1.36.10
+)
 
 require (
 	github.com/fsnotify/fsnotify v1.9.0 // indirect
 	github.com/go-viper/mapstructure/v2 v2.4.0 // indirect
+	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
 	github.com/sagikazarmark/locafero v0.11.0 // indirect
 	github.com/sourcegraph/conc v0.3.1-0.20240121214520-5f936abd7ae8 // indirect
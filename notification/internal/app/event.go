@@ -0,0 +1,100 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/cloudevents"
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/events"
+	segmentioKafka "github.com/segmentio/kafka-go"
+)
+
+// decode turns an outbox message into a CloudEvent regardless of whether the
+// orchestrator published it with an envelope or as plain JSON with headers.
+func (n *NotificationApp) decode(msg segmentioKafka.Message) (cloudevents.Event, error) {
+	event, ok, err := cloudevents.FromKafka(msg)
+	if err != nil {
+		return cloudevents.Event{}, err
+	}
+	if ok {
+		if len(event.Data) > 0 && event.DataContentType == "application/json" && !json.Valid(event.Data) {
+			return cloudevents.Event{}, errors.New("cloudevent data is not valid json")
+		}
+		return upcast(event, event.Extensions["schemaversion"])
+	}
+
+	var envelope map[string]any
+	if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+		return cloudevents.Event{}, err
+	}
+
+	headers := map[string]string{}
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	id := headers["event_id"]
+	if id == "" {
+		id = string(msg.Key)
+	}
+	eventType := headers["event_type"]
+	if eventType == "" {
+		eventType, _ = envelope["type"].(string)
+	}
+
+	event = cloudevents.New(id, n.source, eventType)
+	event.Subject = string(msg.Key)
+	if !msg.Time.IsZero() {
+		event.Time = msg.Time.UTC()
+	}
+	event.DataContentType = "application/json"
+	event.Data = msg.Value
+	if correlationID := headers["correlation_id"]; correlationID != "" {
+		event.Extensions["correlationid"] = correlationID
+	}
+	return upcast(event, headers["schema_version"])
+}
+
+// upcast brings the event data up to the current schema version of its type
+// so webhooks only ever see the latest payload shape.
+func upcast(event cloudevents.Event, declared string) (cloudevents.Event, error) {
+	version, err := strconv.Atoi(declared)
+	if err != nil {
+		version = events.PayloadVersion(event.Data)
+	}
+
+	data, current, err := events.Default().Upcast(event.Type, version, event.Data)
+	if err != nil {
+		return cloudevents.Event{}, err
+	}
+	switch event.Type {
+	case events.TypePaymentDecisionFinalized:
+		var decision events.PaymentDecisionFinalized
+		if err := json.Unmarshal(data, &decision); err != nil {
+			return cloudevents.Event{}, err
+		}
+	case events.TypePaymentDecisionOverridden:
+		var override events.PaymentDecisionOverridden
+		if err := json.Unmarshal(data, &override); err != nil {
+			return cloudevents.Event{}, err
+		}
+	case events.TypePaymentChallengeRequired:
+		var challenge events.PaymentChallengeRequired
+		if err := json.Unmarshal(data, &challenge); err != nil {
+			return cloudevents.Event{}, err
+		}
+	case events.TypePaymentRefunded, events.TypePaymentReversed:
+		var refund events.PaymentRefunded
+		if err := json.Unmarshal(data, &refund); err != nil {
+			return cloudevents.Event{}, err
+		}
+	}
+
+	event.Data = data
+	if event.Extensions == nil {
+		event.Extensions = map[string]string{}
+	}
+	event.Extensions["schemaversion"] = strconv.Itoa(current)
+	return event, nil
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"encoding/json"
+
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/cloudevents"
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/events"
+)
+
+// explain describes the reason codes of final decisions and challenge
+// requests and applies the merchant's redaction mode before the payload
+// leaves the platform.
+func (n *NotificationApp) explain(event cloudevents.Event) (cloudevents.Event, error) {
+	if n.reasons == nil {
+		return event, nil
+	}
+	if event.Type != events.TypePaymentDecisionFinalized && event.Type != events.TypePaymentChallengeRequired {
+		return event, nil
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(event.Data, &doc); err != nil {
+		return event, err
+	}
+	merchantID, _ := doc["merchant_id"].(string)
+	n.reasons.Explain(doc, n.redaction.For(merchantID))
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return event, err
+	}
+	event.Data = data
+	return event, nil
+}
@@ -2,52 +2,115 @@ package app
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/cloudevents"
 	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/kafka"
 	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/notify"
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/reasons"
 	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/store"
 	segmentioKafka "github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 )
 
+// publisher is the part of the Kafka producer the app needs for dead
+// lettering.
+type publisher interface {
+	Publish(ctx context.Context, topic string, key, value []byte, headers []segmentioKafka.Header) error
+}
+
 type NotificationApp struct {
-	log      *zap.Logger
-	sender   *notify.Sender
-	state    *store.StateStore
-	producer *kafka.Producer
-	dlqTopic string
+	log       *zap.Logger
+	sender    *notify.Sender
+	state     *store.StateStore
+	producer  publisher
+	dlqTopic  string
+	source    string
+	ceMode    string
+	reasons   *reasons.Registry
+	redaction reasons.Policy
+}
+
+type Option func(*NotificationApp)
+
+// WithSource sets the CloudEvents source used for events that arrive without
+// an envelope of their own.
+func WithSource(source string) Option {
+	return func(n *NotificationApp) {
+		n.source = source
+	}
+}
+
+// WithCloudEvents delivers webhook bodies as CloudEvents in the given content
+// mode (structured or binary) instead of the bare event payload.
+func WithCloudEvents(mode string) Option {
+	return func(n *NotificationApp) {
+		n.ceMode = mode
+	}
+}
+
+// WithExplanations describes reason codes in decision webhooks and redacts
+// them per merchant according to policy.
+func WithExplanations(registry *reasons.Registry, policy reasons.Policy) Option {
+	return func(n *NotificationApp) {
+		n.reasons = registry
+		n.redaction = policy
+	}
 }
 
-func New(log *zap.Logger, sender *notify.Sender, state *store.StateStore, prod *kafka.Producer, dlq string) *NotificationApp {
-	return &NotificationApp{
+func New(log *zap.Logger, sender *notify.Sender, state *store.StateStore, prod *kafka.Producer, dlq string, opts ...Option) *NotificationApp {
+	n := &NotificationApp{
 		log:      log,
 		sender:   sender,
 		state:    state,
 		producer: prod,
 		dlqTopic: dlq,
+		source:   "notification",
+	}
+	for _, opt := range opts {
+		opt(n)
 	}
+	return n
 }
 
 func (n *NotificationApp) Handle(ctx context.Context, msg segmentioKafka.Message) error {
-	var envelope map[string]any
-	// I'm doing this for skipping bad message format
-	if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+	event, err := n.decode(msg)
+	if err != nil {
 		n.log.Error("invalid outbox payload", zap.Error(err))
-		return nil
+		return n.deadLetter(ctx, msg)
+	}
+	if event, err = n.explain(event); err != nil {
+		n.log.Error("failed to explain decision", zap.Error(err), zap.String("id", event.ID))
+		return n.deadLetter(ctx, msg)
 	}
 
-	id := string(msg.Key)
-	if n.state.Seen(id) {
-		n.log.Info("duplicate event ignored", zap.String("id", id))
+	if n.state.Seen(event.ID) {
+		n.log.Info("duplicate event ignored", zap.String("id", event.ID))
 		return nil
 	}
-	if err := n.sender.Send(ctx, msg.Value); err != nil {
+	if err := n.deliver(ctx, event); err != nil {
 		n.log.Warn("notify failed", zap.Error(err))
 		// Send to DLQ
 		_ = n.producer.Publish(ctx, n.dlqTopic, msg.Key, msg.Value, msg.Headers)
 		return err
 	}
-	n.state.Mark(id)
+	n.state.Mark(event.ID)
 	return nil
 }
+
+// deadLetter parks a message that can never be delivered on the DLQ so the
+// consumer can commit past it. If the DLQ is unavailable the error is
+// returned and the message stays uncommitted.
+func (n *NotificationApp) deadLetter(ctx context.Context, msg segmentioKafka.Message) error {
+	if err := n.producer.Publish(ctx, n.dlqTopic, msg.Key, msg.Value, msg.Headers); err != nil {
+		return fmt.Errorf("dead-letter message: %w", err)
+	}
+	return nil
+}
+
+func (n *NotificationApp) deliver(ctx context.Context, event cloudevents.Event) error {
+	if n.ceMode == "" {
+		return n.sender.Send(ctx, event.Data)
+	}
+	return n.sender.SendCloudEvent(ctx, event, n.ceMode)
+}
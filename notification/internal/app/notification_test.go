@@ -0,0 +1,246 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/notify"
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/reasons"
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/store"
+	segmentioKafka "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// fakePublisher records dead-lettered messages, failing with err if set.
+type fakePublisher struct {
+	mu     sync.Mutex
+	err    error
+	topics []string
+	values [][]byte
+}
+
+func (f *fakePublisher) Publish(_ context.Context, topic string, _, value []byte, _ []segmentioKafka.Header) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.topics = append(f.topics, topic)
+	f.values = append(f.values, value)
+	return nil
+}
+
+// webhook records the requests it receives and answers with status.
+type webhook struct {
+	mu       sync.Mutex
+	status   int
+	bodies   [][]byte
+	headers  []http.Header
+	requests int
+}
+
+func (w *webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.requests++
+	w.bodies = append(w.bodies, body)
+	w.headers = append(w.headers, r.Header.Clone())
+	rw.WriteHeader(w.status)
+}
+
+func newTestApp(t *testing.T, status int, opts ...Option) (*NotificationApp, *webhook, *fakePublisher) {
+	t.Helper()
+	hook := &webhook{status: status}
+	srv := httptest.NewServer(hook)
+	t.Cleanup(srv.Close)
+
+	dlq := &fakePublisher{}
+	n := New(zap.NewNop(), notify.New(srv.URL, time.Second), store.New(), nil, "notifications.dlq", opts...)
+	n.producer = dlq
+	return n, hook, dlq
+}
+
+// v1Decision is a final decision as the orchestrator published it before
+// correlation and ts were renamed.
+func v1Decision() segmentioKafka.Message {
+	return segmentioKafka.Message{
+		Key: []byte("p-1"),
+		Value: []byte(`{
+			"schema_version": 1,
+			"type": "PaymentDecisionFinalized",
+			"payment_id": "p-1",
+			"merchant_id": "m-1",
+			"status": "DECLINED",
+			"score": 0.91,
+			"reason": "amount over limit",
+			"reason_codes": ["amount_over_limit"],
+			"correlation": "corr-1",
+			"ts": "2026-03-04T05:06:07Z"
+		}`),
+		Headers: []segmentioKafka.Header{
+			{Key: "event_id", Value: []byte("evt-1")},
+			{Key: "event_type", Value: []byte("PaymentDecisionFinalized")},
+			{Key: "correlation_id", Value: []byte("corr-1")},
+			{Key: "schema_version", Value: []byte("1")},
+		},
+		Time: time.Date(2026, 3, 4, 5, 6, 8, 0, time.UTC),
+	}
+}
+
+// checkV2 asserts data is the v2 shape of v1Decision with its reasons
+// explained.
+func checkV2(t *testing.T, data []byte) {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("delivered payload is not json: %v", err)
+	}
+	if doc["schema_version"] != float64(2) {
+		t.Errorf("schema_version = %v, want 2", doc["schema_version"])
+	}
+	if doc["correlation_id"] != "corr-1" || doc["occurred_at"] != "2026-03-04T05:06:07Z" {
+		t.Errorf("correlation_id, occurred_at = %v, %v; want the renamed v1 fields", doc["correlation_id"], doc["occurred_at"])
+	}
+	for _, old := range []string{"correlation", "ts"} {
+		if _, ok := doc[old]; ok {
+			t.Errorf("v1 field %q still present", old)
+		}
+	}
+	explained, _ := doc["reasons"].([]any)
+	if len(explained) != 1 {
+		t.Fatalf("reasons = %v, want amount_over_limit described", doc["reasons"])
+	}
+	if code, _ := explained[0].(map[string]any); code["code"] != "amount_over_limit" {
+		t.Errorf("reasons[0] = %v, want amount_over_limit", explained[0])
+	}
+}
+
+func TestHandleDeliversV1AsCurrent(t *testing.T) {
+	policy := reasons.Policy{Default: reasons.ModeFull}
+	tests := []struct {
+		name string
+		opts []Option
+		body func(t *testing.T, body []byte, header http.Header) []byte
+	}{
+		{
+			name: "plain payload",
+			body: func(_ *testing.T, body []byte, _ http.Header) []byte { return body },
+		},
+		{
+			name: "structured cloudevent",
+			opts: []Option{WithCloudEvents("structured")},
+			body: func(t *testing.T, body []byte, _ http.Header) []byte {
+				var envelope struct {
+					ID            string          `json:"id"`
+					Type          string          `json:"type"`
+					SchemaVersion string          `json:"schemaversion"`
+					Data          json.RawMessage `json:"data"`
+				}
+				if err := json.Unmarshal(body, &envelope); err != nil {
+					t.Fatal(err)
+				}
+				if envelope.ID != "evt-1" || envelope.Type != "PaymentDecisionFinalized" || envelope.SchemaVersion != "2" {
+					t.Errorf("envelope = %+v, want evt-1 PaymentDecisionFinalized at schemaversion 2", envelope)
+				}
+				return envelope.Data
+			},
+		},
+		{
+			name: "binary cloudevent",
+			opts: []Option{WithCloudEvents("binary")},
+			body: func(t *testing.T, body []byte, header http.Header) []byte {
+				if header.Get("Ce-Id") != "evt-1" || header.Get("Ce-Schemaversion") != "2" || header.Get("Ce-Correlationid") != "corr-1" {
+					t.Errorf("headers = %v, want evt-1 at schemaversion 2 with its correlation id", header)
+				}
+				return body
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithExplanations(reasons.Default(), policy)}, tt.opts...)
+			n, hook, dlq := newTestApp(t, http.StatusNoContent, opts...)
+
+			if err := n.Handle(context.Background(), v1Decision()); err != nil {
+				t.Fatalf("Handle: %v", err)
+			}
+			if hook.requests != 1 {
+				t.Fatalf("webhook called %d times, want 1", hook.requests)
+			}
+			checkV2(t, tt.body(t, hook.bodies[0], hook.headers[0]))
+			if len(dlq.values) != 0 {
+				t.Errorf("dead-lettered %d messages, want none", len(dlq.values))
+			}
+
+			// redelivery of the same event is not sent twice
+			if err := n.Handle(context.Background(), v1Decision()); err != nil {
+				t.Fatalf("Handle duplicate: %v", err)
+			}
+			if hook.requests != 1 {
+				t.Errorf("webhook called %d times after a duplicate, want 1", hook.requests)
+			}
+		})
+	}
+}
+
+func TestHandleDeadLetters(t *testing.T) {
+	newer := v1Decision()
+	newer.Headers[3].Value = []byte("9")
+
+	notExplainable := v1Decision()
+	notExplainable.Headers = append(notExplainable.Headers[:3:3], segmentioKafka.Header{Key: "schema_version", Value: []byte("2")})
+	notExplainable.Value = []byte(`{"payment_id": "p-1", "occurred_at": "2026-03-04T05:06:07Z", "reason_codes": "amount_over_limit"}`)
+
+	tests := []struct {
+		name   string
+		msg    segmentioKafka.Message
+		status int
+	}{
+		{"not json", segmentioKafka.Message{Key: []byte("p-1"), Value: []byte("{not json")}, http.StatusNoContent},
+		{"newer schema version", newer, http.StatusNoContent},
+		{"payload that does not match its type", notExplainable, http.StatusNoContent},
+		{"webhook rejects the payload", v1Decision(), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, hook, dlq := newTestApp(t, tt.status, WithExplanations(reasons.Default(), reasons.Policy{Default: reasons.ModeFull}))
+
+			err := n.Handle(context.Background(), tt.msg)
+			if tt.status >= 400 {
+				// failed deliveries are returned so the consumer retries
+				if err == nil {
+					t.Error("Handle succeeded for a rejected delivery")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Handle = %v, want nil once dead-lettered", err)
+				}
+				if hook.requests != 0 {
+					t.Errorf("webhook called %d times for an undeliverable message", hook.requests)
+				}
+			}
+			if !reflect.DeepEqual(dlq.topics, []string{"notifications.dlq"}) || string(dlq.values[0]) != string(tt.msg.Value) {
+				t.Errorf("dead-lettered %q to %v, want the original message on notifications.dlq", dlq.values, dlq.topics)
+			}
+		})
+	}
+}
+
+func TestHandleKeepsMessageWhenDeadLetterFails(t *testing.T) {
+	n, _, dlq := newTestApp(t, http.StatusNoContent)
+	dlq.err = errors.New("broker unavailable")
+
+	err := n.Handle(context.Background(), segmentioKafka.Message{Key: []byte("p-1"), Value: []byte("{not json")})
+	if !errors.Is(err, dlq.err) {
+		t.Errorf("Handle = %v, want the dead-letter error so the message is not committed", err)
+	}
+}
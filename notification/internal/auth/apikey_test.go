@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeAPIKeys(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func digest(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys, err := LoadAPIKeys(writeAPIKeys(t, `{"keys": [
+		{"id": "ops-dashboard", "sha256": "`+digest("dashboard-key")+`", "role": "viewer"},
+		{"id": "ops-admin", "sha256": "`+digest("admin-key")+`", "role": "admin"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		want    Principal
+		wantErr error
+	}{
+		{"viewer key", "dashboard-key", Principal{Subject: "ops-dashboard", Role: RoleViewer, Method: "api_key"}, nil},
+		{"admin key", "admin-key", Principal{Subject: "ops-admin", Role: RoleAdmin, Method: "api_key"}, nil},
+		{"unknown key", "guessed-key", Principal{}, ErrInvalidCredentials},
+		// the file holds digests; sending one must not authenticate
+		{"digest sent as the key", digest("admin-key"), Principal{}, ErrInvalidCredentials},
+		{"no key", "", Principal{}, ErrNoCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/payments", nil)
+			if tt.key != "" {
+				r.Header.Set(APIKeyHeader, tt.key)
+			}
+			p, err := keys.Authenticate(r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Authenticate = %+v, %v; want %v", p, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || *p != tt.want {
+				t.Errorf("Authenticate = %+v, %v; want %+v", p, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAPIKeysErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not json", `keys`},
+		{"digest not hex", `{"keys": [{"id": "k", "sha256": "zz", "role": "viewer"}]}`},
+		{"digest too short", `{"keys": [{"id": "k", "sha256": "abcd", "role": "viewer"}]}`},
+		{"unknown role", `{"keys": [{"id": "k", "sha256": "` + digest("k") + `", "role": "owner"}]}`},
+		{"public is not a role for keys", `{"keys": [{"id": "k", "sha256": "` + digest("k") + `", "role": "public"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadAPIKeys(writeAPIKeys(t, tt.content)); err == nil {
+				t.Error("LoadAPIKeys succeeded, want an error")
+			}
+		})
+	}
+	if _, err := LoadAPIKeys(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadAPIKeys of a missing file succeeded")
+	}
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServiceTokens(t *testing.T) {
+	s := NewServiceTokens([]byte("shared-secret"))
+	s.now = func() time.Time { return testNow }
+	issue := func(subject string, role Role, ttl time.Duration) string {
+		token, err := s.Issue(subject, role, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			token: func() string { return issue("risk-engine", RoleAnalyst, time.Minute) },
+		},
+		{
+			name: "claims changed after signing",
+			token: func() string {
+				token := issue("risk-engine", RoleViewer, time.Minute)
+				dot := strings.LastIndexByte(token, '.')
+				forged, _ := json.Marshal(serviceClaims{Subject: "risk-engine", Role: RoleAdmin, ExpiresAt: testNow.Add(time.Minute).Unix()})
+				return serviceTokenPrefix + base64.RawURLEncoding.EncodeToString(forged) + token[dot:]
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "signature changed",
+			token: func() string {
+				token := issue("risk-engine", RoleAnalyst, time.Minute)
+				// the first character carries only signature bits, the last
+				// may carry padding that decoding ignores
+				i := strings.LastIndexByte(token, '.') + 1
+				flipped := byte('A')
+				if token[i] == 'A' {
+					flipped = 'B'
+				}
+				return token[:i] + string(flipped) + token[i+1:]
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "signed with another secret",
+			token: func() string {
+				other := NewServiceTokens([]byte("other-secret"))
+				other.now = s.now
+				token, _ := other.Issue("risk-engine", RoleAnalyst, time.Minute)
+				return token
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "signature not base64",
+			token:   func() string { return issue("risk-engine", RoleAnalyst, time.Minute) + "!" },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "no signature",
+			token:   func() string { return serviceTokenPrefix },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "expired",
+			token:   func() string { return issue("risk-engine", RoleAnalyst, -time.Second) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "unknown role",
+			token:   func() string { return issue("risk-engine", "root", time.Minute) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "no subject",
+			token:   func() string { return issue("", RoleViewer, time.Minute) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "jwt",
+			token:   func() string { return "eyJhbGciOiJFZERTQSJ9.e30.c2ln" },
+			wantErr: ErrNoCredentials,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/payments", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token())
+			p, err := s.Authenticate(r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Authenticate = %+v, %v; want %v", p, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if p.Subject != "risk-engine" || p.Role != RoleAnalyst || p.Method != "service_token" {
+				t.Errorf("principal = %+v, want risk-engine as analyst by service_token", p)
+			}
+		})
+	}
+
+	for _, header := range []string{"", "Basic cmlzazpzZWNyZXQ=", "Bearer"} {
+		r := httptest.NewRequest("GET", "/payments", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		if _, err := s.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("Authenticate with Authorization %q = %v, want ErrNoCredentials", header, err)
+		}
+	}
+}
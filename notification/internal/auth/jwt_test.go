@@ -0,0 +1,359 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://idp.example.com"
+	testAudience = "payments"
+)
+
+var testNow = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+	ed  ed25519.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeys{rsa: rsaKey, ec: ecKey, ed: edKey}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// writeJWKS publishes the public halves of keys as rsa-1, ec-1 and ed-1.
+func writeJWKS(t *testing.T, keys testKeys) string {
+	t.Helper()
+	set := map[string][]jwk{"keys": {
+		{Kty: "RSA", Kid: "rsa-1", N: b64(keys.rsa.N.Bytes()), E: b64(big.NewInt(int64(keys.rsa.E)).Bytes())},
+		{Kty: "EC", Kid: "ec-1", Crv: "P-256", X: b64(keys.ec.X.FillBytes(make([]byte, 32))), Y: b64(keys.ec.Y.FillBytes(make([]byte, 32)))},
+		{Kty: "OKP", Kid: "ed-1", Crv: "Ed25519", X: b64(keys.ed.Public().(ed25519.PublicKey))},
+	}}
+	raw, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// signJWT signs claims with key under the given header alg and kid.
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+
+	var sig []byte
+	var err error
+	digest := sha256.Sum256([]byte(signed))
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+		if err == nil {
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(signed))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(sig)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":  "alice",
+		"iss":  testIssuer,
+		"aud":  testAudience,
+		"exp":  testNow.Add(time.Hour).Unix(),
+		"role": "analyst",
+	}
+}
+
+func with(claims map[string]any, key string, value any) map[string]any {
+	if value == nil {
+		delete(claims, key)
+	} else {
+		claims[key] = value
+	}
+	return claims
+}
+
+func TestJWTVerifier(t *testing.T) {
+	keys := newTestKeys(t)
+	v, err := LoadJWTVerifier(writeJWKS(t, keys), testIssuer, testAudience, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return testNow }
+
+	other := newTestKeys(t)
+	tests := []struct {
+		name     string
+		token    func() string
+		wantRole Role
+		wantErr  error
+	}{
+		{
+			name:     "RS256",
+			token:    func() string { return signJWT(t, "RS256", "rsa-1", keys.rsa, validClaims()) },
+			wantRole: RoleAnalyst,
+		},
+		{
+			name:     "ES256",
+			token:    func() string { return signJWT(t, "ES256", "ec-1", keys.ec, validClaims()) },
+			wantRole: RoleAnalyst,
+		},
+		{
+			name:     "EdDSA",
+			token:    func() string { return signJWT(t, "EdDSA", "ed-1", keys.ed, validClaims()) },
+			wantRole: RoleAnalyst,
+		},
+		{
+			name: "highest of several roles",
+			token: func() string {
+				return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "role", []string{"viewer", "admin", "owner"}))
+			},
+			wantRole: RoleAdmin,
+		},
+		{
+			name: "audience in a list",
+			token: func() string {
+				return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "aud", []string{"other", testAudience}))
+			},
+			wantRole: RoleAnalyst,
+		},
+		{
+			name: "expired within clock skew",
+			token: func() string {
+				return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "exp", testNow.Add(-10*time.Second).Unix()))
+			},
+			wantRole: RoleAnalyst,
+		},
+		{
+			name: "not before within clock skew",
+			token: func() string {
+				return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "nbf", testNow.Add(10*time.Second).Unix()))
+			},
+			wantRole: RoleAnalyst,
+		},
+		{
+			name:    "signed by another key",
+			token:   func() string { return signJWT(t, "RS256", "rsa-1", other.rsa, validClaims()) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "claims changed after signing",
+			token: func() string {
+				parts := strings.Split(signJWT(t, "ES256", "ec-1", keys.ec, validClaims()), ".")
+				forged, _ := json.Marshal(with(validClaims(), "role", "admin"))
+				return parts[0] + "." + b64(forged) + "." + parts[2]
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "signature not base64",
+			token: func() string {
+				parts := strings.Split(signJWT(t, "EdDSA", "ed-1", keys.ed, validClaims()), ".")
+				return parts[0] + "." + parts[1] + ".!!"
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "alg of another key type",
+			token:   func() string { return signJWT(t, "RS256", "ec-1", keys.rsa, validClaims()) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "alg none",
+			token: func() string {
+				parts := strings.Split(signJWT(t, "EdDSA", "ed-1", keys.ed, validClaims()), ".")
+				header, _ := json.Marshal(map[string]string{"alg": "none", "kid": "ed-1"})
+				return b64(header) + "." + parts[1] + "."
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "unknown kid",
+			token:   func() string { return signJWT(t, "EdDSA", "ed-2", keys.ed, validClaims()) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "expired",
+			token: func() string {
+				return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "exp", testNow.Add(-time.Minute).Unix()))
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "no exp",
+			token:   func() string { return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "exp", nil)) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "not valid yet",
+			token: func() string {
+				return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "nbf", testNow.Add(time.Minute).Unix()))
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "other issuer",
+			token: func() string {
+				return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "iss", "https://evil.example.com"))
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "no issuer",
+			token:   func() string { return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "iss", nil)) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "other audience",
+			token: func() string {
+				return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "aud", []string{"billing"}))
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "no audience",
+			token:   func() string { return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "aud", nil)) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "no subject",
+			token:   func() string { return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "sub", nil)) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "no known role",
+			token:   func() string { return signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "role", "owner")) },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "malformed",
+			token:   func() string { return "not.a-jwt" },
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "service token",
+			token:   func() string { return serviceTokenPrefix + "e30.c2ln" },
+			wantErr: ErrNoCredentials,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/payments", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token())
+			p, err := v.Authenticate(r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Authenticate = %+v, %v; want %v", p, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if p.Subject != "alice" || p.Role != tt.wantRole || p.Method != "jwt" {
+				t.Errorf("principal = %+v, want alice as %s by jwt", p, tt.wantRole)
+			}
+		})
+	}
+
+	if _, err := v.Authenticate(httptest.NewRequest("GET", "/payments", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Authenticate without a header = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestJWTVerifierRoleClaim(t *testing.T) {
+	keys := newTestKeys(t)
+	v, err := LoadJWTVerifier(writeJWKS(t, keys), testIssuer, testAudience, "payments_roles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return testNow }
+
+	r := httptest.NewRequest("GET", "/payments", nil)
+	r.Header.Set("Authorization", "Bearer "+signJWT(t, "EdDSA", "ed-1", keys.ed, with(validClaims(), "payments_roles", []string{"viewer"})))
+	if p, err := v.Authenticate(r); err != nil || p.Role != RoleViewer {
+		t.Errorf("Authenticate = %+v, %v; want the viewer role from payments_roles", p, err)
+	}
+}
+
+func TestLoadJWTVerifierErrors(t *testing.T) {
+	keys := newTestKeys(t)
+	jwks := writeJWKS(t, keys)
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name     string
+		path     string
+		issuer   string
+		audience string
+	}{
+		{"no issuer", jwks, "", testAudience},
+		{"no audience", jwks, testIssuer, ""},
+		{"missing file", filepath.Join(dir, "missing.json"), testIssuer, testAudience},
+		{"not json", write("bad.json", "keys"), testIssuer, testAudience},
+		{"no keys", write("empty.json", `{"keys": []}`), testIssuer, testAudience},
+		{"unsupported key", write("oct.json", `{"keys": [{"kty": "oct", "kid": "k"}]}`), testIssuer, testAudience},
+		{"short ec key", write("ec.json", `{"keys": [{"kty": "EC", "crv": "P-256", "kid": "k", "x": "AQ", "y": "AQ"}]}`), testIssuer, testAudience},
+		{"rsa key without exponent", write("rsa.json", `{"keys": [{"kty": "RSA", "kid": "k", "n": "AQAB"}]}`), testIssuer, testAudience},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadJWTVerifier(tt.path, tt.issuer, tt.audience, "role"); err == nil {
+				t.Error("LoadJWTVerifier succeeded, want an error")
+			}
+		})
+	}
+}
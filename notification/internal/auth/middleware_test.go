@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// tokenAuth authenticates "Bearer <role>" as a caller holding that role and
+// rejects "Bearer bad".
+type tokenAuth struct{}
+
+func (tokenAuth) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearer(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	role, err := ParseRole(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return &Principal{Subject: "caller", Role: role, Method: "test"}, nil
+}
+
+func testMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		if p, found := FromContext(r.Context()); found {
+			w.Header().Set("X-Principal", p.Subject+"/"+string(p.Role))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	mux.HandleFunc("GET /healthz", ok)
+	mux.HandleFunc("GET /payments/{id}", ok)
+	mux.HandleFunc("POST /payments/{id}/override", ok)
+	mux.HandleFunc("PUT /rules", ok)
+	// registered without a policy entry
+	mux.HandleFunc("DELETE /lists/{id}", ok)
+	return mux
+}
+
+var testPolicy = Policy{
+	"GET /healthz":                 RolePublic,
+	"GET /payments/{id}":           RoleViewer,
+	"POST /payments/{id}/override": RoleAnalyst,
+	"PUT /rules":                   RoleAdmin,
+}
+
+func TestMiddleware(t *testing.T) {
+	h := NewMiddleware(zap.NewNop(), tokenAuth{}, testPolicy).Wrap(testMux())
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		token     string
+		want      int
+		principal string
+	}{
+		{"public route without credentials", "GET", "/healthz", "", http.StatusOK, ""},
+		{"public route ignores bad credentials", "GET", "/healthz", "bad", http.StatusOK, ""},
+		{"no credentials", "GET", "/payments/p1", "", http.StatusUnauthorized, ""},
+		{"invalid credentials", "GET", "/payments/p1", "bad", http.StatusUnauthorized, ""},
+		{"viewer reads", "GET", "/payments/p1", "viewer", http.StatusOK, "caller/viewer"},
+		{"viewer cannot override", "POST", "/payments/p1/override", "viewer", http.StatusForbidden, ""},
+		{"analyst overrides", "POST", "/payments/p1/override", "analyst", http.StatusOK, "caller/analyst"},
+		{"admin overrides", "POST", "/payments/p1/override", "admin", http.StatusOK, "caller/admin"},
+		{"analyst cannot edit rules", "PUT", "/rules", "analyst", http.StatusForbidden, ""},
+		{"admin edits rules", "PUT", "/rules", "admin", http.StatusOK, "caller/admin"},
+		{"route without policy denies analyst", "DELETE", "/lists/l1", "analyst", http.StatusForbidden, ""},
+		{"route without policy allows admin", "DELETE", "/lists/l1", "admin", http.StatusOK, "caller/admin"},
+		{"unknown route", "GET", "/nowhere", "", http.StatusNotFound, ""},
+		{"wrong method", "DELETE", "/rules", "", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Header().Get("X-Principal"); got != tt.principal {
+				t.Errorf("principal = %q, want %q", got, tt.principal)
+			}
+			if challenge := w.Header().Get("WWW-Authenticate"); (tt.want == http.StatusUnauthorized) != (challenge != "") {
+				t.Errorf("WWW-Authenticate = %q with status %d", challenge, w.Code)
+			}
+		})
+	}
+}
+
+func TestMiddlewareDisabled(t *testing.T) {
+	h := NewMiddleware(zap.NewNop(), Chain{}, testPolicy).Disable().Wrap(testMux())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("PUT", "/rules", nil))
+	if w.Code != http.StatusOK || w.Header().Get("X-Principal") != "anonymous/admin" {
+		t.Errorf("disabled middleware = %d as %q, want 200 as anonymous/admin", w.Code, w.Header().Get("X-Principal"))
+	}
+}
+
+func TestChain(t *testing.T) {
+	keys, err := LoadAPIKeys(writeAPIKeys(t, `{"keys": [{"id": "ops", "sha256": "`+digest("ops-key")+`", "role": "admin"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := Chain{keys, tokenAuth{}}
+	tests := []struct {
+		name    string
+		apiKey  string
+		token   string
+		subject string
+		wantErr error
+	}{
+		{"first authenticator", "ops-key", "", "ops", nil},
+		{"falls through to the next", "", "viewer", "caller", nil},
+		{"stops at invalid credentials", "guessed-key", "viewer", "", ErrInvalidCredentials},
+		{"nothing recognized", "", "", "", ErrNoCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/payments/p1", nil)
+			if tt.apiKey != "" {
+				r.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			p, err := chain.Authenticate(r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Authenticate = %+v, %v; want %v", p, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || p.Subject != tt.subject {
+				t.Errorf("Authenticate = %+v, %v; want %s", p, err, tt.subject)
+			}
+		})
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role, required Role
+		want           bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleAnalyst, false},
+		{RoleAnalyst, RoleViewer, true},
+		{RoleAnalyst, RoleAdmin, false},
+		{RoleAdmin, RoleAnalyst, true},
+		{RoleViewer, RolePublic, true},
+		{RolePublic, RoleViewer, false},
+		{"root", RoleViewer, false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Allows(tt.required); got != tt.want {
+			t.Errorf("%s.Allows(%s) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
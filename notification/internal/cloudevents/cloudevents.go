@@ -0,0 +1,234 @@
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	SpecVersion = "1.0"
+
+	ModeStructured = "structured"
+	ModeBinary     = "binary"
+
+	StructuredContentType = "application/cloudevents+json; charset=UTF-8"
+)
+
+var ErrInvalidEvent = errors.New("cloudevent requires id, source and type")
+
+type Event struct {
+	ID              string
+	Source          string
+	Type            string
+	Subject         string
+	Time            time.Time
+	DataContentType string
+	Data            []byte
+	Extensions      map[string]string
+}
+
+func New(id, source, eventType string) Event {
+	return Event{
+		ID:         id,
+		Source:     source,
+		Type:       eventType,
+		Time:       time.Now().UTC(),
+		Extensions: map[string]string{},
+	}
+}
+
+func (e Event) Validate() error {
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return ErrInvalidEvent
+	}
+	return nil
+}
+
+// MarshalJSON renders the event in the structured JSON format. JSON data is
+// embedded as-is, anything else travels base64 encoded in data_base64.
+func (e Event) MarshalJSON() ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	out := map[string]any{
+		"specversion": SpecVersion,
+		"id":          e.ID,
+		"source":      e.Source,
+		"type":        e.Type,
+	}
+	if e.Subject != "" {
+		out["subject"] = e.Subject
+	}
+	if !e.Time.IsZero() {
+		out["time"] = e.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if e.DataContentType != "" {
+		out["datacontenttype"] = e.DataContentType
+	}
+	for k, v := range e.Extensions {
+		out[k] = v
+	}
+	if len(e.Data) > 0 {
+		if isJSON(e.DataContentType) && json.Valid(e.Data) {
+			out["data"] = json.RawMessage(e.Data)
+		} else {
+			out["data_base64"] = e.Data
+		}
+	}
+	return json.Marshal(out)
+}
+
+func (e *Event) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	var specVersion string
+	*e = Event{Extensions: map[string]string{}}
+	for k, v := range raw {
+		switch k {
+		case "data":
+			e.Data = []byte(v)
+		case "data_base64":
+			if err := json.Unmarshal(v, &e.Data); err != nil {
+				return err
+			}
+		default:
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				// extension attributes may be non-string JSON scalars
+				s = string(v)
+			}
+			if err := e.setAttribute(k, s); err != nil {
+				return err
+			}
+			if k == "specversion" {
+				specVersion = s
+			}
+		}
+	}
+	if specVersion != SpecVersion {
+		return errors.New("unsupported cloudevents specversion: " + specVersion)
+	}
+	return e.Validate()
+}
+
+func (e *Event) setAttribute(name, value string) error {
+	switch name {
+	case "specversion":
+	case "id":
+		e.ID = value
+	case "source":
+		e.Source = value
+	case "type":
+		e.Type = value
+	case "subject":
+		e.Subject = value
+	case "datacontenttype":
+		e.DataContentType = value
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return err
+		}
+		e.Time = t
+	default:
+		e.Extensions[name] = value
+	}
+	return nil
+}
+
+// FromKafka decodes a CloudEvent carried in either content mode. The boolean
+// reports whether the message was a CloudEvent at all.
+func FromKafka(msg kafka.Message) (Event, bool, error) {
+	headers := map[string]string{}
+	for _, h := range msg.Headers {
+		headers[strings.ToLower(h.Key)] = string(h.Value)
+	}
+
+	if strings.HasPrefix(headers["content-type"], "application/cloudevents+json") {
+		var e Event
+		if err := json.Unmarshal(msg.Value, &e); err != nil {
+			return Event{}, true, err
+		}
+		return e, true, nil
+	}
+
+	specVersion, ok := headers["ce_specversion"]
+	if !ok {
+		return Event{}, false, nil
+	}
+	if specVersion != SpecVersion {
+		return Event{}, true, errors.New("unsupported cloudevents specversion: " + specVersion)
+	}
+
+	e := Event{Extensions: map[string]string{}, Data: msg.Value, DataContentType: headers["content-type"]}
+	for k, v := range headers {
+		if !strings.HasPrefix(k, "ce_") {
+			continue
+		}
+		if err := e.setAttribute(strings.TrimPrefix(k, "ce_"), v); err != nil {
+			return Event{}, true, err
+		}
+	}
+	return e, true, e.Validate()
+}
+
+// NewHTTPRequest builds a POST request carrying the event using the
+// CloudEvents HTTP protocol binding.
+func NewHTTPRequest(ctx context.Context, url string, e Event, mode string) (*http.Request, error) {
+	switch mode {
+	case ModeStructured:
+		body, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", StructuredContentType)
+		return req, nil
+	case ModeBinary:
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(e.Data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("ce-specversion", SpecVersion)
+		req.Header.Set("ce-id", e.ID)
+		req.Header.Set("ce-source", e.Source)
+		req.Header.Set("ce-type", e.Type)
+		if e.Subject != "" {
+			req.Header.Set("ce-subject", e.Subject)
+		}
+		if !e.Time.IsZero() {
+			req.Header.Set("ce-time", e.Time.UTC().Format(time.RFC3339Nano))
+		}
+		if e.DataContentType != "" {
+			req.Header.Set("Content-Type", e.DataContentType)
+		}
+		for k, v := range e.Extensions {
+			req.Header.Set("ce-"+k, v)
+		}
+		return req, nil
+	default:
+		return nil, errors.New("unknown cloudevents mode: " + mode)
+	}
+}
+
+func isJSON(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return ct == "" || ct == "application/json" || ct == "text/json" || strings.HasSuffix(ct, "+json")
+}
@@ -0,0 +1,212 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func sample() Event {
+	e := New("evt-1", "decision-orchestrator", "PaymentDecisionFinalized")
+	e.Subject = "p-1"
+	e.Time = time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC)
+	e.DataContentType = "application/json"
+	e.Data = []byte(`{"payment_id":"p-1"}`)
+	e.Extensions["schemaversion"] = "2"
+	return e
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+	}{
+		{"json data", "application/json", []byte(`{"payment_id":"p-1"}`)},
+		{"json data without content type", "", []byte(`{"a":[1,2]}`)},
+		{"binary data", "application/avro", []byte{0x00, 0x02, 0xfe, 0xff}},
+		{"no data", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := sample()
+			e.DataContentType, e.Data = tt.contentType, tt.data
+			raw, err := json.Marshal(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got Event
+			if err := json.Unmarshal(raw, &got); err != nil {
+				t.Fatalf("Unmarshal(%s): %v", raw, err)
+			}
+			if !reflect.DeepEqual(got, e) {
+				t.Errorf("round trip = %+v, want %+v", got, e)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"not json", `{`},
+		{"missing specversion", `{"id":"1","source":"s","type":"t"}`},
+		{"unsupported specversion", `{"specversion":"0.3","id":"1","source":"s","type":"t"}`},
+		{"missing id", `{"specversion":"1.0","source":"s","type":"t"}`},
+		{"bad time", `{"specversion":"1.0","id":"1","source":"s","type":"t","time":"yesterday"}`},
+		{"bad data_base64", `{"specversion":"1.0","id":"1","source":"s","type":"t","data_base64":"%%%"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e Event
+			if err := json.Unmarshal([]byte(tt.raw), &e); err == nil {
+				t.Errorf("Unmarshal succeeded: %+v", e)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONNonStringExtension(t *testing.T) {
+	var e Event
+	raw := `{"specversion":"1.0","id":"1","source":"s","type":"t","schemaversion":2,"partitionkey":"p-1"}`
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Extensions["schemaversion"] != "2" || e.Extensions["partitionkey"] != "p-1" {
+		t.Errorf("extensions = %v", e.Extensions)
+	}
+}
+
+func TestFromKafka(t *testing.T) {
+	want := sample()
+	structured, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryHeaders := []kafka.Header{
+		{Key: "ce_specversion", Value: []byte("1.0")},
+		{Key: "ce_id", Value: []byte("evt-1")},
+		{Key: "ce_source", Value: []byte("decision-orchestrator")},
+		{Key: "ce_type", Value: []byte("PaymentDecisionFinalized")},
+		{Key: "ce_subject", Value: []byte("p-1")},
+		{Key: "ce_time", Value: []byte("2026-01-02T03:04:05.0000006Z")},
+		{Key: "ce_schemaversion", Value: []byte("2")},
+		{Key: "Content-Type", Value: []byte("application/json")},
+	}
+
+	tests := []struct {
+		name    string
+		msg     kafka.Message
+		isEvent bool
+		wantErr bool
+	}{
+		{
+			name:    "structured",
+			msg:     kafka.Message{Value: structured, Headers: []kafka.Header{{Key: "content-type", Value: []byte(StructuredContentType)}}},
+			isEvent: true,
+		},
+		{
+			name:    "binary",
+			msg:     kafka.Message{Value: want.Data, Headers: binaryHeaders},
+			isEvent: true,
+		},
+		{
+			name: "plain json",
+			msg:  kafka.Message{Value: want.Data, Headers: []kafka.Header{{Key: "content-type", Value: []byte("application/json")}}},
+		},
+		{
+			name:    "structured with a broken envelope",
+			msg:     kafka.Message{Value: []byte(`{"id":`), Headers: []kafka.Header{{Key: "content-type", Value: []byte(StructuredContentType)}}},
+			isEvent: true,
+			wantErr: true,
+		},
+		{
+			name:    "binary with an unsupported specversion",
+			msg:     kafka.Message{Value: want.Data, Headers: []kafka.Header{{Key: "ce_specversion", Value: []byte("0.3")}}},
+			isEvent: true,
+			wantErr: true,
+		},
+		{
+			name:    "binary without required attributes",
+			msg:     kafka.Message{Value: want.Data, Headers: []kafka.Header{{Key: "ce_specversion", Value: []byte("1.0")}, {Key: "ce_id", Value: []byte("evt-1")}}},
+			isEvent: true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, isEvent, err := FromKafka(tt.msg)
+			if isEvent != tt.isEvent {
+				t.Fatalf("isEvent = %v, want %v", isEvent, tt.isEvent)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Error("FromKafka succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.isEvent && !reflect.DeepEqual(got, want) {
+				t.Errorf("FromKafka = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestNewHTTPRequest(t *testing.T) {
+	ctx := context.Background()
+	e := sample()
+
+	req, err := NewHTTPRequest(ctx, "http://merchant.example/hook", e, ModeStructured)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "POST" || req.Header.Get("Content-Type") != StructuredContentType {
+		t.Errorf("structured request %s with content type %q", req.Method, req.Header.Get("Content-Type"))
+	}
+	body, _ := io.ReadAll(req.Body)
+	var got Event
+	if err := json.Unmarshal(body, &got); err != nil || !reflect.DeepEqual(got, e) {
+		t.Errorf("structured body = %s, %v", body, err)
+	}
+
+	req, err = NewHTTPRequest(ctx, "http://merchant.example/hook", e, ModeBinary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(req.Body)
+	if string(body) != string(e.Data) {
+		t.Errorf("binary body = %s, want the data", body)
+	}
+	want := map[string]string{
+		"Ce-Specversion":   "1.0",
+		"Ce-Id":            "evt-1",
+		"Ce-Source":        "decision-orchestrator",
+		"Ce-Type":          "PaymentDecisionFinalized",
+		"Ce-Subject":       "p-1",
+		"Ce-Time":          "2026-01-02T03:04:05.0000006Z",
+		"Ce-Schemaversion": "2",
+		"Content-Type":     "application/json",
+	}
+	for k, v := range want {
+		if got := req.Header.Get(k); got != v {
+			t.Errorf("header %s = %q, want %q", k, got, v)
+		}
+	}
+
+	if _, err := NewHTTPRequest(ctx, "http://merchant.example/hook", e, "batched"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+	if _, err := NewHTTPRequest(ctx, "http://merchant.example/hook", Event{}, ModeBinary); err == nil {
+		t.Error("binary mode accepted an invalid event")
+	}
+}
@@ -0,0 +1,143 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/schema"
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Codec converts between the JSON documents the services work with and the
+// bytes carried on the wire for a topic.
+type Codec interface {
+	ContentType() string
+	SchemaID() int
+	Encode(payload []byte) ([]byte, error)
+	Decode(data []byte) ([]byte, error)
+}
+
+func New(s schema.Schema) (Codec, error) {
+	switch s.Format {
+	case schema.FormatJSON:
+		required, err := schema.RequiredProperties(s)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonCodec{id: s.ID, required: required}, nil
+	case schema.FormatAvro:
+		as, err := schema.AvroSchema(s)
+		if err != nil {
+			return nil, err
+		}
+		return &avroCodec{id: s.ID, schema: as}, nil
+	case schema.FormatProtobuf:
+		md, err := schema.MessageDescriptor(s)
+		if err != nil {
+			return nil, err
+		}
+		return &protoCodec{id: s.ID, desc: md}, nil
+	default:
+		return nil, fmt.Errorf("unsupported codec format %q", s.Format)
+	}
+}
+
+// JSON returns the schema-less passthrough codec.
+func JSON() Codec {
+	return &jsonCodec{}
+}
+
+type jsonCodec struct {
+	id       int
+	required []string
+}
+
+func (c *jsonCodec) ContentType() string { return "application/json" }
+func (c *jsonCodec) SchemaID() int       { return c.id }
+
+func (c *jsonCodec) Encode(payload []byte) ([]byte, error) {
+	return payload, c.check(payload)
+}
+
+func (c *jsonCodec) Decode(data []byte) ([]byte, error) {
+	return data, c.check(data)
+}
+
+func (c *jsonCodec) check(doc []byte) error {
+	if len(c.required) == 0 {
+		if !json.Valid(doc) {
+			return errors.New("invalid json payload")
+		}
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return err
+	}
+	for _, name := range c.required {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("payload missing required field %q", name)
+		}
+	}
+	return nil
+}
+
+type avroCodec struct {
+	id     int
+	schema avro.Schema
+}
+
+func (c *avroCodec) ContentType() string { return "application/avro" }
+func (c *avroCodec) SchemaID() int       { return c.id }
+
+func (c *avroCodec) Encode(payload []byte) ([]byte, error) {
+	doc, err := decodeJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	v, err := fromJSON(c.schema, doc)
+	if err != nil {
+		return nil, err
+	}
+	return avro.Marshal(c.schema, v)
+}
+
+func (c *avroCodec) Decode(data []byte) ([]byte, error) {
+	var v any
+	if err := avro.Unmarshal(c.schema, data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// protoCodec maps JSON onto a dynamic message built from the registered
+// descriptor. Decoded 64-bit integers follow protojson and come back as
+// strings.
+type protoCodec struct {
+	id   int
+	desc protoreflect.MessageDescriptor
+}
+
+func (c *protoCodec) ContentType() string { return "application/x-protobuf" }
+func (c *protoCodec) SchemaID() int       { return c.id }
+
+func (c *protoCodec) Encode(payload []byte) ([]byte, error) {
+	m := dynamicpb.NewMessage(c.desc)
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(payload, m); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(m)
+}
+
+func (c *protoCodec) Decode(data []byte) ([]byte, error) {
+	m := dynamicpb.NewMessage(c.desc)
+	if err := proto.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+}
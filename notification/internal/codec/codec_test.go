@@ -0,0 +1,316 @@
+package codec
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/schema"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const decisionAvro = `{"type":"record","name":"Decision","namespace":"payments","fields":[
+	{"name":"payment_id","type":"string"},
+	{"name":"amount","type":"long"},
+	{"name":"score","type":"double"},
+	{"name":"timed_out","type":"boolean"},
+	{"name":"status","type":{"type":"enum","name":"Status","symbols":["APPROVED","DECLINED"]}},
+	{"name":"reason","type":["null","string"],"default":null},
+	{"name":"reason_codes","type":{"type":"array","items":"string"}},
+	{"name":"labels","type":{"type":"map","values":"long"}},
+	{"name":"occurred_at","type":{"type":"long","logicalType":"timestamp-millis"}},
+	{"name":"version","type":"int","default":1}]}`
+
+// decisionProto is payments.Decision with payment_id, amount, score,
+// timed_out and reason_codes as fields 1 to 5.
+func decisionProto(t *testing.T) []byte {
+	t.Helper()
+	field := func(name string, n int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{Name: proto.String(name), JsonName: proto.String(name), Number: proto.Int32(n), Type: typ.Enum(), Label: label.Enum()}
+	}
+	opt, rep := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("decision.proto"),
+		Package: proto.String("payments"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Decision"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("payment_id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt),
+				field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, opt),
+				field("score", 3, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, opt),
+				field("timed_out", 4, descriptorpb.FieldDescriptorProto_TYPE_BOOL, opt),
+				field("reason_codes", 5, descriptorpb.FieldDescriptorProto_TYPE_STRING, rep),
+			},
+		}},
+	}}}
+	raw, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// testRegistry writes a file registry with a JSON, an Avro and a Protobuf
+// subject and loads it.
+func testRegistry(t *testing.T) *schema.FileRegistry {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"decision.avsc": []byte(decisionAvro),
+		"decision.pb":   decisionProto(t),
+		"outbox.json":   []byte(`{"required":["event_id","type"],"properties":{"event_id":{"type":"string"},"type":{"type":"string"}}}`),
+	}
+	for name, raw := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), raw, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index := `{"schemas":[
+		{"id":1,"subject":"payments.outbox-value","version":1,"format":"json","schema_file":"outbox.json"},
+		{"id":2,"subject":"payments.decisions-value","version":1,"format":"avro","schema_file":"decision.avsc"},
+		{"id":3,"subject":"payments.scores-value","version":1,"format":"protobuf","message":"payments.Decision","schema_file":"decision.pb"}
+	]}`
+	path := filepath.Join(dir, "registry.json")
+	if err := os.WriteFile(path, []byte(index), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r, err := schema.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.CheckCompatibility(r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func codecFor(t *testing.T, r schema.Registry, id int) Codec {
+	t.Helper()
+	s, err := r.ByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func jsonEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+	// numbers stay json.Number so 64-bit values are compared exactly
+	g, err := decodeJSON(got)
+	if err != nil {
+		t.Fatalf("decoded %s: %v", got, err)
+	}
+	w, err := decodeJSON([]byte(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("decoded %s\nwant    %s", got, want)
+	}
+}
+
+func TestAvroRoundTrip(t *testing.T) {
+	c := codecFor(t, testRegistry(t), 2)
+	if c.ContentType() != "application/avro" || c.SchemaID() != 2 {
+		t.Errorf("codec %s with schema %d", c.ContentType(), c.SchemaID())
+	}
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{
+			name:    "all fields",
+			payload: `{"payment_id":"p-1","amount":9007199254740993,"score":0.25,"timed_out":true,"status":"DECLINED","reason":"velocity","reason_codes":["user_velocity"],"labels":{"a":1},"occurred_at":"2026-01-02T03:04:05.678Z","version":2}`,
+			want:    `{"payment_id":"p-1","amount":9007199254740993,"score":0.25,"timed_out":true,"status":"DECLINED","reason":"velocity","reason_codes":["user_velocity"],"labels":{"a":1},"occurred_at":"2026-01-02T03:04:05.678Z","version":2}`,
+		},
+		{
+			name:    "defaults and epoch millis",
+			payload: `{"payment_id":"p-2","amount":0,"score":1,"timed_out":false,"status":"APPROVED","reason_codes":[],"labels":{},"occurred_at":1767323045678,"extra":"dropped"}`,
+			want:    `{"payment_id":"p-2","amount":0,"score":1,"timed_out":false,"status":"APPROVED","reason":null,"reason_codes":[],"labels":{},"occurred_at":"2026-01-02T03:04:05.678Z","version":1}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := c.Encode([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			out, err := c.Decode(data)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			jsonEqual(t, out, tt.want)
+		})
+	}
+}
+
+func TestAvroEncodeErrors(t *testing.T) {
+	c := codecFor(t, testRegistry(t), 2)
+	base := `"payment_id":"p-1","score":0.25,"timed_out":true,"status":"DECLINED","reason_codes":[],"labels":{},"occurred_at":"2026-01-02T03:04:05Z"`
+	for name, payload := range map[string]string{
+		"not json":          `{`,
+		"not an object":     `[1]`,
+		"missing field":     `{` + base + `}`,
+		"string for long":   `{"amount":"1",` + base + `}`,
+		"fraction for long": `{"amount":1.5,` + base + `}`,
+		"bad union branch":  `{"amount":1,"reason":7,` + base + `}`,
+		"bad timestamp":     `{"payment_id":"p-1","amount":1,"score":0.25,"timed_out":true,"status":"DECLINED","reason_codes":[],"labels":{},"occurred_at":true}`,
+	} {
+		if _, err := c.Encode([]byte(payload)); err == nil {
+			t.Errorf("%s: Encode succeeded", name)
+		}
+	}
+	if _, err := c.Decode([]byte{0xff}); err == nil {
+		t.Error("Decode of truncated data succeeded")
+	}
+}
+
+func TestProtobufRoundTrip(t *testing.T) {
+	c := codecFor(t, testRegistry(t), 3)
+	if c.ContentType() != "application/x-protobuf" || c.SchemaID() != 3 {
+		t.Errorf("codec %s with schema %d", c.ContentType(), c.SchemaID())
+	}
+	data, err := c.Encode([]byte(`{"payment_id":"p-1","amount":"1500","score":0.5,"timed_out":true,"reason_codes":["a","b"],"unknown":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// protojson renders 64-bit integers as strings
+	jsonEqual(t, out, `{"payment_id":"p-1","amount":"1500","score":0.5,"timed_out":true,"reason_codes":["a","b"]}`)
+
+	if _, err := c.Encode([]byte(`{"amount":"many"}`)); err == nil {
+		t.Error("Encode of a non-numeric amount succeeded")
+	}
+	if _, err := c.Decode([]byte{0x0a, 0x05}); err == nil {
+		t.Error("Decode of truncated data succeeded")
+	}
+}
+
+func TestJSONCodec(t *testing.T) {
+	c := codecFor(t, testRegistry(t), 1)
+	tests := []struct {
+		name    string
+		codec   Codec
+		payload string
+		wantErr bool
+	}{
+		{"required fields present", c, `{"event_id":"e-1","type":"PaymentCreated","extra":1}`, false},
+		{"required field missing", c, `{"event_id":"e-1"}`, true},
+		{"not an object", c, `[1]`, true},
+		{"passthrough accepts any json", JSON(), `[1,2]`, false},
+		{"passthrough rejects invalid json", JSON(), `{`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tt.codec.Encode([]byte(tt.payload))
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Encode = %v, want error %v", err, tt.wantErr)
+			}
+			if _, derr := tt.codec.Decode([]byte(tt.payload)); (derr != nil) != tt.wantErr {
+				t.Errorf("Decode = %v, want error %v", derr, tt.wantErr)
+			}
+			if err == nil && string(out) != tt.payload {
+				t.Errorf("Encode changed the payload to %s", out)
+			}
+		})
+	}
+}
+
+func TestTopics(t *testing.T) {
+	r := testRegistry(t)
+	topics, err := NewTopics(r, map[string]string{
+		"payments.outbox":    "json",
+		"payments.decisions": "avro",
+		"payments.scores":    "protobuf",
+		"payments.other":     "json",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"payment_id":"p-1","amount":1,"score":0.5,"timed_out":false,"status":"APPROVED","reason_codes":[],"labels":{},"occurred_at":"2026-01-02T03:04:05Z"}`)
+	enc, err := topics.Encode("payments.decisions", payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc.SchemaID != 2 || enc.ContentType != "application/avro" {
+		t.Errorf("Encode = schema %d, %s", enc.SchemaID, enc.ContentType)
+	}
+	headers := enc.Headers()
+	if len(headers) != 1 || headers[0].Key != SchemaIDHeader || string(headers[0].Value) != "2" {
+		t.Errorf("headers = %v", headers)
+	}
+	// the schema id header picks the codec, whatever the topic
+	if out, err := topics.Decode("payments.other", headers, enc.Value); err != nil {
+		t.Errorf("Decode by schema id: %v", err)
+	} else {
+		jsonEqual(t, out, `{"payment_id":"p-1","amount":1,"score":0.5,"timed_out":false,"status":"APPROVED","reason":null,"reason_codes":[],"labels":{},"occurred_at":"2026-01-02T03:04:05Z","version":1}`)
+	}
+
+	if enc, err := topics.Encode("payments.other", []byte(`{"a":1}`)); err != nil || enc.SchemaID != 0 || enc.Headers() != nil {
+		t.Errorf("Encode on a topic without a subject = %+v, %v; want plain json", enc, err)
+	}
+	for name, h := range map[string][]kafka.Header{
+		"unknown schema id": {{Key: SchemaIDHeader, Value: []byte("99")}},
+		"bad schema id":     {{Key: SchemaIDHeader, Value: []byte("two")}},
+	} {
+		if _, err := topics.Decode("payments.decisions", h, enc.Value); err == nil {
+			t.Errorf("%s: Decode succeeded", name)
+		}
+	}
+
+	var none *Topics
+	if out, err := none.Decode("payments.decisions", nil, []byte(`{"a":1}`)); err != nil || string(out) != `{"a":1}` {
+		t.Errorf("nil Topics Decode = %s, %v; want passthrough", out, err)
+	}
+	if _, err := none.Decode("payments.decisions", headers, enc.Value); err == nil {
+		t.Error("nil Topics decoded a schema id")
+	}
+}
+
+func TestNewTopicsErrors(t *testing.T) {
+	r := testRegistry(t)
+	tests := []struct {
+		name     string
+		registry schema.Registry
+		formats  map[string]string
+	}{
+		{"avro without a registry", nil, map[string]string{"payments.decisions": "avro"}},
+		{"format differs from the subject", r, map[string]string{"payments.decisions": "protobuf"}},
+		{"avro without a subject", r, map[string]string{"payments.unknown": "avro"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTopics(tt.registry, tt.formats); err == nil {
+				t.Error("NewTopics succeeded")
+			}
+		})
+	}
+}
+
+func TestParseTopicFormats(t *testing.T) {
+	got, err := ParseTopicFormats(" payments.outbox=avro, ,payments.decisions=json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"payments.outbox": "avro", "payments.decisions": "json"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTopicFormats = %v, want %v", got, want)
+	}
+	for _, spec := range []string{"payments.outbox", "=avro", "payments.outbox=xml"} {
+		if _, err := ParseTopicFormats(spec); err == nil {
+			t.Errorf("ParseTopicFormats(%q) succeeded", spec)
+		}
+	}
+}
@@ -1,23 +1,38 @@
 package config
 
 import (
+	"errors"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	AppName       string
-	Env           string
-	LogLevel      string
-	KafkaBrokers  []string
-	GroupID       string
-	OutboxTopic   string
-	DLQTopic      string
-	HTTPAddr      string
-	OTELEndpoint  string
-	NotifyWebhook string
-	Timeout       time.Duration
+	AppName         string
+	Env             string
+	LogLevel        string
+	KafkaBrokers    []string
+	GroupID         string
+	OutboxTopic     string
+	DLQTopic        string
+	HTTPAddr        string
+	OTELEndpoint    string
+	NotifyWebhook   string
+	Timeout         time.Duration
+	WebhookFormat   string
+	CloudEventsMode string
+	TopicCodecs     string
+	SchemaRegistry  string
+	AuthDisabled    bool
+	AuthAPIKeysFile string
+	AuthHMACSecret  string
+	AuthJWKSFile    string
+	AuthJWTIssuer   string
+	AuthJWTAudience string
+	AuthRoleClaim   string
+	ReasonCodesFile string
+	RedactionFile   string
+	RedactionMode   string
 }
 
 func Load() (*Config, error) {
@@ -36,18 +51,57 @@ func Load() (*Config, error) {
 	v.SetDefault("OTEL_ENDPOINT", "otel-collector:4317")
 	v.SetDefault("NOTIFY_WEBHOOK", "http://mock-webhook:8080/notify")
 	v.SetDefault("TIMEOUT", 5*time.Second)
+	v.SetDefault("WEBHOOK_FORMAT", "json")
+	v.SetDefault("CLOUDEVENTS_MODE", "structured")
+	v.SetDefault("TOPIC_CODECS", "")
+	v.SetDefault("SCHEMA_REGISTRY_PATH", "")
+	v.SetDefault("AUTH_DISABLED", false)
+	v.SetDefault("AUTH_API_KEYS_FILE", "")
+	v.SetDefault("AUTH_HMAC_SECRET", "")
+	v.SetDefault("AUTH_JWKS_FILE", "")
+	v.SetDefault("AUTH_JWT_ISSUER", "")
+	v.SetDefault("AUTH_JWT_AUDIENCE", "")
+	v.SetDefault("AUTH_ROLE_CLAIM", "role")
+	v.SetDefault("REASON_CODES_FILE", "")
+	v.SetDefault("REDACTION_POLICY_FILE", "")
+	v.SetDefault("REDACTION_DEFAULT_MODE", "full")
 
-	return &Config{
-		AppName:       v.GetString("APP_NAME"),
-		Env:           v.GetString("ENV"),
-		LogLevel:      v.GetString("LOG_LEVEL"),
-		KafkaBrokers:  v.GetStringSlice("KAFKA_BROKERS"),
-		GroupID:       v.GetString("GROUP_ID"),
-		OutboxTopic:   v.GetString("OUTBOX_TOPIC"),
-		DLQTopic:      v.GetString("DLQ_TOPIC"),
-		HTTPAddr:      v.GetString("HTTP_ADDR"),
-		OTELEndpoint:  v.GetString("OTEL_ENDPOINT"),
-		NotifyWebhook: v.GetString("NOTIFY_WEBHOOK"),
-		Timeout:       v.GetDuration("TIMEOUT"),
-	}, nil
+	cfg := &Config{
+		AppName:         v.GetString("APP_NAME"),
+		Env:             v.GetString("ENV"),
+		LogLevel:        v.GetString("LOG_LEVEL"),
+		KafkaBrokers:    v.GetStringSlice("KAFKA_BROKERS"),
+		GroupID:         v.GetString("GROUP_ID"),
+		OutboxTopic:     v.GetString("OUTBOX_TOPIC"),
+		DLQTopic:        v.GetString("DLQ_TOPIC"),
+		HTTPAddr:        v.GetString("HTTP_ADDR"),
+		OTELEndpoint:    v.GetString("OTEL_ENDPOINT"),
+		NotifyWebhook:   v.GetString("NOTIFY_WEBHOOK"),
+		Timeout:         v.GetDuration("TIMEOUT"),
+		WebhookFormat:   v.GetString("WEBHOOK_FORMAT"),
+		CloudEventsMode: v.GetString("CLOUDEVENTS_MODE"),
+		TopicCodecs:     v.GetString("TOPIC_CODECS"),
+		SchemaRegistry:  v.GetString("SCHEMA_REGISTRY_PATH"),
+		AuthDisabled:    v.GetBool("AUTH_DISABLED"),
+		AuthAPIKeysFile: v.GetString("AUTH_API_KEYS_FILE"),
+		AuthHMACSecret:  v.GetString("AUTH_HMAC_SECRET"),
+		AuthJWKSFile:    v.GetString("AUTH_JWKS_FILE"),
+		AuthJWTIssuer:   v.GetString("AUTH_JWT_ISSUER"),
+		AuthJWTAudience: v.GetString("AUTH_JWT_AUDIENCE"),
+		AuthRoleClaim:   v.GetString("AUTH_ROLE_CLAIM"),
+		ReasonCodesFile: v.GetString("REASON_CODES_FILE"),
+		RedactionFile:   v.GetString("REDACTION_POLICY_FILE"),
+		RedactionMode:   v.GetString("REDACTION_DEFAULT_MODE"),
+	}
+
+	if cfg.WebhookFormat != "json" && cfg.WebhookFormat != "cloudevents" {
+		return nil, errors.New("WEBHOOK_FORMAT must be json or cloudevents")
+	}
+	if cfg.CloudEventsMode != "structured" && cfg.CloudEventsMode != "binary" {
+		return nil, errors.New("CLOUDEVENTS_MODE must be structured or binary")
+	}
+	if cfg.AuthJWKSFile != "" && (cfg.AuthJWTIssuer == "" || cfg.AuthJWTAudience == "") {
+		return nil, errors.New("AUTH_JWKS_FILE requires AUTH_JWT_ISSUER and AUTH_JWT_AUDIENCE")
+	}
+	return cfg, nil
 }
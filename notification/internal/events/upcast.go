@@ -0,0 +1,110 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Upcaster rewrites a payload of one schema version into the shape of the
+// next version.
+type Upcaster func(doc map[string]any) (map[string]any, error)
+
+type Upcasters struct {
+	mu      sync.RWMutex
+	chains  map[string]map[int]Upcaster
+	current map[string]int
+}
+
+func NewUpcasters() *Upcasters {
+	return &Upcasters{chains: map[string]map[int]Upcaster{}, current: map[string]int{}}
+}
+
+// Register adds the step from version `from` to `from+1` for an event type
+// and raises the type's current version accordingly.
+func (u *Upcasters) Register(eventType string, from int, fn Upcaster) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.chains[eventType] == nil {
+		u.chains[eventType] = map[int]Upcaster{}
+	}
+	u.chains[eventType][from] = fn
+	if u.current[eventType] < from+1 {
+		u.current[eventType] = from + 1
+	}
+}
+
+func (u *Upcasters) Current(eventType string) int {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if v, ok := u.current[eventType]; ok {
+		return v
+	}
+	return 1
+}
+
+// Upcast walks the registered chain from version to the current version of
+// eventType. Unknown event types and payloads already at the current version
+// are returned unchanged.
+func (u *Upcasters) Upcast(eventType string, version int, payload []byte) ([]byte, int, error) {
+	if version <= 0 {
+		version = 1
+	}
+	current := u.Current(eventType)
+	if version == current {
+		return payload, version, nil
+	}
+	if version > current {
+		return nil, version, fmt.Errorf("%s v%d is newer than supported v%d", eventType, version, current)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return nil, version, err
+	}
+
+	u.mu.RLock()
+	chain := u.chains[eventType]
+	u.mu.RUnlock()
+	for v := version; v < current; v++ {
+		step, ok := chain[v]
+		if !ok {
+			return nil, v, fmt.Errorf("no upcaster for %s v%d", eventType, v)
+		}
+		next, err := step(doc)
+		if err != nil {
+			return nil, v, fmt.Errorf("upcast %s v%d: %w", eventType, v, err)
+		}
+		doc = next
+	}
+	doc["schema_version"] = current
+
+	out, err := json.Marshal(doc)
+	return out, current, err
+}
+
+// PayloadVersion reads schema_version from a JSON payload, treating payloads
+// written before versioning existed as version 1.
+func PayloadVersion(payload []byte) int {
+	var doc struct {
+		SchemaVersion json.RawMessage `json:"schema_version"`
+	}
+	if err := json.Unmarshal(payload, &doc); err != nil || len(doc.SchemaVersion) == 0 {
+		return 1
+	}
+	v, err := strconv.Atoi(string(doc.SchemaVersion))
+	if err != nil || v <= 0 {
+		return 1
+	}
+	return v
+}
+
+func rename(doc map[string]any, from, to string) {
+	if v, ok := doc[from]; ok {
+		if _, exists := doc[to]; !exists {
+			doc[to] = v
+		}
+		delete(doc, from)
+	}
+}
@@ -0,0 +1,10 @@
+package http
+
+import "github.com/dmehra2102/payments-risk-decisioning/notification/internal/auth"
+
+// RoutePolicy is the least role needed for each route. Routes missing here
+// are admin only.
+var RoutePolicy = auth.Policy{
+	"/healthz": auth.RolePublic,
+	"/readyz":  auth.RolePublic,
+}
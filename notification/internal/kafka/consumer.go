@@ -2,9 +2,12 @@ package kafka
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/codec"
 	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
 )
 
 type Handler func(ctx context.Context, msg kafka.Message) error
@@ -12,6 +15,11 @@ type Handler func(ctx context.Context, msg kafka.Message) error
 type Consumer struct {
 	r       *kafka.Reader
 	handler Handler
+	codecs  *codec.Topics
+
+	log      *zap.Logger
+	dlq      *Producer
+	dlqTopic string
 }
 
 func NewConsumer(brokers []string, groupID, topic string, handler Handler) *Consumer {
@@ -24,7 +32,21 @@ func NewConsumer(brokers []string, groupID, topic string, handler Handler) *Cons
 		StartOffset:    kafka.LastOffset,
 		CommitInterval: time.Second,
 	})
-	return &Consumer{r: r, handler: handler}
+	return &Consumer{r: r, handler: handler, log: zap.NewNop()}
+}
+
+// WithCodecs decodes message values into JSON before they reach the handler.
+// Structured CloudEvents are JSON envelopes already and pass through as is.
+func (c *Consumer) WithCodecs(codecs *codec.Topics) *Consumer {
+	c.codecs = codecs
+	return c
+}
+
+// WithDeadLetter logs messages that cannot be decoded and moves them to
+// topic, so one bad message does not stall the partition.
+func (c *Consumer) WithDeadLetter(log *zap.Logger, prod *Producer, topic string) *Consumer {
+	c.log, c.dlq, c.dlqTopic = log, prod, topic
+	return c
 }
 
 func (c *Consumer) Run(ctx context.Context) error {
@@ -34,6 +56,20 @@ func (c *Consumer) Run(ctx context.Context) error {
 			return err
 		}
 
+		if c.codecs != nil {
+			decoded, err := c.decode(msg)
+			if err != nil {
+				if !c.deadLetter(ctx, msg, err) {
+					continue
+				}
+				if err := c.r.CommitMessages(ctx, msg); err != nil {
+					return err
+				}
+				continue
+			}
+			msg = decoded
+		}
+
 		if err := c.handler(ctx, msg); err != nil {
 			continue
 		}
@@ -47,3 +83,43 @@ func (c *Consumer) Run(ctx context.Context) error {
 func (c *Consumer) Close() error {
 	return c.r.Close()
 }
+
+// deadLetter reports whether msg was parked and can be committed. Without a
+// dead letter topic the message is committed past once logged.
+func (c *Consumer) deadLetter(ctx context.Context, msg kafka.Message, cause error) bool {
+	fields := []zap.Field{zap.Error(cause), zap.String("topic", msg.Topic), zap.Int("partition", msg.Partition), zap.Int64("offset", msg.Offset)}
+	if c.dlq == nil || c.dlqTopic == "" {
+		c.log.Error("undecodable message skipped", fields...)
+		return true
+	}
+	if err := c.dlq.Publish(ctx, c.dlqTopic, msg.Key, msg.Value, msg.Headers); err != nil {
+		c.log.Error("undecodable message could not be dead lettered, will be redelivered", append(fields, zap.NamedError("dlq_error", err))...)
+		return false
+	}
+	c.log.Error("undecodable message dead lettered", append(fields, zap.String("dlq_topic", c.dlqTopic))...)
+	return true
+}
+
+func (c *Consumer) decode(msg kafka.Message) (kafka.Message, error) {
+	for _, h := range msg.Headers {
+		if strings.EqualFold(h.Key, "content-type") && strings.HasPrefix(string(h.Value), "application/cloudevents+json") {
+			return msg, nil
+		}
+	}
+
+	value, err := c.codecs.Decode(msg.Topic, msg.Headers, msg.Value)
+	if err != nil {
+		return msg, err
+	}
+
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for _, h := range msg.Headers {
+		if h.Key == codec.SchemaIDHeader || h.Key == "content-type" {
+			continue
+		}
+		headers = append(headers, h)
+	}
+	msg.Value = value
+	msg.Headers = append(headers, kafka.Header{Key: "content-type", Value: []byte("application/json")})
+	return msg, nil
+}
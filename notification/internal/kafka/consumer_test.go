@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/cloudevents"
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/codec"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+const testTopic = "payments.outbox"
+
+func testConsumer(t *testing.T) *Consumer {
+	t.Helper()
+	codecs, err := codec.NewTopics(nil, map[string]string{testTopic: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return (&Consumer{log: zap.NewNop()}).WithCodecs(codecs)
+}
+
+func TestDecodeKeepsStructuredCloudEvents(t *testing.T) {
+	value := []byte(`{"specversion":"1.0","id":"evt-1","source":"decision-orchestrator","type":"PaymentDecisionFinalized",` +
+		`"datacontenttype":"application/json","data":{"payment_id":"p-1","status":"APPROVED"}}`)
+	msg := kafka.Message{
+		Topic:   testTopic,
+		Key:     []byte("p-1"),
+		Value:   value,
+		Headers: []kafka.Header{{Key: "content-type", Value: []byte("application/cloudevents+json; charset=UTF-8")}},
+	}
+
+	decoded, err := testConsumer(t).decode(msg)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	e, ok, err := cloudevents.FromKafka(decoded)
+	if err != nil || !ok {
+		t.Fatalf("FromKafka = ok %v, err %v; want a structured cloudevent", ok, err)
+	}
+	if e.ID != "evt-1" || e.Type != "PaymentDecisionFinalized" {
+		t.Errorf("event = %s/%s, want evt-1/PaymentDecisionFinalized", e.ID, e.Type)
+	}
+	if string(e.Data) != `{"payment_id":"p-1","status":"APPROVED"}` {
+		t.Errorf("data = %s, want the original payload", e.Data)
+	}
+}
+
+func TestDecodeRewritesContentTypeOfPlainJSON(t *testing.T) {
+	msg := kafka.Message{
+		Topic: testTopic,
+		Value: []byte(`{"payment_id":"p-1"}`),
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/vnd.payments+json")},
+			{Key: "event_id", Value: []byte("evt-1")},
+		},
+	}
+
+	decoded, err := testConsumer(t).decode(msg)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	headers := map[string]string{}
+	for _, h := range decoded.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	if headers["content-type"] != "application/json" {
+		t.Errorf("content-type = %q, want application/json", headers["content-type"])
+	}
+	if headers["event_id"] != "evt-1" {
+		t.Errorf("event_id header lost: %v", headers)
+	}
+}
+
+func TestDecodeFailsWithoutRegistry(t *testing.T) {
+	msg := kafka.Message{
+		Topic:   testTopic,
+		Value:   []byte{0, 1, 2},
+		Headers: []kafka.Header{{Key: codec.SchemaIDHeader, Value: []byte("7")}},
+	}
+	if _, err := testConsumer(t).decode(msg); err == nil {
+		t.Fatal("decode succeeded for a schema id with no registry")
+	}
+}
+
+func TestDeadLetterWithoutTopicCommits(t *testing.T) {
+	c := testConsumer(t)
+	if !c.deadLetter(context.Background(), kafka.Message{Topic: testTopic}, errors.New("bad payload")) {
+		t.Error("message without a dead letter topic should be committed past")
+	}
+}
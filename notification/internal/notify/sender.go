@@ -6,6 +6,8 @@ import (
 	"errors"
 	"net/http"
 	"time"
+
+	"github.com/dmehra2102/payments-risk-decisioning/notification/internal/cloudevents"
 )
 
 type Sender struct {
@@ -23,6 +25,18 @@ func New(webhook string, timeout time.Duration) *Sender {
 func (s *Sender) Send(ctx context.Context, payload []byte) error {
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, s.webhook, bytes.NewReader(payload))
 	req.Header.Set("Content-Type", "application/json")
+	return s.do(req)
+}
+
+func (s *Sender) SendCloudEvent(ctx context.Context, event cloudevents.Event, mode string) error {
+	req, err := cloudevents.NewHTTPRequest(ctx, s.webhook, event, mode)
+	if err != nil {
+		return err
+	}
+	return s.do(req)
+}
+
+func (s *Sender) do(req *http.Request) error {
 	resp, err := s.client.Do(req)
 	if err != nil {
 		return err
@@ -0,0 +1,123 @@
+package reasons
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Mode is how much of a decision's explanation a merchant receives.
+type Mode string
+
+const (
+	// ModeFull sends every code, its description and the contributions.
+	ModeFull Mode = "full"
+	// ModeGeneric replaces sensitive and unregistered codes with GenericCode
+	// and drops contributions and the free text reason.
+	ModeGeneric Mode = "generic"
+	// ModeNone strips the explanation entirely.
+	ModeNone Mode = "none"
+)
+
+const GenericCode = "risk_assessment"
+
+var generic = Code{Code: GenericCode, Severity: SeverityMedium, Description: "The payment was assessed as risky."}
+
+// Policy picks the redaction mode per merchant.
+type Policy struct {
+	Default   Mode            `json:"default"`
+	Merchants map[string]Mode `json:"merchants"`
+}
+
+// LoadPolicy reads a Policy from a JSON file. Without a file every merchant
+// gets fallback.
+func LoadPolicy(path string, fallback Mode) (Policy, error) {
+	p := Policy{Default: fallback}
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return Policy{}, err
+		}
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return Policy{}, fmt.Errorf("redaction policy: %w", err)
+		}
+		if p.Default == "" {
+			p.Default = fallback
+		}
+	}
+	if !p.Default.valid() {
+		return Policy{}, fmt.Errorf("redaction policy: unknown default mode %q", p.Default)
+	}
+	for m, mode := range p.Merchants {
+		if !mode.valid() {
+			return Policy{}, fmt.Errorf("redaction policy: merchant %s has unknown mode %q", m, mode)
+		}
+	}
+	return p, nil
+}
+
+func (m Mode) valid() bool {
+	return m == ModeFull || m == ModeGeneric || m == ModeNone
+}
+
+func (p Policy) For(merchantID string) Mode {
+	if m, ok := p.Merchants[merchantID]; ok {
+		return m
+	}
+	return p.Default
+}
+
+// Explain rewrites a decision payload for the merchant it belongs to: the
+// reason codes are filtered by the merchant's mode and described in a
+// "reasons" list. Fields it does not know are left untouched.
+func (r *Registry) Explain(doc map[string]any, mode Mode) {
+	var codes []string
+	if raw, ok := doc["reason_codes"].([]any); ok {
+		for _, c := range raw {
+			if s, ok := c.(string); ok {
+				codes = append(codes, s)
+			}
+		}
+	}
+
+	if mode == ModeNone {
+		delete(doc, "reason_codes")
+		delete(doc, "contributions")
+		doc["reason"] = ""
+		return
+	}
+
+	var visible []Code
+	seen := map[string]bool{}
+	for _, c := range codes {
+		code, ok := r.Lookup(c)
+		if mode == ModeGeneric && (!ok || code.Sensitive) {
+			code = generic
+		} else if !ok {
+			code = Code{Code: c, Severity: SeverityMedium, Description: generic.Description}
+		}
+		if seen[code.Code] {
+			continue
+		}
+		seen[code.Code] = true
+		visible = append(visible, code)
+	}
+
+	if len(visible) > 0 {
+		out := make([]string, len(visible))
+		for i, c := range visible {
+			out[i] = c.Code
+		}
+		doc["reason_codes"] = out
+		doc["reasons"] = visible
+	}
+	if mode == ModeGeneric {
+		delete(doc, "contributions")
+		descriptions := make([]string, len(visible))
+		for i, c := range visible {
+			descriptions[i] = c.Description
+		}
+		doc["reason"] = strings.Join(descriptions, " ")
+	}
+}
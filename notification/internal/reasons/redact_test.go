@@ -0,0 +1,149 @@
+package reasons
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func decision(t *testing.T) map[string]any {
+	t.Helper()
+	var doc map[string]any
+	raw := `{
+		"payment_id": "p-1",
+		"merchant_id": "m-1",
+		"reason": "user_velocity: 7 payments in 10m",
+		"reason_codes": ["amount_over_limit", "user_velocity", "model_v3_feature_9", "amount_over_limit"],
+		"contributions": [{"feature": "velocity_10m", "value": 0.4}]
+	}`
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          Mode
+		codes         []string
+		reason        string
+		contributions bool
+	}{
+		{
+			name:          "full keeps every code and the contributions",
+			mode:          ModeFull,
+			codes:         []string{"amount_over_limit", "user_velocity", "model_v3_feature_9"},
+			reason:        "user_velocity: 7 payments in 10m",
+			contributions: true,
+		},
+		{
+			name:   "generic hides sensitive and unregistered codes",
+			mode:   ModeGeneric,
+			codes:  []string{"amount_over_limit", GenericCode},
+			reason: "The amount exceeds the limit for its currency. The payment was assessed as risky.",
+		},
+		{
+			name: "none strips the explanation",
+			mode: ModeNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decision(t)
+			Default().Explain(doc, tt.mode)
+
+			var codes []string
+			if raw, ok := doc["reason_codes"].([]string); ok {
+				codes = raw
+			}
+			if !reflect.DeepEqual(codes, tt.codes) {
+				t.Errorf("reason_codes = %v, want %v", codes, tt.codes)
+			}
+			if doc["reason"] != tt.reason {
+				t.Errorf("reason = %q, want %q", doc["reason"], tt.reason)
+			}
+			if _, ok := doc["contributions"]; ok != tt.contributions {
+				t.Errorf("contributions present = %v, want %v", ok, tt.contributions)
+			}
+			reasons, _ := doc["reasons"].([]Code)
+			if len(reasons) != len(tt.codes) {
+				t.Errorf("reasons = %v, want one per code", reasons)
+			}
+			if doc["payment_id"] != "p-1" {
+				t.Errorf("payment_id = %v, want other fields untouched", doc["payment_id"])
+			}
+		})
+	}
+}
+
+func TestExplainWithoutCodes(t *testing.T) {
+	doc := map[string]any{"payment_id": "p-1", "reason": "ok"}
+	Default().Explain(doc, ModeFull)
+	if _, ok := doc["reasons"]; ok {
+		t.Errorf("reasons added to a decision without codes: %v", doc)
+	}
+	if doc["reason"] != "ok" {
+		t.Errorf("reason = %q, want it kept in full mode", doc["reason"])
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		fallback Mode
+		want     map[string]Mode
+		wantErr  bool
+	}{
+		{
+			name:     "no file",
+			fallback: ModeGeneric,
+			want:     map[string]Mode{"m-1": ModeGeneric},
+		},
+		{
+			name:     "merchant overrides",
+			file:     `{"default": "none", "merchants": {"m-1": "full"}}`,
+			fallback: ModeGeneric,
+			want:     map[string]Mode{"m-1": ModeFull, "m-2": ModeNone},
+		},
+		{
+			name:     "default falls back",
+			file:     `{"merchants": {"m-1": "none"}}`,
+			fallback: ModeFull,
+			want:     map[string]Mode{"m-1": ModeNone, "m-2": ModeFull},
+		},
+		{name: "unknown default", file: `{"default": "partial"}`, fallback: ModeFull, wantErr: true},
+		{name: "unknown merchant mode", file: `{"merchants": {"m-1": "all"}}`, fallback: ModeFull, wantErr: true},
+		{name: "unknown fallback", fallback: "all", wantErr: true},
+		{name: "invalid json", file: `{`, fallback: ModeFull, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			if tt.file != "" {
+				path = filepath.Join(t.TempDir(), "redaction.json")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p, err := LoadPolicy(path, tt.fallback)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("LoadPolicy succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for merchant, want := range tt.want {
+				if got := p.For(merchant); got != want {
+					t.Errorf("For(%s) = %s, want %s", merchant, got, want)
+				}
+			}
+		})
+	}
+}
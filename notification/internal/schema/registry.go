@@ -0,0 +1,166 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatProtobuf Format = "protobuf"
+	FormatAvro     Format = "avro"
+)
+
+var ErrNotFound = errors.New("schema not found")
+
+type Schema struct {
+	ID      int    `json:"id"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	Format  Format `json:"format"`
+	// Definition holds the JSON Schema or Avro schema text, or the raw
+	// FileDescriptorSet bytes for protobuf.
+	Definition []byte `json:"-"`
+	// Message is the fully qualified protobuf message name.
+	Message string `json:"message,omitempty"`
+}
+
+type Registry interface {
+	ByID(id int) (Schema, error)
+	Latest(subject string) (Schema, error)
+	Versions(subject string) ([]Schema, error)
+	Subjects() []string
+}
+
+// FileRegistry is a read-only registry backed by a JSON index on local disk,
+// used in tests and in environments without a registry service.
+type FileRegistry struct {
+	mu       sync.RWMutex
+	byID     map[int]Schema
+	subjects map[string][]Schema
+}
+
+type fileIndex struct {
+	Schemas []struct {
+		Schema
+		Inline string `json:"schema"`
+		File   string `json:"schema_file"`
+	} `json:"schemas"`
+}
+
+func LoadFile(path string) (*FileRegistry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var idx fileIndex
+	if err := json.Unmarshal(raw, &idx); err != nil {
+		return nil, fmt.Errorf("parse schema registry %s: %w", path, err)
+	}
+
+	r := &FileRegistry{byID: map[int]Schema{}, subjects: map[string][]Schema{}}
+	for _, entry := range idx.Schemas {
+		s := entry.Schema
+		switch {
+		case entry.File != "":
+			file := entry.File
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(path), file)
+			}
+			if s.Definition, err = os.ReadFile(file); err != nil {
+				return nil, err
+			}
+		case entry.Inline != "":
+			s.Definition = []byte(entry.Inline)
+		}
+		if err := r.add(s); err != nil {
+			return nil, err
+		}
+	}
+
+	for subject := range r.subjects {
+		sort.Slice(r.subjects[subject], func(i, j int) bool {
+			return r.subjects[subject][i].Version < r.subjects[subject][j].Version
+		})
+	}
+	return r, nil
+}
+
+func (r *FileRegistry) add(s Schema) error {
+	if s.ID <= 0 || s.Subject == "" || s.Version <= 0 {
+		return fmt.Errorf("schema entry requires id, subject and version: %+v", s)
+	}
+	switch s.Format {
+	case FormatJSON, FormatAvro:
+	case FormatProtobuf:
+		if s.Message == "" {
+			return fmt.Errorf("protobuf schema %d requires a message name", s.ID)
+		}
+	default:
+		return fmt.Errorf("schema %d has unknown format %q", s.ID, s.Format)
+	}
+	if _, dup := r.byID[s.ID]; dup {
+		return fmt.Errorf("duplicate schema id %d", s.ID)
+	}
+	for _, existing := range r.subjects[s.Subject] {
+		if existing.Version == s.Version {
+			return fmt.Errorf("duplicate version %d for subject %s", s.Version, s.Subject)
+		}
+	}
+
+	r.byID[s.ID] = s
+	r.subjects[s.Subject] = append(r.subjects[s.Subject], s)
+	return nil
+}
+
+func (r *FileRegistry) ByID(id int) (Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.byID[id]
+	if !ok {
+		return Schema{}, fmt.Errorf("%w: id %d", ErrNotFound, id)
+	}
+	return s, nil
+}
+
+func (r *FileRegistry) Latest(subject string) (Schema, error) {
+	versions, err := r.Versions(subject)
+	if err != nil {
+		return Schema{}, err
+	}
+	return versions[len(versions)-1], nil
+}
+
+func (r *FileRegistry) Versions(subject string) ([]Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.subjects[subject]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: subject %s", ErrNotFound, subject)
+	}
+	return append([]Schema(nil), versions...), nil
+}
+
+func (r *FileRegistry) Subjects() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.subjects))
+	for subject := range r.subjects {
+		out = append(out, subject)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// TopicSubject follows the topic name strategy for message values.
+func TopicSubject(topic string) string {
+	return topic + "-value"
+}